@@ -0,0 +1,309 @@
+// Package ani decodes and encodes Windows animated cursors (RIFF ACON, .ani) on the riffbin chunk tree.
+// The anih header, the rate and seq  chunks are decoded into the typed values,
+// and the frames in LIST-fram are kept as the icon sub-chunks of the raw ICO/CUR files.
+package ani
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/karupanerura/riffbin"
+)
+
+var (
+	aconType = [4]byte{'A', 'C', 'O', 'N'}
+	framType = [4]byte{'f', 'r', 'a', 'm'}
+	infoType = [4]byte{'I', 'N', 'F', 'O'}
+	anihID   = [4]byte{'a', 'n', 'i', 'h'}
+	rateID   = [4]byte{'r', 'a', 't', 'e'}
+	seqID    = [4]byte{'s', 'e', 'q', ' '}
+	iconID   = [4]byte{'i', 'c', 'o', 'n'}
+)
+
+const (
+	anihBytes = 36
+	stepBytes = 4
+)
+
+// Flags is the attributes of the animated cursor. (bfAttributes)
+type Flags uint32
+
+const (
+	// FlagIcon means the frames are the ICO/CUR files. The frames are the raw bitmaps if it is not set.
+	FlagIcon Flags = 1 << iota
+
+	// FlagSequence means the seq  chunk is present.
+	FlagSequence
+)
+
+// Header is the anih chunk except the frame and the step counts, which are derived from Cursor.
+type Header struct {
+	Width    uint32
+	Height   uint32
+	BitCount uint32
+	Planes   uint32
+
+	// DisplayRate is the default display rate of the steps in jiffies (1/60 seconds).
+	DisplayRate uint32
+
+	// Flags is the attributes. FlagSequence is set by the presence of Cursor.Sequence on encoding.
+	Flags Flags
+}
+
+// Cursor is an animated cursor.
+type Cursor struct {
+	Header Header
+
+	// Rates is the rate chunk of the display rates of the steps in jiffies, or nil to use Header.DisplayRate.
+	Rates []uint32
+
+	// Sequence is the seq  chunk of the frame indices of the steps, or nil to show Frames in order.
+	Sequence []uint32
+
+	// Frames is the icon chunks in LIST-fram of the raw ICO/CUR files.
+	// They are *riffbin.InStreamSubChunk if the cursor is read by Read, and they are streamed as it is by WriteTo.
+	// The chunk IDs of *riffbin.InStreamSubChunk and *riffbin.OnMemorySubChunk are ignored on encoding, so &riffbin.OnMemorySubChunk{Payload: ico} is enough to compose a frame.
+	// The other sub-chunks must be icon chunks.
+	Frames []riffbin.SubChunk
+
+	// Info is the sub-chunks of LIST-INFO. (e.g. INAM, IART)
+	Info []riffbin.Chunk
+}
+
+// Read reads the animated cursor from r by riffbin.ReadSections with the pad bytes.
+func Read(r riffbin.PartialReader) (*Cursor, error) {
+	c, err := riffbin.ReadSections(r, riffbin.WithReadPadding())
+	if err != nil {
+		return nil, err
+	}
+	return Decode(c)
+}
+
+// Decode decodes the animated cursor from the chunk tree.
+// The sub-chunk payloads are read with the own cursor, so c can be still written after decoding.
+func Decode(c *riffbin.RIFFChunk) (*Cursor, error) {
+	if c.FormType != aconType {
+		return nil, fmt.Errorf("form type %q is not ACON: %w", string(c.FormType[:]), riffbin.ErrInvalidFormat)
+	}
+
+	cur := &Cursor{}
+	var anih []byte
+	for _, chunk := range c.Payload {
+		switch cc := chunk.(type) {
+		case *riffbin.ListChunk:
+			chunks, err := cc.Chunks()
+			if err != nil {
+				return nil, err
+			}
+
+			switch cc.ListType {
+			case framType:
+				for _, fc := range chunks {
+					sc, ok := fc.(riffbin.SubChunk)
+					if !ok || !bytes.Equal(sc.ChunkID(), iconID[:]) {
+						return nil, fmt.Errorf("%q chunk in LIST-fram is not an icon: %w", string(fc.ChunkID()), riffbin.ErrInvalidFormat)
+					}
+					cur.Frames = append(cur.Frames, sc)
+				}
+			case infoType:
+				cur.Info = chunks
+			}
+		case riffbin.SubChunk:
+			var id [4]byte
+			copy(id[:], cc.ChunkID())
+			if id != anihID && id != rateID && id != seqID {
+				continue
+			}
+
+			b, err := riffbin.ReadPayload(cc)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", string(id[:]), err)
+			}
+			switch id {
+			case anihID:
+				anih = b
+			case rateID:
+				if cur.Rates, err = decodeSteps("rate", b); err != nil {
+					return nil, err
+				}
+			case seqID:
+				if cur.Sequence, err = decodeSteps("seq ", b); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	if anih == nil {
+		return nil, fmt.Errorf("anih chunk is not found: %w", riffbin.ErrInvalidFormat)
+	}
+
+	frames, steps, err := cur.Header.decode(anih)
+	if err != nil {
+		return nil, err
+	}
+	if int(frames) != len(cur.Frames) {
+		return nil, fmt.Errorf("anih frames %d mismatches %d icon chunks: %w", frames, len(cur.Frames), riffbin.ErrInvalidFormat)
+	}
+	if cur.Rates != nil && int(steps) != len(cur.Rates) {
+		return nil, fmt.Errorf("anih steps %d mismatches %d rates: %w", steps, len(cur.Rates), riffbin.ErrInvalidFormat)
+	}
+	if cur.Sequence != nil {
+		if int(steps) != len(cur.Sequence) {
+			return nil, fmt.Errorf("anih steps %d mismatches %d sequence: %w", steps, len(cur.Sequence), riffbin.ErrInvalidFormat)
+		}
+		for i, f := range cur.Sequence {
+			if f >= frames {
+				return nil, fmt.Errorf("sequence[%d] frame %d is out of %d frames: %w", i, f, frames, riffbin.ErrInvalidFormat)
+			}
+		}
+	}
+	return cur, nil
+}
+
+func (h *Header) decode(b []byte) (frames, steps uint32, err error) {
+	if len(b) < anihBytes {
+		return 0, 0, fmt.Errorf("anih chunk size %d is too short: %w", len(b), riffbin.ErrInvalidFormat)
+	}
+	if size := binary.LittleEndian.Uint32(b[0:]); size != anihBytes {
+		return 0, 0, fmt.Errorf("anih header size %d is not %d: %w", size, anihBytes, riffbin.ErrInvalidFormat)
+	}
+
+	frames = binary.LittleEndian.Uint32(b[4:])
+	steps = binary.LittleEndian.Uint32(b[8:])
+	h.Width = binary.LittleEndian.Uint32(b[12:])
+	h.Height = binary.LittleEndian.Uint32(b[16:])
+	h.BitCount = binary.LittleEndian.Uint32(b[20:])
+	h.Planes = binary.LittleEndian.Uint32(b[24:])
+	h.DisplayRate = binary.LittleEndian.Uint32(b[28:])
+	h.Flags = Flags(binary.LittleEndian.Uint32(b[32:]))
+	return frames, steps, nil
+}
+
+func decodeSteps(name string, b []byte) ([]uint32, error) {
+	if len(b)%stepBytes != 0 {
+		return nil, fmt.Errorf("%s chunk size %d is not a multiple of %d: %w", name, len(b), stepBytes, riffbin.ErrInvalidFormat)
+	}
+	steps := make([]uint32, 0, len(b)/stepBytes)
+	for i := 0; i < len(b); i += stepBytes {
+		steps = append(steps, binary.LittleEndian.Uint32(b[i:]))
+	}
+	return steps, nil
+}
+
+// Steps returns the number of the steps of the animation.
+func (cur *Cursor) Steps() int {
+	if cur.Sequence != nil {
+		return len(cur.Sequence)
+	}
+	return len(cur.Frames)
+}
+
+// Encode encodes the cursor into the chunk tree.
+// The frames are shared with cur by the own cursor if they are *riffbin.InStreamSubChunk or *riffbin.OnMemorySubChunk.
+func (cur *Cursor) Encode() (*riffbin.RIFFChunk, error) {
+	steps := cur.Steps()
+	if cur.Rates != nil && len(cur.Rates) != steps {
+		return nil, fmt.Errorf("%d rates mismatches %d steps", len(cur.Rates), steps)
+	}
+	for i, f := range cur.Sequence {
+		if int(f) >= len(cur.Frames) {
+			return nil, fmt.Errorf("sequence[%d] frame %d is out of %d frames", i, f, len(cur.Frames))
+		}
+	}
+
+	payload := []riffbin.Chunk{
+		&riffbin.OnMemorySubChunk{ID: anihID, Payload: cur.Header.encode(uint32(len(cur.Frames)), uint32(steps), cur.Sequence != nil)},
+	}
+	if cur.Rates != nil {
+		payload = append(payload, &riffbin.OnMemorySubChunk{ID: rateID, Payload: encodeSteps(cur.Rates)})
+	}
+	if cur.Sequence != nil {
+		payload = append(payload, &riffbin.OnMemorySubChunk{ID: seqID, Payload: encodeSteps(cur.Sequence)})
+	}
+	if len(cur.Info) != 0 {
+		payload = append(payload, &riffbin.ListChunk{ListType: infoType, Payload: cur.Info})
+	}
+
+	frames := make([]riffbin.Chunk, 0, len(cur.Frames))
+	for i, f := range cur.Frames {
+		fc, err := frameChunk(f)
+		if err != nil {
+			return nil, fmt.Errorf("frame[%d]: %w", i, err)
+		}
+		frames = append(frames, fc)
+	}
+	payload = append(payload, &riffbin.ListChunk{ListType: framType, Payload: frames})
+
+	return &riffbin.RIFFChunk{FormType: aconType, Payload: payload}, nil
+}
+
+func (h *Header) encode(frames, steps uint32, sequence bool) []byte {
+	flags := h.Flags &^ FlagSequence
+	if sequence {
+		flags |= FlagSequence
+	}
+
+	b := make([]byte, 0, anihBytes)
+	b = appendUint32(b, anihBytes)
+	b = appendUint32(b, frames)
+	b = appendUint32(b, steps)
+	b = appendUint32(b, h.Width)
+	b = appendUint32(b, h.Height)
+	b = appendUint32(b, h.BitCount)
+	b = appendUint32(b, h.Planes)
+	b = appendUint32(b, h.DisplayRate)
+	b = appendUint32(b, uint32(flags))
+	return b
+}
+
+func encodeSteps(steps []uint32) []byte {
+	b := make([]byte, 0, len(steps)*stepBytes)
+	for _, s := range steps {
+		b = appendUint32(b, s)
+	}
+	return b
+}
+
+// WriteTo writes the cursor to w by riffbin.CompletedChunkWriter with the pad bytes.
+func (cur *Cursor) WriteTo(w io.Writer) (int64, error) {
+	c, err := cur.Encode()
+	if err != nil {
+		return 0, err
+	}
+	return riffbin.NewCompletedChunkWriter(w, riffbin.WithWritePadding()).Write(c)
+}
+
+// Frame returns the raw ICO/CUR file of the i-th frame.
+// It reads the frame with the own cursor, so cur can be still written after reading.
+func (cur *Cursor) Frame(i int) ([]byte, error) {
+	if i < 0 || i >= len(cur.Frames) {
+		return nil, fmt.Errorf("frame index %d is out of range", i)
+	}
+	return riffbin.ReadPayload(cur.Frames[i])
+}
+
+// frameChunk returns the icon sub-chunk to write the frame.
+// The other sub-chunks cannot be renamed, so they must be icon chunks already.
+func frameChunk(c riffbin.SubChunk) (riffbin.SubChunk, error) {
+	switch cc := c.(type) {
+	case *riffbin.InStreamSubChunk:
+		return &riffbin.InStreamSubChunk{ID: iconID, SectionReader: io.NewSectionReader(cc.SectionReader, 0, cc.Size())}, nil
+	case *riffbin.OnMemorySubChunk:
+		return &riffbin.OnMemorySubChunk{ID: iconID, Payload: cc.Payload}, nil
+	case nil:
+		return nil, errors.New("frame is nil")
+	}
+	if !bytes.Equal(c.ChunkID(), iconID[:]) {
+		return nil, fmt.Errorf("%q chunk is not an icon", string(c.ChunkID()))
+	}
+	return c, nil
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	return append(b, buf[:]...)
+}
@@ -0,0 +1,205 @@
+package ani_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/karupanerura/riffbin"
+	"github.com/karupanerura/riffbin/ani"
+)
+
+// the ICONDIR of the CUR files without the images, which is enough as the opaque frames
+var (
+	cur0 = []byte{0x00, 0x00, 0x02, 0x00, 0x00, 0x00}
+	cur1 = []byte{0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0xFF, 0xFF}
+)
+
+func TestCursor(t *testing.T) {
+	t.Parallel()
+
+	cur := &ani.Cursor{
+		Header:   ani.Header{DisplayRate: 10, Flags: ani.FlagIcon},
+		Rates:    []uint32{10, 20, 30},
+		Sequence: []uint32{0, 1, 0},
+		Frames: []riffbin.SubChunk{
+			&riffbin.OnMemorySubChunk{Payload: cur0},
+			&riffbin.OnMemorySubChunk{Payload: cur1},
+		},
+		Info: []riffbin.Chunk{&riffbin.OnMemorySubChunk{ID: [4]byte{'I', 'N', 'A', 'M'}, Payload: []byte("busy\x00\x00")}},
+	}
+	var buf bytes.Buffer
+	if _, err := cur.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	b := buf.Bytes()
+	if !bytes.Equal(b[8:12], []byte("ACON")) || !bytes.Equal(b[12:16], []byte("anih")) {
+		t.Fatalf("unexpected header: %q", b[:16])
+	}
+	anih := b[20:56]
+	expectedAnih := []byte{
+		36, 0, 0, 0, // cbSize
+		2, 0, 0, 0, // nFrames
+		3, 0, 0, 0, // nSteps
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, // iWidth, iHeight, iBitCount, nPlanes
+		10, 0, 0, 0, // iDispRate
+		3, 0, 0, 0, // bfAttributes (AF_ICON|AF_SEQUENCE)
+	}
+	if df := cmp.Diff(anih, expectedAnih); df != "" {
+		t.Errorf("anih diff = %s", df)
+	}
+
+	got, err := ani.Read(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if df := cmp.Diff(got.Header, ani.Header{DisplayRate: 10, Flags: ani.FlagIcon | ani.FlagSequence}); df != "" {
+		t.Errorf("header diff = %s", df)
+	}
+	if df := cmp.Diff(got.Rates, cur.Rates); df != "" {
+		t.Errorf("rates diff = %s", df)
+	}
+	if df := cmp.Diff(got.Sequence, cur.Sequence); df != "" {
+		t.Errorf("sequence diff = %s", df)
+	}
+	if got.Steps() != 3 || len(got.Info) != 1 {
+		t.Errorf("unexpected cursor: %+v", got)
+	}
+	for i, expected := range [][]byte{cur0, cur1} {
+		if _, ok := got.Frames[i].(*riffbin.InStreamSubChunk); !ok {
+			t.Errorf("frame[%d] is %T", i, got.Frames[i])
+		}
+		f, err := got.Frame(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(f, expected) {
+			t.Errorf("unexpected frame[%d]: %v", i, f)
+		}
+	}
+	if _, err := got.Frame(2); err == nil {
+		t.Error("error is expected for the out of range frame")
+	}
+
+	// the decoded cursor can be written again with the streamed frames
+	var rewritten bytes.Buffer
+	if _, err := got.WriteTo(&rewritten); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(rewritten.Bytes(), b) {
+		t.Errorf("unexpected rewritten bytes: %v", rewritten.Bytes())
+	}
+}
+
+func TestCursorWithoutSequence(t *testing.T) {
+	t.Parallel()
+
+	cur := &ani.Cursor{
+		Header: ani.Header{DisplayRate: 5, Flags: ani.FlagIcon | ani.FlagSequence},
+		Frames: []riffbin.SubChunk{&riffbin.OnMemorySubChunk{Payload: cur0}},
+	}
+	var buf bytes.Buffer
+	if _, err := cur.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ani.Read(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Header.Flags != ani.FlagIcon || got.Rates != nil || got.Sequence != nil || got.Steps() != 1 {
+		t.Errorf("unexpected cursor: %+v", got)
+	}
+
+	if _, err := (&ani.Cursor{Rates: []uint32{1, 2}, Frames: cur.Frames}).Encode(); err == nil {
+		t.Error("error is expected for the rates mismatched with the steps")
+	}
+	if _, err := (&ani.Cursor{Sequence: []uint32{1}, Frames: cur.Frames}).Encode(); err == nil {
+		t.Error("error is expected for the sequence out of the frames")
+	}
+	foreign := riffbin.NewIncompleteSubChunk([4]byte{'d', 'a', 't', 'a'}, bytes.NewReader(cur0))
+	if _, err := (&ani.Cursor{Frames: []riffbin.SubChunk{foreign}}).Encode(); err == nil {
+		t.Error("error is expected for the frame of the foreign chunk")
+	}
+}
+
+func TestCursorPadded(t *testing.T) {
+	t.Parallel()
+
+	// the odd-sized icon chunk is followed by the pad byte
+	odd := cur1[:7]
+	cur := &ani.Cursor{
+		Header: ani.Header{DisplayRate: 5, Flags: ani.FlagIcon},
+		Frames: []riffbin.SubChunk{
+			&riffbin.OnMemorySubChunk{Payload: odd},
+			&riffbin.OnMemorySubChunk{Payload: cur0},
+		},
+	}
+	var buf bytes.Buffer
+	if _, err := cur.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := riffbin.ReadSections(bytes.NewReader(buf.Bytes()), riffbin.WithReadPadding()); err != nil {
+		t.Fatalf("written cursor is not padded: %v", err)
+	}
+
+	got, err := ani.Read(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, expected := range [][]byte{odd, cur0} {
+		if f, err := got.Frame(i); err != nil || !bytes.Equal(f, expected) {
+			t.Errorf("unexpected frame[%d]: %v, %v", i, f, err)
+		}
+	}
+
+	// the frames are read with the own cursor, so they can be written twice
+	for i := 0; i < 2; i++ {
+		var rewritten bytes.Buffer
+		if _, err := got.WriteTo(&rewritten); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(rewritten.Bytes(), buf.Bytes()) {
+			t.Errorf("unexpected rewritten bytes: %v", rewritten.Bytes())
+		}
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	t.Parallel()
+
+	anih := func(frames, steps byte) riffbin.Chunk {
+		b := make([]byte, 36)
+		b[0], b[4], b[8] = 36, frames, steps
+		return &riffbin.OnMemorySubChunk{ID: [4]byte{'a', 'n', 'i', 'h'}, Payload: b}
+	}
+	fram := &riffbin.ListChunk{ListType: [4]byte{'f', 'r', 'a', 'm'}, Payload: []riffbin.Chunk{
+		&riffbin.OnMemorySubChunk{ID: [4]byte{'i', 'c', 'o', 'n'}, Payload: cur0},
+	}}
+	acon := [4]byte{'A', 'C', 'O', 'N'}
+
+	for name, c := range map[string]*riffbin.RIFFChunk{
+		"NotACON":   {FormType: [4]byte{'W', 'A', 'V', 'E'}},
+		"NoHeader":  {FormType: acon, Payload: []riffbin.Chunk{fram}},
+		"ShortHead": {FormType: acon, Payload: []riffbin.Chunk{&riffbin.OnMemorySubChunk{ID: [4]byte{'a', 'n', 'i', 'h'}, Payload: []byte{36}}, fram}},
+		"Frames":    {FormType: acon, Payload: []riffbin.Chunk{anih(2, 1), fram}},
+		"Rates": {FormType: acon, Payload: []riffbin.Chunk{anih(1, 1), fram,
+			&riffbin.OnMemorySubChunk{ID: [4]byte{'r', 'a', 't', 'e'}, Payload: []byte{1, 0, 0, 0, 1, 0, 0, 0}},
+		}},
+		"BrokenRates": {FormType: acon, Payload: []riffbin.Chunk{anih(1, 1), fram,
+			&riffbin.OnMemorySubChunk{ID: [4]byte{'r', 'a', 't', 'e'}, Payload: []byte{1, 0}},
+		}},
+		"Sequence": {FormType: acon, Payload: []riffbin.Chunk{anih(1, 1), fram,
+			&riffbin.OnMemorySubChunk{ID: [4]byte{'s', 'e', 'q', ' '}, Payload: []byte{1, 0, 0, 0}},
+		}},
+		"ForeignFrame": {FormType: acon, Payload: []riffbin.Chunk{anih(1, 1), &riffbin.ListChunk{ListType: [4]byte{'f', 'r', 'a', 'm'}, Payload: []riffbin.Chunk{
+			&riffbin.OnMemorySubChunk{ID: [4]byte{'i', 'c', 'o', 'n'}, Payload: cur0},
+			&riffbin.OnMemorySubChunk{ID: [4]byte{'J', 'U', 'N', 'K'}, Payload: []byte{0}},
+		}}}},
+	} {
+		if _, err := ani.Decode(c); !errors.Is(err, riffbin.ErrInvalidFormat) {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,356 @@
+package riffbin
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+var (
+	bextID = [idBytes]byte{'b', 'e', 'x', 't'}
+	ixmlID = [idBytes]byte{'i', 'X', 'M', 'L'}
+	axmlID = [idBytes]byte{'a', 'x', 'm', 'l'}
+	chnaID = [idBytes]byte{'c', 'h', 'n', 'a'}
+)
+
+// broadcastExtensionBytes is the size of the fixed fields of the bext chunk.
+const broadcastExtensionBytes = 602
+
+// BroadcastExtension is the payload of the bext chunk of Broadcast Wave Format. (EBU Tech 3285)
+// The strings are ASCII, and they are padded with NUL bytes to the fixed length in the chunk.
+type BroadcastExtension struct {
+	// Description is the description of the sound sequence. (up to 256 bytes)
+	Description string
+
+	// Originator is the name of the originator. (up to 32 bytes)
+	Originator string
+
+	// OriginatorReference is the unambiguous reference allocated by the originating organization. (up to 32 bytes)
+	OriginatorReference string
+
+	// OriginationDate is the date of creation as "yyyy:mm:dd". (up to 10 bytes)
+	OriginationDate string
+
+	// OriginationTime is the time of creation as "hh:mm:ss". (up to 8 bytes)
+	OriginationTime string
+
+	// TimeReference is the sample count since midnight of the first sample.
+	TimeReference uint64
+
+	// Version is the version of the bext chunk.
+	Version uint16
+
+	// UMID is SMPTE ST 330 Unique Material Identifier.
+	UMID [64]byte
+
+	// LoudnessValue is the integrated loudness in LUFS multiplied by 100. (version 2 or later)
+	LoudnessValue int16
+
+	// LoudnessRange is the loudness range in LU multiplied by 100. (version 2 or later)
+	LoudnessRange int16
+
+	// MaxTruePeakLevel is the maximum true peak level in dBTP multiplied by 100. (version 2 or later)
+	MaxTruePeakLevel int16
+
+	// MaxMomentaryLoudness is the highest value of the momentary loudness level in LUFS multiplied by 100. (version 2 or later)
+	MaxMomentaryLoudness int16
+
+	// MaxShortTermLoudness is the highest value of the short-term loudness level in LUFS multiplied by 100. (version 2 or later)
+	MaxShortTermLoudness int16
+
+	// CodingHistory is the coding history as the lines terminated by CR/LF.
+	CodingHistory string
+}
+
+// stringField is a fixed length string field padded with NUL bytes.
+type stringField struct {
+	name string
+	s    *string
+	size int
+}
+
+func (b *BroadcastExtension) stringFields() []stringField {
+	return []stringField{
+		{"Description", &b.Description, 256},
+		{"Originator", &b.Originator, 32},
+		{"OriginatorReference", &b.OriginatorReference, 32},
+		{"OriginationDate", &b.OriginationDate, 10},
+		{"OriginationTime", &b.OriginationTime, 8},
+	}
+}
+
+// MarshalBinary encodes b as the payload of the bext chunk.
+// The payload is padded with a NUL byte to be even.
+func (b *BroadcastExtension) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, broadcastExtensionBytes, padEven(broadcastExtensionBytes+len(b.CodingHistory)))
+
+	pos := 0
+	for _, field := range b.stringFields() {
+		if len(*field.s) > field.size {
+			return nil, fmt.Errorf("%s exceeds %d bytes", field.name, field.size)
+		}
+		copy(buf[pos:], *field.s)
+		pos += field.size
+	}
+	binary.LittleEndian.PutUint64(buf[pos:], b.TimeReference)
+	binary.LittleEndian.PutUint16(buf[pos+8:], b.Version)
+	copy(buf[pos+10:], b.UMID[:])
+	pos += 10 + len(b.UMID)
+	for i, v := range []int16{b.LoudnessValue, b.LoudnessRange, b.MaxTruePeakLevel, b.MaxMomentaryLoudness, b.MaxShortTermLoudness} {
+		binary.LittleEndian.PutUint16(buf[pos+i*2:], uint16(v))
+	}
+	// the rest is reserved
+
+	buf = append(buf, b.CodingHistory...)
+	return buf[:cap(buf)], nil
+}
+
+// UnmarshalBinary decodes the payload of the bext chunk into b.
+func (b *BroadcastExtension) UnmarshalBinary(data []byte) error {
+	if len(data) < broadcastExtensionBytes {
+		return fmt.Errorf("bext chunk is too short: %w", ErrInvalidFormat)
+	}
+
+	pos := 0
+	for _, field := range b.stringFields() {
+		*field.s = trimNUL(data[pos : pos+field.size])
+		pos += field.size
+	}
+	b.TimeReference = binary.LittleEndian.Uint64(data[pos:])
+	b.Version = binary.LittleEndian.Uint16(data[pos+8:])
+	copy(b.UMID[:], data[pos+10:])
+	pos += 10 + len(b.UMID)
+	for i, v := range []*int16{&b.LoudnessValue, &b.LoudnessRange, &b.MaxTruePeakLevel, &b.MaxMomentaryLoudness, &b.MaxShortTermLoudness} {
+		*v = int16(binary.LittleEndian.Uint16(data[pos+i*2:]))
+	}
+
+	b.CodingHistory = trimNUL(data[broadcastExtensionBytes:])
+	return nil
+}
+
+// chnaEntryBytes is the size of an entry of the chna chunk.
+const chnaEntryBytes = 40
+
+// ADMTrackUID is an entry of the chna chunk which assigns the track to the ADM audioTrackUID.
+type ADMTrackUID struct {
+	// TrackIndex is the 1-based index of the track in the data chunk.
+	TrackIndex uint16
+
+	// UID is the audioTrackUID. (e.g. "ATU_00000001")
+	UID string
+
+	// TrackFormatRef is the reference to the audioTrackFormat or audioChannelFormat. (e.g. "AT_00010001_01")
+	TrackFormatRef string
+
+	// PackFormatRef is the reference to the audioPackFormat. (e.g. "AP_00010002")
+	PackFormatRef string
+}
+
+// ChannelAssignment is the payload of the chna chunk of ADM. (ITU-R BS.2076 / EBU Tech 3285 s7)
+type ChannelAssignment struct {
+	// UIDs are the assigned audioTrackUIDs. The number of the tracks is counted from the distinct TrackIndex.
+	UIDs []ADMTrackUID
+}
+
+func (e *ADMTrackUID) fields() []stringField {
+	return []stringField{
+		{"UID", &e.UID, 12},
+		{"TrackFormatRef", &e.TrackFormatRef, 14},
+		{"PackFormatRef", &e.PackFormatRef, 11},
+	}
+}
+
+// MarshalBinary encodes a as the payload of the chna chunk.
+func (a *ChannelAssignment) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 4+len(a.UIDs)*chnaEntryBytes)
+
+	tracks := map[uint16]bool{}
+	for i := range a.UIDs {
+		e := &a.UIDs[i]
+		tracks[e.TrackIndex] = true
+
+		pos := 4 + i*chnaEntryBytes
+		binary.LittleEndian.PutUint16(buf[pos:], e.TrackIndex)
+		pos += 2
+		for _, field := range e.fields() {
+			if len(*field.s) > field.size {
+				return nil, fmt.Errorf("UIDs[%d]: %s exceeds %d bytes", i, field.name, field.size)
+			}
+			copy(buf[pos:], *field.s)
+			pos += field.size
+		}
+		// the last byte is padding
+	}
+	binary.LittleEndian.PutUint16(buf[0:], uint16(len(tracks)))
+	binary.LittleEndian.PutUint16(buf[2:], uint16(len(a.UIDs)))
+	return buf, nil
+}
+
+// UnmarshalBinary decodes the payload of the chna chunk into a.
+// The unused entries allocated after the UIDs are ignored.
+func (a *ChannelAssignment) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return fmt.Errorf("chna chunk is too short: %w", ErrInvalidFormat)
+	}
+	n := int(binary.LittleEndian.Uint16(data[2:]))
+	if len(data) < 4+n*chnaEntryBytes {
+		return fmt.Errorf("chna chunk is too short for %d UIDs: %w", n, ErrInvalidFormat)
+	}
+
+	a.UIDs = make([]ADMTrackUID, n)
+	for i := range a.UIDs {
+		e := &a.UIDs[i]
+
+		pos := 4 + i*chnaEntryBytes
+		e.TrackIndex = binary.LittleEndian.Uint16(data[pos:])
+		pos += 2
+		for _, field := range e.fields() {
+			*field.s = trimNUL(data[pos : pos+field.size])
+			pos += field.size
+		}
+	}
+	return nil
+}
+
+// BroadcastMetadata is the metadata chunks of Broadcast Wave Format.
+// The nil fields are the absent chunks.
+type BroadcastMetadata struct {
+	// Extension is the bext chunk.
+	Extension *BroadcastExtension
+
+	// IXML is the XML document of the iXML chunk.
+	IXML []byte
+
+	// AXML is the ADM XML document of the axml chunk.
+	AXML []byte
+
+	// Channels is the chna chunk.
+	Channels *ChannelAssignment
+}
+
+// ReadBroadcastMetadata decodes the first bext, iXML, axml and chna chunks in the root of the WAVE. (e.g. read by ReadSections)
+// The sub-chunk payloads are read with the own cursor, so c can be still written after reading.
+func ReadBroadcastMetadata(c *RIFFChunk) (*BroadcastMetadata, error) {
+	if c.FormType != waveType {
+		return nil, fmt.Errorf("form type %q is not WAVE: %w", string(c.FormType[:]), ErrInvalidFormat)
+	}
+
+	m := &BroadcastMetadata{}
+	for _, chunk := range c.Payload {
+		sc, ok := chunk.(SubChunk)
+		if !ok {
+			continue
+		}
+
+		var id [idBytes]byte
+		copy(id[:], sc.ChunkID())
+		switch {
+		case id == bextID && m.Extension == nil:
+			b, err := ReadPayload(sc)
+			if err != nil {
+				return nil, fmt.Errorf("bext: %w", err)
+			}
+			m.Extension = &BroadcastExtension{}
+			if err := m.Extension.UnmarshalBinary(b); err != nil {
+				return nil, err
+			}
+		case id == ixmlID && m.IXML == nil:
+			b, err := ReadPayload(sc)
+			if err != nil {
+				return nil, fmt.Errorf("iXML: %w", err)
+			}
+			m.IXML = bytes.TrimRight(b, "\x00")
+		case id == axmlID && m.AXML == nil:
+			b, err := ReadPayload(sc)
+			if err != nil {
+				return nil, fmt.Errorf("axml: %w", err)
+			}
+			m.AXML = bytes.TrimRight(b, "\x00")
+		case id == chnaID && m.Channels == nil:
+			b, err := ReadPayload(sc)
+			if err != nil {
+				return nil, fmt.Errorf("chna: %w", err)
+			}
+			m.Channels = &ChannelAssignment{}
+			if err := m.Channels.UnmarshalBinary(b); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return m, nil
+}
+
+// SetBroadcastMetadata encodes the non-nil fields of m into the chunks in the root of the WAVE.
+// The existing chunks are replaced in place, and the new chunks are inserted before the data chunk,
+// so they precede the incomplete data chunk written by IncompleteChunkWriter.
+func SetBroadcastMetadata(c *RIFFChunk, m *BroadcastMetadata) error {
+	if c.FormType != waveType {
+		return fmt.Errorf("form type %q is not WAVE: %w", string(c.FormType[:]), ErrInvalidFormat)
+	}
+
+	var chunks []*OnMemorySubChunk
+	if m.Extension != nil {
+		b, err := m.Extension.MarshalBinary()
+		if err != nil {
+			return fmt.Errorf("bext: %w", err)
+		}
+		chunks = append(chunks, &OnMemorySubChunk{ID: bextID, Payload: b})
+	}
+	if m.IXML != nil {
+		chunks = append(chunks, &OnMemorySubChunk{ID: ixmlID, Payload: padNUL(m.IXML)})
+	}
+	if m.AXML != nil {
+		chunks = append(chunks, &OnMemorySubChunk{ID: axmlID, Payload: padNUL(m.AXML)})
+	}
+	if m.Channels != nil {
+		b, err := m.Channels.MarshalBinary()
+		if err != nil {
+			return fmt.Errorf("chna: %w", err)
+		}
+		chunks = append(chunks, &OnMemorySubChunk{ID: chnaID, Payload: b})
+	}
+
+	for _, nc := range chunks {
+		c.Payload = setRootSubChunk(c.Payload, nc)
+	}
+	return nil
+}
+
+// setRootSubChunk replaces the first sub-chunk with the same ID as nc, or inserts nc before the data chunk.
+func setRootSubChunk(payload []Chunk, nc *OnMemorySubChunk) []Chunk {
+	data := len(payload)
+	for i, chunk := range payload {
+		if _, ok := chunk.(groupedChunk); ok {
+			continue
+		}
+
+		switch id := chunk.ChunkID(); {
+		case bytes.Equal(id, nc.ID[:]):
+			payload[i] = nc
+			return payload
+		case bytes.Equal(id, dataID[:]) && data == len(payload):
+			data = i
+		}
+	}
+	return insertChunk(payload, data, nc)
+}
+
+// trimNUL returns the string before the first NUL byte.
+func trimNUL(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
+
+// padNUL pads b with a NUL byte to be even.
+func padNUL(b []byte) []byte {
+	if len(b)%2 == 0 {
+		return b
+	}
+	return append(append(make([]byte, 0, len(b)+1), b...), 0)
+}
+
+func padEven(n int) int {
+	return n + n%2
+}
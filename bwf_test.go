@@ -0,0 +1,138 @@
+package riffbin_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/karupanerura/riffbin"
+)
+
+func TestBroadcastMetadata(t *testing.T) {
+	t.Parallel()
+
+	metadata := &riffbin.BroadcastMetadata{
+		Extension: &riffbin.BroadcastExtension{
+			Description:          "take 1",
+			Originator:           "riffbin",
+			OriginatorReference:  "REF0001",
+			OriginationDate:      "2022:01:02",
+			OriginationTime:      "03:04:05",
+			TimeReference:        0x1_0000_0001,
+			Version:              2,
+			UMID:                 [64]byte{0x06, 0x0A, 0x2B, 0x34},
+			LoudnessValue:        -2300,
+			LoudnessRange:        500,
+			MaxTruePeakLevel:     -100,
+			MaxMomentaryLoudness: -1800,
+			MaxShortTermLoudness: -2000,
+			CodingHistory:        "A=PCM,F=44100,W=16,M=mono\r\n",
+		},
+		IXML: []byte("<BWFXML><IXML_VERSION>1.5</IXML_VERSION></BWFXML>"),
+		AXML: []byte("<ebuCoreMain/>"),
+		Channels: &riffbin.ChannelAssignment{
+			UIDs: []riffbin.ADMTrackUID{
+				{TrackIndex: 1, UID: "ATU_00000001", TrackFormatRef: "AT_00010001_01", PackFormatRef: "AP_00010002"},
+				{TrackIndex: 2, UID: "ATU_00000002", TrackFormatRef: "AT_00010002_01", PackFormatRef: "AP_00010002"},
+			},
+		},
+	}
+
+	t.Run("IncompleteChunkWriter", func(t *testing.T) {
+		t.Parallel()
+
+		waveChunks := newWaveChunks()
+		c := &riffbin.RIFFChunk{
+			FormType: [4]byte{'W', 'A', 'V', 'E'},
+			Payload: []riffbin.Chunk{
+				waveChunks[0],
+				riffbin.NewIncompleteSubChunk([4]byte{'d', 'a', 't', 'a'}, bytes.NewReader([]byte{0x00, 0x00, 0x01, 0x00})),
+			},
+		}
+		if err := riffbin.SetBroadcastMetadata(c, metadata); err != nil {
+			t.Fatal(err)
+		}
+
+		f := writeTempFile(t, nil)
+		w, err := riffbin.NewIncompleteChunkWriter(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(c); err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := os.ReadFile(f.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		riffChunk, err := riffbin.ReadSections(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var ids []string
+		for _, chunk := range riffChunk.Payload {
+			ids = append(ids, string(chunk.ChunkID()))
+		}
+		if df := cmp.Diff(ids, []string{"fmt ", "bext", "iXML", "axml", "chna", "data"}); df != "" {
+			t.Errorf("diff = %s", df)
+		}
+		if findings := riffbin.Validate(riffChunk, riffbin.ProfileWAVE); len(findings) != 0 {
+			t.Errorf("unexpected findings: %v", findings)
+		}
+
+		got, err := riffbin.ReadBroadcastMetadata(riffChunk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if df := cmp.Diff(got, metadata); df != "" {
+			t.Errorf("diff = %s", df)
+		}
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		t.Parallel()
+
+		c := &riffbin.RIFFChunk{FormType: [4]byte{'W', 'A', 'V', 'E'}, Payload: newWaveChunks()}
+		if err := riffbin.SetBroadcastMetadata(c, &riffbin.BroadcastMetadata{IXML: []byte("<old/>")}); err != nil {
+			t.Fatal(err)
+		}
+		if err := riffbin.SetBroadcastMetadata(c, &riffbin.BroadcastMetadata{IXML: []byte("<new/>")}); err != nil {
+			t.Fatal(err)
+		}
+		if len(c.Payload) != 3 {
+			t.Fatalf("unexpected chunks: %d", len(c.Payload))
+		}
+
+		got, err := riffbin.ReadBroadcastMetadata(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if df := cmp.Diff(got, &riffbin.BroadcastMetadata{IXML: []byte("<new/>")}); df != "" {
+			t.Errorf("diff = %s", df)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+
+		var ext riffbin.BroadcastExtension
+		if err := ext.UnmarshalBinary(make([]byte, 601)); !errors.Is(err, riffbin.ErrInvalidFormat) {
+			t.Errorf("unexpected error: %v", err)
+		}
+		var channels riffbin.ChannelAssignment
+		if err := channels.UnmarshalBinary([]byte{0x01, 0x00, 0x01, 0x00}); !errors.Is(err, riffbin.ErrInvalidFormat) {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		c := &riffbin.RIFFChunk{FormType: [4]byte{'W', 'A', 'V', 'E'}, Payload: newWaveChunks()}
+		err := riffbin.SetBroadcastMetadata(c, &riffbin.BroadcastMetadata{Extension: &riffbin.BroadcastExtension{Description: strings.Repeat("a", 257)}})
+		if err == nil {
+			t.Error("error is expected")
+		}
+	})
+}
@@ -30,6 +30,55 @@ type Chunk interface {
 	BodySize() uint32
 }
 
+// ChunkLocation is the location of the chunk in the parsed binary.
+// The offsets are counted from the head of io.Reader for ReadFull, and from the origin of io.Seeker or io.ReaderAt for the other readers.
+// So they are relative to the position where the reader started to read by ReadFull, and they are absolute in the source by ReadSections.
+type ChunkLocation struct {
+	// HeaderOffset is the offset of the chunk header.
+	HeaderOffset int64
+
+	// BodyOffset is the offset of the chunk body. The body of grouped chunks starts with the type.
+	BodyOffset int64
+
+	// DeclaredSize is the body size declared in the chunk header.
+	DeclaredSize uint32
+
+	// PadBytes is the bytes of the padding read after the body. It is always 0 without WithReadPadding for RIFF.
+	PadBytes int64
+}
+
+// ChunkLocations is a table of the locations of the chunks parsed by the readers with WithChunkLocations.
+// The chunks parsed by (*ListChunk).Load later are also added to the table, so it is safe to use concurrently.
+type ChunkLocations struct {
+	mu sync.RWMutex
+	m  map[Chunk]ChunkLocation
+}
+
+// Lookup returns the location of c. It returns false if c is not parsed with the table.
+func (l *ChunkLocations) Lookup(c Chunk) (ChunkLocation, bool) {
+	if l == nil {
+		return ChunkLocation{}, false
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	loc, ok := l.m[c]
+	return loc, ok
+}
+
+func (l *ChunkLocations) set(c Chunk, loc ChunkLocation) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.m == nil {
+		l.m = map[Chunk]ChunkLocation{}
+	}
+	l.m[c] = loc
+}
+
 type groupedChunk interface {
 	Chunk
 
@@ -49,12 +98,8 @@ func (c *RIFFChunk) ChunkID() []byte {
 	return riffID[:]
 }
 
-func (c *RIFFChunk) BodySize() (size uint32) {
-	size = typeBytes
-	for _, p := range c.Payload {
-		size += HeaderBytes + p.BodySize()
-	}
-	return
+func (c *RIFFChunk) BodySize() uint32 {
+	return uint32(riffChunkFormat.bodySize(c))
 }
 
 func (c *RIFFChunk) groupType() []byte {
@@ -66,9 +111,12 @@ func (c *RIFFChunk) payload() []Chunk {
 }
 
 // ListChunk is a LIST chunk.
+// Payload of the LIST chunk read by ReadSectionsLazy is nil until it is loaded by Load.
 type ListChunk struct {
 	ListType [typeBytes]byte
 	Payload  []Chunk
+
+	lazy *lazyPayload
 }
 
 var _ groupedChunk = (*ListChunk)(nil)
@@ -77,12 +125,8 @@ func (c *ListChunk) ChunkID() []byte {
 	return listID[:]
 }
 
-func (c *ListChunk) BodySize() (size uint32) {
-	size = typeBytes
-	for _, p := range c.Payload {
-		size += HeaderBytes + p.BodySize()
-	}
-	return
+func (c *ListChunk) BodySize() uint32 {
+	return uint32(riffChunkFormat.bodySize(c))
 }
 
 func (c *ListChunk) groupType() []byte {
@@ -157,7 +201,7 @@ func (c *IncompleteSubChunk) ChunkID() []byte {
 }
 
 func (c *IncompleteSubChunk) BodySize() uint32 {
-	return c.writtenLength
+	return uint32(c.writtenLength)
 }
 
 func (c *IncompleteSubChunk) Incomplete() bool {
@@ -165,19 +209,19 @@ func (c *IncompleteSubChunk) Incomplete() bool {
 }
 
 type incompleteChunkBody struct {
-	writtenLength uint32
+	writtenLength uint64
 	reader        io.Reader
 }
 
 func (c *incompleteChunkBody) Read(p []byte) (n int, err error) {
 	n, err = c.reader.Read(p)
-	c.writtenLength += uint32(n)
+	c.writtenLength += uint64(n)
 	return
 }
 
 func (c *incompleteChunkBody) WriteTo(w io.Writer) (n int64, err error) {
 	n, err = io.Copy(w, c.reader)
-	c.writtenLength += uint32(n)
+	c.writtenLength += uint64(n)
 	return
 }
 
@@ -200,3 +244,16 @@ func (c *InStreamSubChunk) BodySize() uint32 {
 func (c *InStreamSubChunk) Incomplete() bool {
 	return false
 }
+
+// subChunkSize returns the body size of c without the limit of BodySize for the formats with the 64 bits size field.
+func subChunkSize(c SubChunk) uint64 {
+	switch cc := c.(type) {
+	case *OnMemorySubChunk:
+		return uint64(len(cc.Payload))
+	case *IncompleteSubChunk:
+		return cc.writtenLength
+	case *InStreamSubChunk:
+		return uint64(cc.Size())
+	}
+	return uint64(c.BodySize())
+}
@@ -0,0 +1,236 @@
+package riffbin
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// chunkFormat is the format of the chunks which differs between RIFF, EA IFF 85 and Sony Wave64.
+// The readers and the writers are shared by the formats with it.
+type chunkFormat struct {
+	// idBytes is the width of the chunk ID, and the type of the grouped chunks has the same width.
+	idBytes int
+
+	// sizeBytes is the width of the size field. (4 or 8)
+	sizeBytes int
+
+	// order is the byte order of the size field.
+	order binary.ByteOrder
+
+	// sizeIncludesHeader is true if the size field counts the header itself.
+	sizeIncludesHeader bool
+
+	// align is the alignment of the chunks in the grouped chunks. (1 for no padding)
+	// The padding after the body is counted in the body of the parent, but not in the size field of the chunk.
+	align int64
+
+	tree chunkTree
+}
+
+var (
+	// riffChunkFormat is RIFF without the pad bytes, as same as the most of the writers in the wild.
+	riffChunkFormat = &chunkFormat{idBytes: idBytes, sizeBytes: sizeBytes, order: binary.LittleEndian, align: 1, tree: riffTree{}}
+
+	// riffPaddedChunkFormat is RIFF with the pad byte after the odd size chunks as the spec requires.
+	riffPaddedChunkFormat = &chunkFormat{idBytes: idBytes, sizeBytes: sizeBytes, order: binary.LittleEndian, align: 2, tree: riffTree{}}
+
+	iffChunkFormat = &chunkFormat{idBytes: idBytes, sizeBytes: sizeBytes, order: binary.BigEndian, align: 2, tree: iffTree{}}
+
+	// w64ChunkFormat aligns the whole chunk, and it is same as aligning the body because the header is aligned.
+	w64ChunkFormat = &chunkFormat{idBytes: guidBytes, sizeBytes: 8, order: binary.LittleEndian, sizeIncludesHeader: true, align: w64Alignment, tree: w64Tree{}}
+)
+
+// headerBytes returns the bytes of the chunk header.
+func (f *chunkFormat) headerBytes() int64 {
+	return int64(f.idBytes + f.sizeBytes)
+}
+
+// padding returns the bytes of the padding after the body of size bytes.
+func (f *chunkFormat) padding(size uint64) int64 {
+	return int64((uint64(f.align) - size%uint64(f.align)) % uint64(f.align))
+}
+
+// putSize encodes the size field of the body size to buf.
+func (f *chunkFormat) putSize(buf []byte, size uint64) {
+	if f.sizeIncludesHeader {
+		size += uint64(f.headerBytes())
+	}
+	if f.sizeBytes == 8 {
+		f.order.PutUint64(buf, size)
+	} else {
+		f.order.PutUint32(buf, uint32(size))
+	}
+}
+
+// parseHeader decodes the chunk header in buf, and returns the chunk ID and the body size.
+func (f *chunkFormat) parseHeader(buf []byte) ([]byte, int64, error) {
+	var size uint64
+	if f.sizeBytes == 8 {
+		size = f.order.Uint64(buf[f.idBytes:])
+	} else {
+		size = uint64(f.order.Uint32(buf[f.idBytes:]))
+	}
+	if f.sizeIncludesHeader {
+		if size < uint64(f.headerBytes()) {
+			return nil, 0, ErrInvalidFormat
+		}
+		size -= uint64(f.headerBytes())
+	}
+	if size > 1<<62 {
+		return nil, 0, ErrInvalidFormat
+	}
+	return buf[:f.idBytes], int64(size), nil
+}
+
+// bodySize returns the body size of c. The body of the grouped chunks includes the padding of the chunks in the payload.
+func (f *chunkFormat) bodySize(c interface{}) uint64 {
+	if section, ok := unloadedPayload(c); ok {
+		return uint64(f.idBytes) + uint64(section.Size())
+	}
+	if _, ok := f.tree.groupType(c); ok {
+		size := uint64(f.idBytes)
+		for _, p := range f.tree.payload(c) {
+			size += uint64(f.chunkBytes(p))
+		}
+		return size
+	}
+
+	return f.tree.leafSize(c)
+}
+
+// chunkBytes returns the bytes of c in the parent with the header and the padding, and it is 0 for the JUNK chunk to be skipped.
+func (f *chunkFormat) chunkBytes(c interface{}) int64 {
+	if j, ok := c.(*junkChunk); ok && j.skip {
+		return 0
+	}
+
+	size := f.bodySize(c)
+	return f.headerBytes() + int64(size) + f.padding(size)
+}
+
+// chunkName returns the base name of c in the chunk paths.
+func (f *chunkFormat) chunkName(c interface{}) string {
+	typ, _ := f.tree.groupType(c)
+	return f.tree.name(f.tree.chunkID(c), typ)
+}
+
+// chunkTree is the accessors of the chunk tree of the format.
+// The chunks are Chunk for RIFF and EA IFF 85, and W64Chunk for Sony Wave64, so they are passed as interface{}.
+type chunkTree interface {
+	// isRoot returns true if id is the ID of the root chunk.
+	isRoot(id []byte) bool
+
+	// isGroup returns true if id is the ID of the grouped chunks.
+	isGroup(id []byte) bool
+
+	// validChild returns true if the chunk of id can be placed after the siblings in the grouped chunk of parent.
+	validChild(parent, id []byte, siblings []interface{}) bool
+
+	// newGroup creates the grouped chunk of id.
+	newGroup(id, typ []byte, payload []interface{}) interface{}
+
+	// newSubChunk creates the sub-chunk of id with the payload of c.
+	newSubChunk(id []byte, c SubChunk) interface{}
+
+	// name returns the base name of the chunk in the chunk paths. typ is nil for the sub-chunks.
+	name(id, typ []byte) string
+
+	// label returns the chunk ID in the error messages.
+	label(id []byte) string
+
+	// chunkID returns the chunk ID of c.
+	chunkID(c interface{}) []byte
+
+	// groupType returns the type of c if c is a grouped chunk.
+	groupType(c interface{}) ([]byte, bool)
+
+	// payload returns the payload of the grouped chunk c.
+	payload(c interface{}) []interface{}
+
+	// subChunk returns the payload of c if c is a sub-chunk.
+	subChunk(c interface{}) (SubChunk, bool)
+
+	// leafSize returns the body size of c which is not a grouped chunk.
+	leafSize(c interface{}) uint64
+}
+
+// riffTree is the chunk tree of RIFF.
+type riffTree struct{}
+
+var _ chunkTree = riffTree{}
+
+func (riffTree) isRoot(id []byte) bool {
+	return bytes.Equal(riffID[:], id)
+}
+
+func (riffTree) isGroup(id []byte) bool {
+	return bytes.Equal(listID[:], id) || bytes.Equal(riffID[:], id)
+}
+
+func (riffTree) validChild(parent, id []byte, siblings []interface{}) bool {
+	return true
+}
+
+func (riffTree) newGroup(id, typ []byte, payload []interface{}) interface{} {
+	chunks := toChunks(payload)
+	if bytes.Equal(listID[:], id) {
+		c := &ListChunk{Payload: chunks}
+		copy(c.ListType[:], typ)
+		return c
+	}
+
+	c := &RIFFChunk{Payload: chunks}
+	copy(c.FormType[:], typ)
+	return c
+}
+
+func (riffTree) newSubChunk(id []byte, c SubChunk) interface{} {
+	return c
+}
+
+func (riffTree) name(id, typ []byte) string {
+	return chunkBaseName(id, typ)
+}
+
+func (riffTree) label(id []byte) string {
+	return fmt.Sprintf("%q", string(id))
+}
+
+func (riffTree) chunkID(c interface{}) []byte {
+	return c.(Chunk).ChunkID()
+}
+
+func (riffTree) groupType(c interface{}) ([]byte, bool) {
+	if cc, ok := c.(groupedChunk); ok {
+		return cc.groupType(), true
+	}
+	return nil, false
+}
+
+func (riffTree) payload(c interface{}) []interface{} {
+	payload := c.(groupedChunk).payload()
+	chunks := make([]interface{}, len(payload))
+	for i, p := range payload {
+		chunks[i] = p
+	}
+	return chunks
+}
+
+func (riffTree) subChunk(c interface{}) (SubChunk, bool) {
+	cc, ok := c.(SubChunk)
+	return cc, ok
+}
+
+func (riffTree) leafSize(c interface{}) uint64 {
+	return uint64(c.(Chunk).BodySize())
+}
+
+// toChunks converts the payload read by the shared readers to the payload of RIFF and EA IFF 85.
+func toChunks(payload []interface{}) []Chunk {
+	chunks := make([]Chunk, len(payload))
+	for i, p := range payload {
+		chunks[i] = p.(Chunk)
+	}
+	return chunks
+}
@@ -2,7 +2,7 @@ package riffbin
 
 import (
 	"bytes"
-	"encoding/binary"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -13,6 +13,9 @@ var (
 	ErrInvalidFormat = errors.New("invlaid format")
 )
 
+// maxIOBytes is the maximum bytes to read/write at once with the context to stop promptly on cancellation.
+const maxIOBytes = 1 << 20
+
 type PartialReader interface {
 	io.ReadSeeker
 	io.ReaderAt
@@ -20,137 +23,360 @@ type PartialReader interface {
 
 // ReadFull reads RIFF binary from io.Reader.
 // It creates *RIFFChunk with *OnMemorySubChunk for sub-chunks.
-func ReadFull(r io.Reader) (*RIFFChunk, error) {
-	return read(r, createOnMemorySubChunk)
+func ReadFull(r io.Reader, opts ...ReaderOption) (*RIFFChunk, error) {
+	return ReadFullContext(context.Background(), r, nil, opts...)
+}
+
+// ReadFullContext is same as ReadFull, but it stops reading when ctx is done.
+// progress is called with the path of the chunk in reading and the total bytes read if it is not nil.
+func ReadFullContext(ctx context.Context, r io.Reader, progress ProgressFunc, opts ...ReaderOption) (*RIFFChunk, error) {
+	cfg := newReaderConfig(opts)
+	return read(&readState{ctx: ctx, src: r, progress: progress, cfg: cfg, format: cfg.riffFormat()}, createOnMemorySubChunk)
 }
 
 // ReadSections reads RIFF binary from io.ReadSeeker to use less memory than ReadFull.
 // It creates *RIFFChunk with *InStreamSubChunk for sub-chunks.
-func ReadSections(r PartialReader) (*RIFFChunk, error) {
-	return read(r, createInStreamSubChunk)
+func ReadSections(r PartialReader, opts ...ReaderOption) (*RIFFChunk, error) {
+	return ReadSectionsContext(context.Background(), r, nil, opts...)
+}
+
+// ReadSectionsContext is same as ReadSections, but it stops reading when ctx is done.
+// progress is called with the path of the chunk in reading and the total bytes read or skipped if it is not nil.
+func ReadSectionsContext(ctx context.Context, r PartialReader, progress ProgressFunc, opts ...ReaderOption) (*RIFFChunk, error) {
+	cfg := newReaderConfig(opts)
+	if cfg.buffered {
+		return readSectionsBuffered(ctx, r, progress, cfg)
+	}
+
+	pos, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("get seek position: %w", err)
+	}
+
+	return read(&readState{ctx: ctx, src: r, progress: progress, base: pos, cfg: cfg, format: cfg.riffFormat()}, createInStreamSubChunk)
+}
+
+// ReadSectionsAt reads RIFF binary of size bytes from io.ReaderAt as same as ReadSections.
+// It never calls Seek, and reads r only by ReadAt with the own cursor, so it is safe to parse the same r concurrently.
+func ReadSectionsAt(r io.ReaderAt, size int64, opts ...ReaderOption) (*RIFFChunk, error) {
+	return ReadSectionsAtContext(context.Background(), r, size, nil, opts...)
+}
+
+// ReadSectionsAtContext is same as ReadSectionsAt, but it stops reading when ctx is done.
+// progress is called with the path of the chunk in reading and the total bytes read or skipped if it is not nil.
+func ReadSectionsAtContext(ctx context.Context, r io.ReaderAt, size int64, progress ProgressFunc, opts ...ReaderOption) (*RIFFChunk, error) {
+	cfg := newReaderConfig(opts)
+	return read(&readState{ctx: ctx, src: io.NewSectionReader(r, 0, size), progress: progress, cfg: cfg, format: cfg.riffFormat()}, createInStreamSubChunk)
 }
 
-func read(r io.Reader, f subChunkConstructorFn) (*RIFFChunk, error) {
-	var buf [HeaderBytes]byte
+// readState is a state of reading to be shared in the all chunks.
+// It reads the source with the context, and reports the progress.
+type readState struct {
+	ctx      context.Context
+	src      io.Reader
+	progress ProgressFunc
+	path     []string
+	base     int64
+	n        int64
+	cfg      readerConfig
+
+	// format is the format of the chunks to read
+	format *chunkFormat
+
+	// lazy is true to parse the payload of LIST chunks on demand
+	lazy bool
+
+	// quiet is true to defer the progress until the chunk in reading is named
+	quiet bool
+}
+
+func (s *readState) Read(p []byte) (n int, err error) {
+	if err = s.ctx.Err(); err != nil {
+		return
+	}
+
+	if len(p) > maxIOBytes {
+		p = p[:maxIOBytes]
+	}
+	n, err = s.src.Read(p)
+	s.advance(int64(n))
+	return
+}
+
+// advance reports n bytes are processed in the current chunk.
+func (s *readState) advance(n int64) {
+	if n == 0 {
+		return
+	}
+
+	s.n += n
+	if !s.quiet {
+		s.report()
+	}
+}
+
+func (s *readState) report() {
+	if s.progress != nil {
+		s.progress(joinChunkPath(s.path), s.n)
+	}
+}
+
+// readHeader reads the chunk header from r without reporting the progress.
+// The header bytes are reported by report after the chunk is named, as same as the chunk writers.
+func (s *readState) readHeader(r io.Reader, p []byte) error {
+	s.quiet = true
+	defer func() { s.quiet = false }()
+
+	_, err := io.ReadFull(r, p)
+	return err
+}
+
+// pos returns the current offset in the source.
+func (s *readState) pos() int64 {
+	return s.base + s.n
+}
+
+// location returns the location of the chunk whose header has just been read.
+func (s *readState) location(bodyLen int64) ChunkLocation {
+	return ChunkLocation{
+		HeaderOffset: s.pos() - s.format.headerBytes(),
+		BodyOffset:   s.pos(),
+		DeclaredSize: uint32(bodyLen),
+	}
+}
+
+func (s *readState) push(name string) {
+	s.path = append(s.path, name)
+}
+
+func (s *readState) pop() {
+	s.path = s.path[:len(s.path)-1]
+}
+
+func read(s *readState, f subChunkConstructorFn) (*RIFFChunk, error) {
+	chunk, err := readRoot(s, f)
+	if err != nil {
+		return nil, err
+	}
+	return chunk.(*RIFFChunk), nil
+}
+
+// readRoot reads the root chunk in the format of s.
+func readRoot(s *readState, f subChunkConstructorFn) (interface{}, error) {
+	buf := make([]byte, s.format.headerBytes())
 
 	// read header
-	if _, err := io.ReadFull(r, buf[:]); err == io.EOF || err == io.ErrUnexpectedEOF {
+	if err := s.readHeader(s, buf); err == io.EOF || err == io.ErrUnexpectedEOF {
 		return nil, ErrInvalidFormat
 	} else if err != nil {
 		return nil, err
 	}
 
 	// verify id
-	if !bytes.Equal(riffID[:], buf[:idBytes]) {
+	id, bodyLen, err := s.format.parseHeader(buf)
+	if err != nil {
+		return nil, err
+	}
+	if !s.format.tree.isRoot(id) || bodyLen < int64(s.format.idBytes) {
 		return nil, ErrInvalidFormat
 	}
 
-	ch := groupedChunkHeader{id: riffID}
-	rr := &io.LimitedReader{R: r, N: int64(binary.LittleEndian.Uint32(buf[idBytes:]))}
-	chunk, err := readGroupedChunkBody(r, rr, &ch, f)
+	loc := s.location(bodyLen)
+	rr := &io.LimitedReader{R: s, N: bodyLen}
+	chunk, err := readGroupedChunkBody(s, rr, id, nil, f)
 	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
 		return nil, ErrInvalidFormat
 	} else if err != nil {
 		return nil, err
 	}
 
-	// verify EOF
-	if n, err := r.Read(buf[:1]); err == nil {
+	if err := s.verifyEOF(); err != nil {
+		return nil, err
+	}
+
+	s.setLocation(chunk, loc)
+	return chunk, nil
+}
+
+// verifyEOF verifies the source reaches EOF just after the root chunk, and the skipped sub-chunk bodies are not truncated.
+func (s *readState) verifyEOF() error {
+	var buf [1]byte
+	if n, err := s.Read(buf[:]); err == nil {
 		// too long payload (too small payload size)
-		return nil, ErrInvalidFormat
+		return ErrInvalidFormat
 	} else if n == 0 && err == io.EOF {
 		// OK
 	} else {
 		// any other I/O error is occurred
-		return nil, err
+		return err
 	}
 
-	return chunk.(*RIFFChunk), nil
-}
-
-type groupedChunkHeader struct {
-	id        [idBytes]byte
-	groupType [idBytes]byte
-}
-
-func (h *groupedChunkHeader) toGroupedChunk(payload []Chunk) groupedChunk {
-	if bytes.Equal(listID[:], h.id[:]) {
-		return &ListChunk{
-			ListType: h.groupType,
-			Payload:  payload,
+	// verify the skipped sub-chunk bodies are not truncated
+	if sk, ok := s.src.(io.Seeker); ok {
+		cur, err := sk.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return fmt.Errorf("seek: %w", err)
 		}
-	}
-	if bytes.Equal(riffID[:], h.id[:]) {
-		return &RIFFChunk{
-			FormType: h.groupType,
-			Payload:  payload,
+		end, err := sk.Seek(0, io.SeekEnd)
+		if err != nil {
+			return fmt.Errorf("seek: %w", err)
+		}
+		// keep the position of the caller
+		if _, err := sk.Seek(cur, io.SeekStart); err != nil {
+			return fmt.Errorf("seek: %w", err)
+		}
+		if end < s.pos() {
+			return ErrInvalidFormat
 		}
 	}
 
-	panic("should not reach here")
+	return nil
 }
 
-func readGroupedChunkBody(src io.Reader, r *io.LimitedReader, chunk *groupedChunkHeader, f subChunkConstructorFn) (groupedChunk, error) {
-	var buf [HeaderBytes]byte
+// setLocation records the location of the chunk to the table of WithChunkLocations.
+// Only the chunks of RIFF and EA IFF 85 are recorded.
+func (s *readState) setLocation(c interface{}, loc ChunkLocation) {
+	if cc, ok := c.(Chunk); ok {
+		s.cfg.locations.set(cc, loc)
+	}
+}
 
+// readGroupedChunkBody reads the body of the grouped chunk of id.
+// names is the namer of the siblings to name the chunk in the progress, and it is nil for the root chunk.
+func readGroupedChunkBody(s *readState, r *io.LimitedReader, id []byte, names chunkNamer, f subChunkConstructorFn) (interface{}, error) {
 	// read type
-	if _, err := io.ReadFull(r, chunk.groupType[:typeBytes]); err != nil {
+	typ := make([]byte, s.format.idBytes)
+	if err := s.readHeader(r, typ); err != nil {
 		return nil, err
 	}
-	if r.N == 0 {
-		return chunk.toGroupedChunk([]Chunk{}), nil
+	if names != nil {
+		s.push(names.nextName(s.format.tree.name(id, typ)))
+		defer s.pop()
+	}
+	s.report()
+
+	if s.lazy && bytes.Equal(listID[:], id) {
+		return readLazyListChunk(s, r, typ)
+	}
+
+	payload, err := readPayload(s, r, id, f)
+	if err != nil {
+		return nil, err
 	}
 
+	return s.format.tree.newGroup(id, typ, payload), nil
+}
+
+// readPayload reads the chunks in the grouped chunk of parent until r reaches the limit.
+func readPayload(s *readState, r *io.LimitedReader, parent []byte, f subChunkConstructorFn) ([]interface{}, error) {
+	tree := s.format.tree
+	buf := make([]byte, s.format.headerBytes())
+
 	// read sub-chunks
-	var payload []Chunk
+	payload := []interface{}{}
+	children := chunkNamer{}
 	for r.N > 0 {
-		if _, err := io.ReadFull(r, buf[:]); err != nil {
+		if err := s.readHeader(r, buf); err != nil {
 			return nil, err
 		}
-		bodyLen := binary.LittleEndian.Uint32(buf[idBytes:])
-
-		// check wel-known id
-		if bytes.Equal(listID[:], buf[:idBytes]) || bytes.Equal(riffID[:], buf[:idBytes]) {
-			ch := groupedChunkHeader{}
-			rr := &io.LimitedReader{R: r, N: int64(bodyLen)}
-			copy(ch.id[:], buf[:idBytes])
-			chunk, err := readGroupedChunkBody(src, rr, &ch, f)
+		id, bodyLen, err := s.format.parseHeader(buf)
+		if err != nil {
+			return nil, err
+		}
+		if bodyLen > r.N {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if !tree.validChild(parent, id, payload) {
+			return nil, ErrInvalidFormat
+		}
+
+		loc := s.location(bodyLen)
+		var chunk interface{}
+		if tree.isGroup(id) {
+			// check wel-known id
+			if bodyLen < int64(s.format.idBytes) {
+				return nil, ErrInvalidFormat
+			}
+
+			rr := &io.LimitedReader{R: r, N: bodyLen}
+			chunk, err = readGroupedChunkBody(s, rr, id, children, f)
 			if err != nil {
 				return nil, err
 			}
-
-			payload = append(payload, chunk)
 		} else {
 			// or not, this is a simple sub-chunk
-			chunk, err := f(src, r, buf[:idBytes], bodyLen)
+			s.push(children.nextName(tree.name(id, nil)))
+			s.report()
+			var sc SubChunk
+			sc, err = f(s, r, id, bodyLen)
+			s.pop()
 			if err != nil {
 				return nil, fmt.Errorf("construct sub-chunk: %w", err)
 			}
+			chunk = tree.newSubChunk(id, sc)
+		}
 
-			payload = append(payload, chunk)
+		// skip the padding, but the padding of the last chunk may be omitted
+		pad := s.format.padding(uint64(bodyLen))
+		if pad > r.N {
+			pad = r.N
+		}
+		if _, err := io.CopyN(io.Discard, r, pad); err != nil {
+			return nil, err
 		}
+
+		loc.PadBytes = pad
+		s.setLocation(chunk, loc)
+		payload = append(payload, chunk)
 	}
 
-	return chunk.toGroupedChunk(payload), nil
+	return payload, nil
 }
 
-type subChunkConstructorFn = func(src io.Reader, r *io.LimitedReader, id []byte, bodyLen uint32) (SubChunk, error)
+type subChunkConstructorFn = func(s *readState, r *io.LimitedReader, id []byte, bodyLen int64) (SubChunk, error)
 
-func createOnMemorySubChunk(_ io.Reader, r *io.LimitedReader, id []byte, bodyLen uint32) (SubChunk, error) {
+func createOnMemorySubChunk(s *readState, r *io.LimitedReader, id []byte, bodyLen int64) (SubChunk, error) {
 	chunk := &OnMemorySubChunk{}
 	copy(chunk.ID[:], id)
 
 	// read body payload
-	chunk.Payload = make([]byte, bodyLen)
-	if _, err := io.ReadFull(r, chunk.Payload); err != nil {
+	if bodyLen <= maxIOBytes {
+		chunk.Payload = make([]byte, bodyLen)
+		if _, err := io.ReadFull(r, chunk.Payload); err != nil {
+			return nil, err
+		}
+		return chunk, nil
+	}
+
+	// grow the buffer while reading not to allocate the broken size at once
+	var buf bytes.Buffer
+	if n, err := io.CopyN(&buf, r, bodyLen); err == io.EOF && n < bodyLen {
+		return nil, io.ErrUnexpectedEOF
+	} else if err != nil {
+		return nil, err
+	}
+	chunk.Payload = buf.Bytes()
+	return chunk, nil
+}
+
+func createInStreamSubChunk(s *readState, r *io.LimitedReader, id []byte, bodyLen int64) (SubChunk, error) {
+	section, err := s.skip(r, bodyLen)
+	if err != nil {
 		return nil, err
 	}
 
+	chunk := &InStreamSubChunk{SectionReader: section}
+	copy(chunk.ID[:], id)
 	return chunk, nil
 }
 
-func createInStreamSubChunk(src io.Reader, r *io.LimitedReader, id []byte, bodyLen uint32) (SubChunk, error) {
-	pr := src.(PartialReader)
+// skip skips the body of n bytes in the source by seeking, and returns the section of the skipped body.
+// The source must be PartialReader.
+func (s *readState) skip(r *io.LimitedReader, n int64) (*io.SectionReader, error) {
+	pr := s.src.(PartialReader)
+	if n > r.N {
+		return nil, io.ErrUnexpectedEOF
+	}
 
 	// get seek position
 	pos, err := pr.Seek(0, io.SeekCurrent)
@@ -159,13 +385,29 @@ func createInStreamSubChunk(src io.Reader, r *io.LimitedReader, id []byte, bodyL
 	}
 
 	// skip sub-chunk body
-	_, err = pr.Seek(int64(bodyLen), io.SeekCurrent)
+	_, err = pr.Seek(n, io.SeekCurrent)
 	if err != nil {
 		return nil, fmt.Errorf("seek: %w", err)
 	}
-	r.N -= int64(bodyLen)
+	consume(r, n)
+	s.advance(n)
 
-	chunk := &InStreamSubChunk{SectionReader: io.NewSectionReader(pr, pos, int64(bodyLen))}
-	copy(chunk.ID[:], id)
-	return chunk, nil
+	var ra io.ReaderAt = pr
+	if br, ok := pr.(*bufferedReader); ok {
+		// not to keep the cache alive by the section
+		ra = br.src
+	}
+	return io.NewSectionReader(ra, pos, n), nil
+}
+
+// consume counts n bytes skipped without reading through r as read by r and the all enclosing limited readers.
+func consume(r *io.LimitedReader, n int64) {
+	for {
+		r.N -= n
+		parent, ok := r.R.(*io.LimitedReader)
+		if !ok {
+			return
+		}
+		r = parent
+	}
 }
@@ -0,0 +1,166 @@
+package riffbin
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	defaultBufferBlockSize = 64 * 1024
+	defaultBufferCacheSize = 4
+)
+
+// readSectionsBuffered reads r as same as ReadSections, but it reads the chunk headers through the block cache configured by WithReadBuffer.
+func readSectionsBuffered(ctx context.Context, r PartialReader, progress ProgressFunc, cfg readerConfig) (*RIFFChunk, error) {
+	br, err := newBufferedReader(r, cfg.bufferBlockSize, cfg.bufferCacheSize)
+	if err != nil {
+		return nil, err
+	}
+
+	chunk, err := read(&readState{ctx: ctx, src: br, progress: progress, base: br.pos, cfg: cfg, format: cfg.riffFormat()}, createInStreamSubChunk)
+	if err != nil {
+		return nil, err
+	}
+
+	// move the cursor of r as same as ReadSections
+	if _, err := r.Seek(br.pos, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("seek: %w", err)
+	}
+	return chunk, nil
+}
+
+// bufferedReader is PartialReader to read the source through the block cache.
+// Seek never calls the source, and ReadAt reads the source directly.
+// The sections of the skipped bodies refer the source instead of bufferedReader, so the cache is released after reading.
+type bufferedReader struct {
+	src       PartialReader
+	pos       int64
+	size      int64
+	blockSize int64
+	cacheSize int
+
+	blocks map[int64]*list.Element
+	lru    *list.List
+}
+
+var _ PartialReader = (*bufferedReader)(nil)
+
+type bufferedBlock struct {
+	index int64
+	data  []byte
+}
+
+func newBufferedReader(r PartialReader, blockSize, cacheSize int) (*bufferedReader, error) {
+	if blockSize < 1 {
+		blockSize = defaultBufferBlockSize
+	}
+	if cacheSize < 1 {
+		cacheSize = defaultBufferCacheSize
+	}
+
+	pos, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("get seek position: %w", err)
+	}
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, fmt.Errorf("seek: %w", err)
+	}
+	if _, err := r.Seek(pos, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("seek: %w", err)
+	}
+
+	return &bufferedReader{
+		src:       r,
+		pos:       pos,
+		size:      size,
+		blockSize: int64(blockSize),
+		cacheSize: cacheSize,
+		blocks:    map[int64]*list.Element{},
+		lru:       list.New(),
+	}, nil
+}
+
+func (r *bufferedReader) Read(p []byte) (n int, err error) {
+	if r.pos >= r.size {
+		return 0, io.EOF
+	}
+	if int64(len(p)) >= r.blockSize {
+		// the large read does not need the cache
+		n, err = r.src.ReadAt(p, r.pos)
+		r.pos += int64(n)
+		if n > 0 && err == io.EOF {
+			err = nil
+		}
+		return
+	}
+
+	index := r.pos / r.blockSize
+	data, err := r.block(index)
+	if err != nil {
+		return 0, err
+	}
+
+	offset := r.pos - index*r.blockSize
+	if offset >= int64(len(data)) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	n = copy(p, data[offset:])
+	r.pos += int64(n)
+	return n, nil
+}
+
+func (r *bufferedReader) block(index int64) ([]byte, error) {
+	if e, ok := r.blocks[index]; ok {
+		r.lru.MoveToFront(e)
+		return e.Value.(*bufferedBlock).data, nil
+	}
+
+	start := index * r.blockSize
+	size := r.blockSize
+	if rest := r.size - start; rest < size {
+		size = rest
+	}
+
+	data := make([]byte, size)
+	if n, err := r.src.ReadAt(data, start); err != nil && !(err == io.EOF && n == len(data)) {
+		if err == io.EOF {
+			// the source is shorter than the size
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	r.blocks[index] = r.lru.PushFront(&bufferedBlock{index: index, data: data})
+	for r.lru.Len() > r.cacheSize {
+		e := r.lru.Back()
+		r.lru.Remove(e)
+		delete(r.blocks, e.Value.(*bufferedBlock).index)
+	}
+	return data, nil
+}
+
+func (r *bufferedReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.pos
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+
+	r.pos = offset
+	return offset, nil
+}
+
+func (r *bufferedReader) ReadAt(p []byte, off int64) (int, error) {
+	return r.src.ReadAt(p, off)
+}
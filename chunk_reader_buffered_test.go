@@ -0,0 +1,167 @@
+package riffbin_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/karupanerura/riffbin"
+)
+
+// countingReader counts the calls to the source.
+type countingReader struct {
+	riffbin.PartialReader
+	calls int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	r.calls++
+	return r.PartialReader.Read(p)
+}
+
+func (r *countingReader) Seek(offset int64, whence int) (int64, error) {
+	r.calls++
+	return r.PartialReader.Seek(offset, whence)
+}
+
+func (r *countingReader) ReadAt(p []byte, off int64) (int, error) {
+	r.calls++
+	return r.PartialReader.ReadAt(p, off)
+}
+
+func newManyChunksRIFFChunk(n int) *riffbin.RIFFChunk {
+	list := &riffbin.ListChunk{ListType: [4]byte{'L', 'S', 'T', '1'}}
+	for i := 0; i < n; i++ {
+		list.Payload = append(list.Payload, &riffbin.OnMemorySubChunk{
+			ID:      [4]byte{'E', 'N', 'T', '1'},
+			Payload: []byte(fmt.Sprintf("%08d", i)),
+		})
+	}
+	return &riffbin.RIFFChunk{
+		FormType: [4]byte{'T', 'E', 'S', 'T'},
+		Payload:  []riffbin.Chunk{list},
+	}
+}
+
+func TestWithReadBuffer(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Valid", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range []struct {
+			name  string
+			chunk *riffbin.RIFFChunk
+		}{
+			{name: "Complex", chunk: newComplexRIFFChunk()},
+			{name: "ManyChunks", chunk: newManyChunksRIFFChunk(1000)},
+		} {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				var src bytes.Buffer
+				if _, err := riffbin.NewCompletedChunkWriter(&src).Write(tc.chunk); err != nil {
+					t.Fatal(err)
+				}
+
+				for _, blockSize := range []int{0, 1, 7, 64} {
+					r := &countingReader{PartialReader: bytes.NewReader(src.Bytes())}
+					riffChunk, err := riffbin.ReadSections(r, riffbin.WithReadBuffer(blockSize, 2))
+					if err != nil {
+						t.Fatal(err)
+					}
+					if pos, _ := r.Seek(0, io.SeekCurrent); pos != int64(src.Len()) {
+						t.Errorf("unexpected position: %d", pos)
+					}
+
+					var buf bytes.Buffer
+					if _, err := riffbin.NewCompletedChunkWriter(&buf).Write(riffChunk); err != nil {
+						t.Fatal(err)
+					}
+					if !bytes.Equal(buf.Bytes(), src.Bytes()) {
+						t.Errorf("unexpected bytes are read (blockSize=%d)", blockSize)
+						t.Log(hex.Dump(buf.Bytes()))
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("FewCalls", func(t *testing.T) {
+		t.Parallel()
+
+		var src bytes.Buffer
+		if _, err := riffbin.NewCompletedChunkWriter(&src).Write(newManyChunksRIFFChunk(1000)); err != nil {
+			t.Fatal(err)
+		}
+
+		r := &countingReader{PartialReader: bytes.NewReader(src.Bytes())}
+		if _, err := riffbin.ReadSections(r); err != nil {
+			t.Fatal(err)
+		}
+		unbuffered := r.calls
+
+		r = &countingReader{PartialReader: bytes.NewReader(src.Bytes())}
+		if _, err := riffbin.ReadSections(r, riffbin.WithReadBuffer(4096, 2)); err != nil {
+			t.Fatal(err)
+		}
+		if r.calls > 16 {
+			t.Errorf("too many calls: %d (unbuffered: %d)", r.calls, unbuffered)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+
+		var src bytes.Buffer
+		if _, err := riffbin.NewCompletedChunkWriter(&src).Write(newComplexRIFFChunk()); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, size := range []int{0, 7, 20, src.Len() - 1} {
+			if _, err := riffbin.ReadSections(bytes.NewReader(src.Bytes()[:size]), riffbin.WithReadBuffer(16, 1)); err != riffbin.ErrInvalidFormat {
+				t.Errorf("unexpected error (size=%d): %v", size, err)
+			}
+		}
+	})
+}
+
+func benchmarkReadSectionsFile(b *testing.B, read func(f *os.File) (*riffbin.RIFFChunk, error)) {
+	f, err := os.CreateTemp("", "riffbin")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() {
+		f.Close()
+		os.Remove(f.Name())
+	})
+	if _, err := riffbin.NewCompletedChunkWriter(f).Write(newManyChunksRIFFChunk(10000)); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := read(f); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadSections(b *testing.B) {
+	benchmarkReadSectionsFile(b, func(f *os.File) (*riffbin.RIFFChunk, error) {
+		return riffbin.ReadSections(f)
+	})
+}
+
+func BenchmarkReadSectionsBuffered(b *testing.B) {
+	benchmarkReadSectionsFile(b, func(f *os.File) (*riffbin.RIFFChunk, error) {
+		return riffbin.ReadSections(f, riffbin.WithReadBuffer(0, 0))
+	})
+}
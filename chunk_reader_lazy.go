@@ -0,0 +1,135 @@
+package riffbin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ReadSectionsLazy reads RIFF binary from io.ReadSeeker as same as ReadSections, but it does not parse the payload of LIST chunks until it is needed.
+// The payload of each LIST chunk is parsed by (*ListChunk).Load on demand, and then it is cached.
+// The LIST chunks which are not loaded are written as it is by the chunk writers.
+func ReadSectionsLazy(r PartialReader, opts ...ReaderOption) (*RIFFChunk, error) {
+	return ReadSectionsLazyContext(context.Background(), r, nil, opts...)
+}
+
+// ReadSectionsLazyContext is same as ReadSectionsLazy, but it stops reading when ctx is done.
+// progress is called with the path of the chunk in reading and the total bytes read or skipped if it is not nil.
+func ReadSectionsLazyContext(ctx context.Context, r PartialReader, progress ProgressFunc, opts ...ReaderOption) (*RIFFChunk, error) {
+	pos, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("get seek position: %w", err)
+	}
+
+	cfg := newReaderConfig(opts)
+	return read(&readState{ctx: ctx, src: r, progress: progress, base: pos, cfg: cfg, format: cfg.riffFormat(), lazy: true}, createInStreamSubChunk)
+}
+
+// lazyPayload is the payload of the LIST chunk to be parsed on demand.
+type lazyPayload struct {
+	mu     sync.Mutex
+	loaded bool
+	err    error
+
+	// section is the section of the payload after the list type.
+	section *io.SectionReader
+
+	// offset is the offset of section in the source.
+	offset int64
+
+	// cfg and format are the config and the format of the reader to load the payload as same as the parent.
+	cfg    readerConfig
+	format *chunkFormat
+}
+
+// Load parses the payload of the LIST chunk read by ReadSectionsLazy, and sets it to Payload.
+// It does nothing for the other LIST chunks or the LIST chunks already loaded.
+// It is safe to call Load concurrently.
+func (c *ListChunk) Load() error {
+	if c.lazy == nil {
+		return nil
+	}
+
+	c.lazy.mu.Lock()
+	defer c.lazy.mu.Unlock()
+	if c.lazy.loaded {
+		return c.lazy.err
+	}
+
+	// use the own cursor for each loading
+	section := io.NewSectionReader(c.lazy.section, 0, c.lazy.section.Size())
+	s := &readState{ctx: context.Background(), src: section, base: c.lazy.offset, cfg: c.lazy.cfg, format: c.lazy.format, lazy: true}
+	payload, err := readPayload(s, &io.LimitedReader{R: s, N: section.Size()}, listID[:], createInStreamSubChunk)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		err = ErrInvalidFormat
+	}
+	if err == nil {
+		c.Payload = toChunks(payload)
+	}
+
+	c.lazy.loaded = true
+	c.lazy.err = err
+	return err
+}
+
+// Chunks returns the payload of the LIST chunk after loading it by Load.
+func (c *ListChunk) Chunks() ([]Chunk, error) {
+	if err := c.Load(); err != nil {
+		return nil, err
+	}
+	return c.Payload, nil
+}
+
+// unloaded returns the payload section after the list type if the LIST chunk is not loaded yet.
+func (c *ListChunk) unloaded() (*io.SectionReader, bool) {
+	if c.lazy == nil {
+		return nil, false
+	}
+
+	c.lazy.mu.Lock()
+	defer c.lazy.mu.Unlock()
+	if c.lazy.loaded {
+		return nil, false
+	}
+	return io.NewSectionReader(c.lazy.section, 0, c.lazy.section.Size()), true
+}
+
+// unloadedPayload returns the payload section after the type if c is a LIST chunk which is not loaded yet.
+func unloadedPayload(c interface{}) (*io.SectionReader, bool) {
+	if cc, ok := c.(*ListChunk); ok {
+		return cc.unloaded()
+	}
+	return nil, false
+}
+
+// readLazyListChunk skips the payload of the LIST chunk of typ after the list type to parse it on demand.
+func readLazyListChunk(s *readState, r *io.LimitedReader, typ []byte) (*ListChunk, error) {
+	pr := s.src.(PartialReader)
+	pos, err := pr.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("get seek position: %w", err)
+	}
+	offset := s.pos()
+
+	// skip payload
+	n := r.N
+	_, err = pr.Seek(n, io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("seek: %w", err)
+	}
+	consume(r, n)
+	s.advance(n)
+
+	chunk := &ListChunk{
+		lazy: &lazyPayload{
+			section: io.NewSectionReader(pr, pos, n),
+			offset:  offset,
+			cfg:     s.cfg,
+			format:  s.format,
+		},
+	}
+	copy(chunk.ListType[:], typ)
+	return chunk, nil
+}
@@ -0,0 +1,148 @@
+package riffbin_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/karupanerura/riffbin"
+)
+
+func TestReadSectionsLazy(t *testing.T) {
+	t.Parallel()
+
+	var src bytes.Buffer
+	if _, err := riffbin.NewCompletedChunkWriter(&src).Write(newComplexRIFFChunk()); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Load", func(t *testing.T) {
+		t.Parallel()
+
+		locs := &riffbin.ChunkLocations{}
+		riffChunk, err := riffbin.ReadSectionsLazy(bytes.NewReader(src.Bytes()), riffbin.WithChunkLocations(locs))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if riffChunk.BodySize() != uint32(src.Len()-riffbin.HeaderBytes) {
+			t.Errorf("unexpected body size: %d", riffChunk.BodySize())
+		}
+
+		list := riffChunk.Payload[0].(*riffbin.ListChunk)
+		if list.Payload != nil {
+			t.Error("payload should not be loaded yet")
+		}
+		if list.BodySize() != 58 {
+			t.Errorf("unexpected body size: %d", list.BodySize())
+		}
+
+		// load concurrently
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := list.Load(); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if len(list.Payload) != 3 {
+			t.Fatalf("unexpected payload length: %d", len(list.Payload))
+		}
+		if list.BodySize() != 58 {
+			t.Errorf("unexpected body size: %d", list.BodySize())
+		}
+
+		// nested LIST chunk is also lazy
+		nested := list.Payload[1].(*riffbin.ListChunk)
+		if nested.Payload != nil {
+			t.Error("payload should not be loaded yet")
+		}
+		chunks, err := nested.Chunks()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(chunks) != 2 {
+			t.Fatalf("unexpected payload length: %d", len(chunks))
+		}
+
+		got, err := io.ReadAll(chunks[1].(*riffbin.InStreamSubChunk))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, []byte{0x01, 0x02, 0x03}) {
+			t.Errorf("unexpected payload: %v", got)
+		}
+		if loc, _ := locs.Lookup(chunks[1]); loc.BodyOffset != 63 {
+			t.Errorf("unexpected location: %+v", loc)
+		}
+
+		empty := riffChunk.Payload[2].(*riffbin.ListChunk)
+		if chunks, err := empty.Chunks(); err != nil {
+			t.Fatal(err)
+		} else if chunks == nil || len(chunks) != 0 {
+			t.Errorf("unexpected chunks: %v", chunks)
+		}
+	})
+
+	t.Run("WriteUnloaded", func(t *testing.T) {
+		t.Parallel()
+
+		read := func() *riffbin.RIFFChunk {
+			riffChunk, err := riffbin.ReadSectionsLazy(bytes.NewReader(src.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// load partially
+			if err := riffChunk.Payload[3].(*riffbin.ListChunk).Load(); err != nil {
+				t.Fatal(err)
+			}
+			return riffChunk
+		}
+
+		var buf bytes.Buffer
+		if _, err := riffbin.NewCompletedChunkWriter(&buf).Write(read()); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf.Bytes(), src.Bytes()) {
+			t.Error("unexpected bytes are written")
+			t.Log(hex.Dump(buf.Bytes()))
+		}
+
+		var w bufferWriterAt
+		if _, err := riffbin.NewParallelChunkWriter(&w, 2).Write(read()); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(w.Bytes(), src.Bytes()) {
+			t.Error("unexpected bytes are written")
+			t.Log(hex.Dump(w.Bytes()))
+		}
+	})
+
+	t.Run("InvalidPayload", func(t *testing.T) {
+		t.Parallel()
+
+		riffChunk, err := riffbin.ReadSectionsLazy(bytes.NewReader([]byte{
+			'R', 'I', 'F', 'F', 0x16, 0x00, 0x00, 0x00, 'A', 'B', 'C', 'D',
+			'L', 'I', 'S', 'T', 0x0A, 0x00, 0x00, 0x00, 'E', 'F', 'G', 'H',
+			'I', 'J', 'K', 'L', 0x01, 0x00, // too short sub-chunk header
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		list := riffChunk.Payload[0].(*riffbin.ListChunk)
+		for i := 0; i < 2; i++ {
+			if err := list.Load(); !errors.Is(err, riffbin.ErrInvalidFormat) {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}
+	})
+}
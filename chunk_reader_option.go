@@ -0,0 +1,58 @@
+package riffbin
+
+// ReaderOption is an option for the RIFF chunk readers.
+type ReaderOption func(*readerConfig)
+
+type readerConfig struct {
+	locations *ChunkLocations
+	padding   bool
+
+	buffered        bool
+	bufferBlockSize int
+	bufferCacheSize int
+}
+
+func newReaderConfig(opts []ReaderOption) readerConfig {
+	var cfg readerConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithChunkLocations records the locations of the parsed chunks to locs.
+// The locations are kept out of the chunks, so the chunks can be compared and composed as same as the chunks created by hand.
+func WithChunkLocations(locs *ChunkLocations) ReaderOption {
+	return func(cfg *readerConfig) {
+		cfg.locations = locs
+	}
+}
+
+// WithReadPadding reads the pad byte after each chunk of odd size as the RIFF spec requires.
+// The pad byte of the last chunk in the parent may be omitted. It is ignored by the EA IFF 85 readers which always read the pad bytes.
+// The pad bytes are not included in BodySize of the sub-chunks, but they are included in BodySize of the parent chunks.
+func WithReadPadding() ReaderOption {
+	return func(cfg *readerConfig) {
+		cfg.padding = true
+	}
+}
+
+// WithReadBuffer reads the chunk headers through the block cache by ReadSections and ReadSectionsContext, and it is ignored by the other readers.
+// It reads the source by ReadAt in blockSize bytes, and keeps cacheSize blocks at most, so the many small chunk headers are read by a few calls.
+// The default values (64KiB and 4 blocks) are used if blockSize or cacheSize is not positive.
+// The cache is used only while parsing, and the sections of *InStreamSubChunk read the source directly.
+func WithReadBuffer(blockSize, cacheSize int) ReaderOption {
+	return func(cfg *readerConfig) {
+		cfg.buffered = true
+		cfg.bufferBlockSize = blockSize
+		cfg.bufferCacheSize = cacheSize
+	}
+}
+
+// riffFormat returns the format of RIFF to read with the config.
+func (cfg *readerConfig) riffFormat() *chunkFormat {
+	if cfg.padding {
+		return riffPaddedChunkFormat
+	}
+	return riffChunkFormat
+}
@@ -2,11 +2,13 @@ package riffbin_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -49,7 +51,7 @@ func TestReadFull(t *testing.T) {
 				},
 			}
 
-			if df := cmp.Diff(riffChunk, expected, cmpopts.IgnoreUnexported(riffbin.OnMemorySubChunk{})); df != "" {
+			if df := cmp.Diff(riffChunk, expected, cmpopts.IgnoreUnexported(riffbin.ListChunk{}, riffbin.OnMemorySubChunk{})); df != "" {
 				t.Errorf("diff = %s", df)
 			}
 		})
@@ -66,7 +68,7 @@ func TestReadFull(t *testing.T) {
 				Payload:  []riffbin.Chunk{},
 			}
 
-			if df := cmp.Diff(riffChunk, expected, cmpopts.IgnoreUnexported(riffbin.OnMemorySubChunk{})); df != "" {
+			if df := cmp.Diff(riffChunk, expected, cmpopts.IgnoreUnexported(riffbin.ListChunk{}, riffbin.OnMemorySubChunk{})); df != "" {
 				t.Errorf("diff = %s", df)
 			}
 		})
@@ -88,7 +90,7 @@ func TestReadFull(t *testing.T) {
 				},
 			}
 
-			if df := cmp.Diff(riffChunk, expected, cmpopts.IgnoreUnexported(riffbin.OnMemorySubChunk{})); df != "" {
+			if df := cmp.Diff(riffChunk, expected, cmpopts.IgnoreUnexported(riffbin.ListChunk{}, riffbin.OnMemorySubChunk{})); df != "" {
 				t.Errorf("diff = %s", df)
 			}
 		})
@@ -110,7 +112,7 @@ func TestReadFull(t *testing.T) {
 				},
 			}
 
-			if df := cmp.Diff(riffChunk, expected, cmpopts.IgnoreUnexported(riffbin.OnMemorySubChunk{})); df != "" {
+			if df := cmp.Diff(riffChunk, expected, cmpopts.IgnoreUnexported(riffbin.ListChunk{}, riffbin.OnMemorySubChunk{})); df != "" {
 				t.Errorf("diff = %s", df)
 			}
 		})
@@ -216,7 +218,7 @@ func TestReadSections(t *testing.T) {
 				},
 			}
 
-			if df := cmp.Diff(riffChunk, expected, cmpopts.IgnoreUnexported(riffbin.InStreamSubChunk{}), cmpopts.IgnoreFields(riffbin.InStreamSubChunk{}, "SectionReader")); df != "" {
+			if df := cmp.Diff(riffChunk, expected, cmpopts.IgnoreUnexported(riffbin.ListChunk{}, riffbin.InStreamSubChunk{}), cmpopts.IgnoreFields(riffbin.InStreamSubChunk{}, "SectionReader")); df != "" {
 				t.Fatalf("diff = %s", df)
 			}
 
@@ -243,6 +245,45 @@ func TestReadSections(t *testing.T) {
 			}
 		})
 
+		t.Run("ListChunkFollowedBySubChunk", func(t *testing.T) {
+			t.Parallel()
+
+			riffChunk, err := riffbin.ReadSections(bytes.NewReader([]byte{
+				'R', 'I', 'F', 'F', 0x24, 0x00, 0x00, 0x00, 'A', 'B', 'C', 'D',
+				'L', 'I', 'S', 'T', 0x0E, 0x00, 0x00, 0x00, 'E', 'F', 'G', 'H',
+				'd', 'a', 't', 'a', 0x02, 0x00, 0x00, 0x00, 0x01, 0x02,
+				'n', 'e', 'x', 't', 0x02, 0x00, 0x00, 0x00, 0x03, 0x04,
+			}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := &riffbin.RIFFChunk{
+				FormType: [4]byte{'A', 'B', 'C', 'D'},
+				Payload: []riffbin.Chunk{
+					&riffbin.ListChunk{
+						ListType: [4]byte{'E', 'F', 'G', 'H'},
+						Payload: []riffbin.Chunk{
+							&riffbin.InStreamSubChunk{ID: [4]byte{'d', 'a', 't', 'a'}},
+						},
+					},
+					&riffbin.InStreamSubChunk{ID: [4]byte{'n', 'e', 'x', 't'}},
+				},
+			}
+
+			if df := cmp.Diff(riffChunk, expected, cmpopts.IgnoreUnexported(riffbin.RIFFChunk{}, riffbin.ListChunk{}, riffbin.InStreamSubChunk{}), cmpopts.IgnoreFields(riffbin.InStreamSubChunk{}, "SectionReader")); df != "" {
+				t.Fatalf("diff = %s", df)
+			}
+
+			got, err := io.ReadAll(riffChunk.Payload[1].(*riffbin.InStreamSubChunk))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if df := cmp.Diff([]byte{0x03, 0x04}, got); df != "" {
+				t.Errorf("diff = %s", df)
+			}
+		})
+
 		t.Run("EmptyPayload", func(t *testing.T) {
 			t.Parallel()
 
@@ -256,7 +297,7 @@ func TestReadSections(t *testing.T) {
 				Payload:  []riffbin.Chunk{},
 			}
 
-			if df := cmp.Diff(riffChunk, expected); df != "" {
+			if df := cmp.Diff(riffChunk, expected, cmpopts.IgnoreUnexported(riffbin.ListChunk{})); df != "" {
 				t.Errorf("diff = %s", df)
 			}
 		})
@@ -279,7 +320,7 @@ func TestReadSections(t *testing.T) {
 				},
 			}
 
-			if df := cmp.Diff(riffChunk, expected); df != "" {
+			if df := cmp.Diff(riffChunk, expected, cmpopts.IgnoreUnexported(riffbin.ListChunk{})); df != "" {
 				t.Errorf("diff = %s", df)
 			}
 		})
@@ -301,10 +342,67 @@ func TestReadSections(t *testing.T) {
 				},
 			}
 
-			if df := cmp.Diff(riffChunk, expected, cmpopts.IgnoreUnexported(riffbin.InStreamSubChunk{}), cmpopts.IgnoreFields(riffbin.InStreamSubChunk{}, "SectionReader")); df != "" {
+			if df := cmp.Diff(riffChunk, expected, cmpopts.IgnoreUnexported(riffbin.ListChunk{}, riffbin.InStreamSubChunk{}), cmpopts.IgnoreFields(riffbin.InStreamSubChunk{}, "SectionReader")); df != "" {
 				t.Fatalf("diff = %s", df)
 			}
 		})
+
+		t.Run("NestedListChunk", func(t *testing.T) {
+			t.Parallel()
+
+			riffChunk, err := riffbin.ReadSections(bytes.NewReader([]byte{
+				'R', 'I', 'F', 'F', 0x3A, 0x00, 0x00, 0x00, 'A', 'B', 'C', 'D',
+				'L', 'I', 'S', 'T', 0x24, 0x00, 0x00, 0x00, 'E', 'F', 'G', 'H',
+				'L', 'I', 'S', 'T', 0x0E, 0x00, 0x00, 0x00, 'I', 'J', 'K', 'L',
+				'd', 'a', 't', 'a', 0x02, 0x00, 0x00, 0x00, 0x01, 0x02,
+				't', 'a', 'i', 'l', 0x02, 0x00, 0x00, 0x00, 0x03, 0x04,
+				'n', 'e', 'x', 't', 0x02, 0x00, 0x00, 0x00, 0x05, 0x06,
+			}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := &riffbin.RIFFChunk{
+				FormType: [4]byte{'A', 'B', 'C', 'D'},
+				Payload: []riffbin.Chunk{
+					&riffbin.ListChunk{
+						ListType: [4]byte{'E', 'F', 'G', 'H'},
+						Payload: []riffbin.Chunk{
+							&riffbin.ListChunk{
+								ListType: [4]byte{'I', 'J', 'K', 'L'},
+								Payload: []riffbin.Chunk{
+									&riffbin.InStreamSubChunk{ID: [4]byte{'d', 'a', 't', 'a'}},
+								},
+							},
+							&riffbin.InStreamSubChunk{ID: [4]byte{'t', 'a', 'i', 'l'}},
+						},
+					},
+					&riffbin.InStreamSubChunk{ID: [4]byte{'n', 'e', 'x', 't'}},
+				},
+			}
+
+			if df := cmp.Diff(riffChunk, expected, cmpopts.IgnoreUnexported(riffbin.ListChunk{}, riffbin.InStreamSubChunk{}), cmpopts.IgnoreFields(riffbin.InStreamSubChunk{}, "SectionReader")); df != "" {
+				t.Fatalf("diff = %s", df)
+			}
+
+			outer := riffChunk.Payload[0].(*riffbin.ListChunk)
+			for i, tt := range []struct {
+				Chunk    riffbin.Chunk
+				Expected []byte
+			}{
+				{outer.Payload[0].(*riffbin.ListChunk).Payload[0], []byte{0x01, 0x02}},
+				{outer.Payload[1], []byte{0x03, 0x04}},
+				{riffChunk.Payload[1], []byte{0x05, 0x06}},
+			} {
+				got, err := io.ReadAll(tt.Chunk.(*riffbin.InStreamSubChunk))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if df := cmp.Diff(tt.Expected, got); df != "" {
+					t.Errorf("Chunk[%d]: diff = %s", i, df)
+				}
+			}
+		})
 	})
 
 	t.Run("InvalidFormat", func(t *testing.T) {
@@ -318,12 +416,16 @@ func TestReadSections(t *testing.T) {
 			{"InvalidRIFFID", []byte("LIFF")},
 			{"TooShortSize", []byte{'R', 'I', 'F', 'F', 0x04, 0x00, 0x00}},
 			{"TooShortType", []byte{'R', 'I', 'F', 'F', 0x04, 0x00, 0x00, 0x00, 'X', 'X', 'X'}},
+			{"TooLongListChunkByTotalSize", []byte{'R', 'I', 'F', 'F', 0x10, 0x00, 0x00, 0x00, 'A', 'B', 'C', 'D', 'L', 'I', 'S', 'T', 0x08, 0x00, 0x00, 0x00, 'E', 'F', 'G', 'H'}},
+			{"TooLongSubChunkPayloadInListChunk", []byte{'R', 'I', 'F', 'F', 0x18, 0x00, 0x00, 0x00, 'A', 'B', 'C', 'D', 'L', 'I', 'S', 'T', 0x0C, 0x00, 0x00, 0x00, 'E', 'F', 'G', 'H', 'd', 'a', 't', 'a', 0x02, 0x00, 0x00, 0x00, 0x01, 0x02}},
 			{"TooLargeTotalSize", []byte{'R', 'I', 'F', 'F', 0x05, 0x00, 0x00, 0x00, 'A', 'B', 'C', 'D'}},
 			{"TooSmallTotalSize", []byte{'R', 'I', 'F', 'F', 0x07, 0x00, 0x00, 0x00, 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 0x00, 0x00, 0x00, 0x00}},
 			{"TooShortSubChunkPayloadByTotalSize", []byte{'R', 'I', 'F', 'F', 0x09, 0x00, 0x00, 0x00, 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 0x00, 0x00, 0x00, 0x00}},
 			{"TooShortSubChunkPayloadBySubChunkSize", []byte{'R', 'I', 'F', 'F', 0x08, 0x00, 0x00, 0x00, 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 0x01, 0x00, 0x00, 0x00}},
 			{"TooLongSubChunkPayloadByTotalSize", []byte{'R', 'I', 'F', 'F', 0x09, 0x00, 0x00, 0x00, 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 0x02, 0x00, 0x00, 0x00, 'A', 'B'}},
 			{"TooLongSubChunkPayloadBySubChunkSize", []byte{'R', 'I', 'F', 'F', 0x0A, 0x00, 0x00, 0x00, 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 0x01, 0x00, 0x00, 0x00, 'A', 'B'}},
+			{"TruncatedSubChunkPayload", []byte{'R', 'I', 'F', 'F', 0x0E, 0x00, 0x00, 0x00, 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 0x02, 0x00, 0x00, 0x00, 'A'}},
+			{"TooLongSubChunkPayloadByListChunkSize", []byte{'R', 'I', 'F', 'F', 0x1C, 0x00, 0x00, 0x00, 'A', 'B', 'C', 'D', 'L', 'I', 'S', 'T', 0x0E, 0x00, 0x00, 0x00, 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 0x04, 0x00, 0x00, 0x00, 'A', 'B', 'C', 'D'}},
 		} {
 			tt := tt
 			t.Run(tt.Name, func(t *testing.T) {
@@ -340,6 +442,311 @@ func TestReadSections(t *testing.T) {
 	})
 }
 
+// framedReader reads the data until end, but it can seek over the whole data like a framed stream on a file.
+type framedReader struct {
+	*bytes.Reader
+	end int64
+}
+
+func (r *framedReader) Read(p []byte) (int, error) {
+	pos, _ := r.Seek(0, io.SeekCurrent)
+	if pos >= r.end {
+		return 0, io.EOF
+	}
+	if rest := r.end - pos; int64(len(p)) > rest {
+		p = p[:rest]
+	}
+	return r.Reader.Read(p)
+}
+
+func TestReadSectionsPosition(t *testing.T) {
+	t.Parallel()
+
+	var src bytes.Buffer
+	if _, err := riffbin.NewCompletedChunkWriter(&src).Write(newComplexRIFFChunk()); err != nil {
+		t.Fatal(err)
+	}
+	end := int64(src.Len())
+	src.Write([]byte("trailing data"))
+
+	r := &framedReader{Reader: bytes.NewReader(src.Bytes()), end: end}
+	if _, err := riffbin.ReadSections(r); err != nil {
+		t.Fatal(err)
+	}
+	if pos, err := r.Seek(0, io.SeekCurrent); err != nil {
+		t.Fatal(err)
+	} else if pos != end {
+		t.Errorf("position should be kept at the end of RIFF chunk (%d) but got %d", end, pos)
+	}
+}
+
+type progressRecord struct {
+	Path string
+	N    int64
+}
+
+func TestReadContext(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if _, err := riffbin.NewCompletedChunkWriter(&buf).Write(&riffbin.RIFFChunk{
+		FormType: [4]byte{'T', 'E', 'S', 'T'},
+		Payload: []riffbin.Chunk{
+			&riffbin.ListChunk{
+				ListType: [4]byte{'L', 'S', 'T', '1'},
+				Payload: []riffbin.Chunk{
+					&riffbin.OnMemorySubChunk{ID: [4]byte{'E', 'N', 'T', '1'}, Payload: []byte{0x01}},
+				},
+			},
+			&riffbin.OnMemorySubChunk{ID: [4]byte{'E', 'N', 'T', '1'}, Payload: []byte{0x01, 0x02}},
+			&riffbin.OnMemorySubChunk{ID: [4]byte{'E', 'N', 'T', '1'}, Payload: []byte{0x01, 0x02, 0x03}},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("ReadFullContext", func(t *testing.T) {
+		t.Parallel()
+
+		var got []progressRecord
+		_, err := riffbin.ReadFullContext(context.Background(), bytes.NewReader(buf.Bytes()), func(path string, n int64) {
+			got = append(got, progressRecord{path, n})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []progressRecord{
+			{".", 12},
+			{"LIST-LST1", 24},
+			{"LIST-LST1/ENT1", 32},
+			{"LIST-LST1/ENT1", 33},
+			{"ENT1", 41},
+			{"ENT1", 43},
+			{"ENT1.1", 51},
+			{"ENT1.1", 54},
+		}
+		if df := cmp.Diff(expected, got); df != "" {
+			t.Errorf("diff = %s", df)
+		}
+	})
+
+	t.Run("ReadSectionsContext", func(t *testing.T) {
+		t.Parallel()
+
+		var got []progressRecord
+		_, err := riffbin.ReadSectionsContext(context.Background(), bytes.NewReader(buf.Bytes()), func(path string, n int64) {
+			got = append(got, progressRecord{path, n})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if last := got[len(got)-1]; last.N != int64(buf.Len()) {
+			t.Errorf("unexpected last progress: %+v", last)
+		}
+	})
+
+	t.Run("SameAsWriter", func(t *testing.T) {
+		t.Parallel()
+
+		c, err := riffbin.ReadFull(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var expected []progressRecord
+		if _, err := riffbin.NewCompletedChunkWriter(io.Discard).WriteContext(context.Background(), c, func(path string, n int64) {
+			expected = append(expected, progressRecord{path, n})
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		var full, sections []progressRecord
+		if _, err := riffbin.ReadFullContext(context.Background(), bytes.NewReader(buf.Bytes()), func(path string, n int64) {
+			full = append(full, progressRecord{path, n})
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := riffbin.ReadSectionsContext(context.Background(), bytes.NewReader(buf.Bytes()), func(path string, n int64) {
+			sections = append(sections, progressRecord{path, n})
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if df := cmp.Diff(expected, full); df != "" {
+			t.Errorf("ReadFullContext: diff = %s", df)
+		}
+		if df := cmp.Diff(expected, sections); df != "" {
+			t.Errorf("ReadSectionsContext: diff = %s", df)
+		}
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		c, err := riffbin.ReadFullContext(ctx, bytes.NewReader(buf.Bytes()), func(path string, n int64) {
+			if path == "LIST-LST1" {
+				cancel()
+			}
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if c != nil {
+			t.Error("riff chunk should be nil")
+		}
+	})
+}
+
+func TestChunkLocation(t *testing.T) {
+	t.Parallel()
+
+	// 4 bytes prefix to verify absolute offsets
+	buf := bytes.NewBuffer([]byte{0x00, 0x00, 0x00, 0x00})
+	if _, err := riffbin.NewCompletedChunkWriter(buf).Write(&riffbin.RIFFChunk{
+		FormType: [4]byte{'T', 'E', 'S', 'T'},
+		Payload: []riffbin.Chunk{
+			&riffbin.ListChunk{
+				ListType: [4]byte{'L', 'S', 'T', '1'},
+				Payload: []riffbin.Chunk{
+					&riffbin.OnMemorySubChunk{ID: [4]byte{'E', 'N', 'T', '1'}, Payload: []byte{0x01}},
+				},
+			},
+			&riffbin.OnMemorySubChunk{ID: [4]byte{'E', 'N', 'T', '2'}, Payload: []byte{0x01, 0x02}},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []riffbin.ChunkLocation{
+		{HeaderOffset: 4, BodyOffset: 12, DeclaredSize: 35},
+		{HeaderOffset: 16, BodyOffset: 24, DeclaredSize: 13},
+		{HeaderOffset: 28, BodyOffset: 36, DeclaredSize: 1},
+		{HeaderOffset: 37, BodyOffset: 45, DeclaredSize: 2},
+	}
+	collect := func(t *testing.T, c *riffbin.RIFFChunk, locs *riffbin.ChunkLocations) []riffbin.ChunkLocation {
+		var got []riffbin.ChunkLocation
+		for _, chunk := range []riffbin.Chunk{c, c.Payload[0], c.Payload[0].(*riffbin.ListChunk).Payload[0], c.Payload[1]} {
+			loc, ok := locs.Lookup(chunk)
+			if !ok {
+				t.Errorf("location of %q is not found", chunk.ChunkID())
+			}
+			got = append(got, loc)
+		}
+		return got
+	}
+
+	t.Run("ReadFull", func(t *testing.T) {
+		t.Parallel()
+
+		r := bytes.NewReader(buf.Bytes()[4:])
+		locs := &riffbin.ChunkLocations{}
+		c, err := riffbin.ReadFull(r, riffbin.WithChunkLocations(locs))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// offsets are relative to the head of io.Reader
+		got := collect(t, c, locs)
+		for i := range got {
+			got[i].HeaderOffset += 4
+			got[i].BodyOffset += 4
+		}
+		if df := cmp.Diff(expected, got); df != "" {
+			t.Errorf("diff = %s", df)
+		}
+	})
+
+	t.Run("ReadSections", func(t *testing.T) {
+		t.Parallel()
+
+		r := bytes.NewReader(buf.Bytes())
+		if _, err := r.Seek(4, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		locs := &riffbin.ChunkLocations{}
+		c, err := riffbin.ReadSections(r, riffbin.WithChunkLocations(locs))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// offsets are counted from the origin of io.Seeker, not from the start position
+		if df := cmp.Diff(expected, collect(t, c, locs)); df != "" {
+			t.Errorf("diff = %s", df)
+		}
+	})
+
+	t.Run("NotParsed", func(t *testing.T) {
+		t.Parallel()
+
+		locs := &riffbin.ChunkLocations{}
+		if _, err := riffbin.ReadSections(bytes.NewReader(buf.Bytes()[4:]), riffbin.WithChunkLocations(locs)); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := locs.Lookup(&riffbin.ListChunk{}); ok {
+			t.Error("location should not be found")
+		}
+	})
+}
+
+// to hide io.Seeker
+type pureReaderAt struct {
+	R io.ReaderAt
+}
+
+func (r *pureReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	return r.R.ReadAt(p, off)
+}
+
+func TestReadSectionsAt(t *testing.T) {
+	t.Parallel()
+
+	var src bytes.Buffer
+	if _, err := riffbin.NewCompletedChunkWriter(&src).Write(newComplexRIFFChunk()); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Concurrent", func(t *testing.T) {
+		t.Parallel()
+
+		r := &pureReaderAt{R: bytes.NewReader(src.Bytes())}
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				riffChunk, err := riffbin.ReadSectionsAt(r, int64(src.Len()))
+				if err != nil {
+					t.Error(err)
+					return
+				}
+
+				var buf bytes.Buffer
+				if _, err := riffbin.NewCompletedChunkWriter(&buf).Write(riffChunk); err != nil {
+					t.Error(err)
+					return
+				}
+				if !bytes.Equal(buf.Bytes(), src.Bytes()) {
+					t.Error("unexpected bytes are written")
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("InvalidFormat", func(t *testing.T) {
+		t.Parallel()
+
+		r := &pureReaderAt{R: bytes.NewReader(src.Bytes())}
+		if _, err := riffbin.ReadSectionsAt(r, int64(src.Len()-1)); err != riffbin.ErrInvalidFormat {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
 func ExampleReadSections() {
 	const binary = "UklGRvQHAABXQVZFZm10IBAAAAABAAEARKwAAESsAAABAAgAZGF0YdAHAAB/h4+Xn6autbzDytDW3OHm6+/y9vj6/P3+//79/Pr49vLv6+bh3NbQysO8ta6mn5ePh393b2dfWFBJQjs0LigiHRgTDwwIBgQCAQAAAAECBAYIDA8TGB0iKC40O0JJUFhfZ293f4ePl5+mrrW8w8rQ1tzh5uvv8vb4+vz9/v/+/fz6+Pby7+vm4dzW0MrDvLWupp+Xj4d/d29nX1hQSUI7NC4oIh0YEw8MCAYEAgEAAAABAgQGCAwPExgdIiguNDtCSVBYX2dvd3+Hj5efpq61vMPK0Nbc4ebr7/L2+Pr8/f7//v38+vj28u/r5uHc1tDKw7y1rqafl4+Hf3dvZ19YUElCOzQuKCIdGBMPDAgGBAIBAAAAAQIEBggMDxMYHSIoLjQ7QklQWF9nb3d/h4+Xn6autbzDytDW3OHm6+/y9vj6/P3+//79/Pr49vLv6+bh3NbQysO8ta6mn5ePh393b2dfWFBJQjs0LigiHRgTDwwIBgQCAQAAAAECBAYIDA8TGB0iKC40O0JJUFhfZ293f4ePl5+mrrW8w8rQ1tzh5uvv8vb4+vz9/v/+/fz6+Pby7+vm4dzW0MrDvLWupp+Xj4d/d29nX1hQSUI7NC4oIh0YEw8MCAYEAgEAAAABAgQGCAwPExgdIiguNDtCSVBYX2dvd3+Hj5efpq61vMPK0Nbc4ebr7/L2+Pr8/f7//v38+vj28u/r5uHc1tDKw7y1rqafl4+Hf3dvZ19YUElCOzQuKCIdGBMPDAgGBAIBAAAAAQIEBggMDxMYHSIoLjQ7QklQWF9nb3d/h4+Xn6autbzDytDW3OHm6+/y9vj6/P3+//79/Pr49vLv6+bh3NbQysO8ta6mn5ePh393b2dfWFBJQjs0LigiHRgTDwwIBgQCAQAAAAECBAYIDA8TGB0iKC40O0JJUFhfZ293f4ePl5+mrrW8w8rQ1tzh5uvv8vb4+vz9/v/+/fz6+Pby7+vm4dzW0MrDvLWupp+Xj4d/d29nX1hQSUI7NC4oIh0YEw8MCAYEAgEAAAABAgQGCAwPExgdIiguNDtCSVBYX2dvd3+Hj5efpq61vMPK0Nbc4ebr7/L2+Pr8/f7//v38+vj28u/r5uHc1tDKw7y1rqafl4+Hf3dvZ19YUElCOzQuKCIdGBMPDAgGBAIBAAAAAQIEBggMDxMYHSIoLjQ7QklQWF9nb3d/h4+Xn6autbzDytDW3OHm6+/y9vj6/P3+//79/Pr49vLv6+bh3NbQysO8ta6mn5ePh393b2dfWFBJQjs0LigiHRgTDwwIBgQCAQAAAAECBAYIDA8TGB0iKC40O0JJUFhfZ293f4ePl5+mrrW8w8rQ1tzh5uvv8vb4+vz9/v/+/fz6+Pby7+vm4dzW0MrDvLWupp+Xj4d/d29nX1hQSUI7NC4oIh0YEw8MCAYEAgEAAAABAgQGCAwPExgdIiguNDtCSVBYX2dvd3+Hj5efpq61vMPK0Nbc4ebr7/L2+Pr8/f7//v38+vj28u/r5uHc1tDKw7y1rqafl4+Hf3dvZ19YUElCOzQuKCIdGBMPDAgGBAIBAAAAAQIEBggMDxMYHSIoLjQ7QklQWF9nb3d/h4+Xn6autbzDytDW3OHm6+/y9vj6/P3+//79/Pr49vLv6+bh3NbQysO8ta6mn5ePh393b2dfWFBJQjs0LigiHRgTDwwIBgQCAQAAAAECBAYIDA8TGB0iKC40O0JJUFhfZ293f4ePl5+mrrW8w8rQ1tzh5uvv8vb4+vz9/v/+/fz6+Pby7+vm4dzW0MrDvLWupp+Xj4d/d29nX1hQSUI7NC4oIh0YEw8MCAYEAgEAAAABAgQGCAwPExgdIiguNDtCSVBYX2dvd3+Hj5efpq61vMPK0Nbc4ebr7/L2+Pr8/f7//v38+vj28u/r5uHc1tDKw7y1rqafl4+Hf3dvZ19YUElCOzQuKCIdGBMPDAgGBAIBAAAAAQIEBggMDxMYHSIoLjQ7QklQWF9nb3d/h4+Xn6autbzDytDW3OHm6+/y9vj6/P3+//79/Pr49vLv6+bh3NbQysO8ta6mn5ePh393b2dfWFBJQjs0LigiHRgTDwwIBgQCAQAAAAECBAYIDA8TGB0iKC40O0JJUFhfZ293f4ePl5+mrrW8w8rQ1tzh5uvv8vb4+vz9/v/+/fz6+Pby7+vm4dzW0MrDvLWupp+Xj4d/d29nX1hQSUI7NC4oIh0YEw8MCAYEAgEAAAABAgQGCAwPExgdIiguNDtCSVBYX2dvd3+Hj5efpq61vMPK0Nbc4ebr7/L2+Pr8/f7//v38+vj28u/r5uHc1tDKw7y1rqafl4+Hf3dvZ19YUElCOzQuKCIdGBMPDAgGBAIBAAAAAQIEBggMDxMYHSIoLjQ7QklQWF9nb3d/h4+Xn6autbzDytDW3OHm6+/y9vj6/P3+//79/Pr49vLv6+bh3NbQysO8ta6mn5ePh393b2dfWFBJQjs0LigiHRgTDwwIBgQCAQAAAAECBAYIDA8TGB0iKC40O0JJUFhfZ293f4ePl5+mrrW8w8rQ1tzh5uvv8vb4+vz9/v/+/fz6+Pby7+vm4dzW0MrDvLWupp+Xj4d/d29nX1hQSUI7NC4oIh0YEw8MCAYEAgEAAAABAgQGCAwPExgdIiguNDtCSVBYX2dvdw=="
 	decodedBin, err := base64.StdEncoding.DecodeString(binary)
@@ -0,0 +1,278 @@
+package riffbin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// StreamHeader is a header of the chunk read by StreamReader.
+type StreamHeader struct {
+	// ID is the chunk ID.
+	ID [idBytes]byte
+
+	// Type is the form type of RIFF chunk or the list type of LIST chunk. It is zero for the sub-chunks.
+	Type [typeBytes]byte
+
+	// Path is the slash-separated name of the chunk as same as ProgressFunc (e.g. "LIST-INFO/ISFT", "." is the root RIFF chunk).
+	Path string
+
+	// Depth is the depth of the chunk in the tree. It is 0 for the root RIFF chunk.
+	Depth int
+
+	// Location is the location of the chunk relative to the head of the stream.
+	Location ChunkLocation
+}
+
+// IsGrouped returns true if the chunk is RIFF or LIST chunk.
+func (h *StreamHeader) IsGrouped() bool {
+	return bytes.Equal(h.ID[:], riffID[:]) || bytes.Equal(h.ID[:], listID[:])
+}
+
+// StreamReader reads RIFF binary from io.Reader sequentially without keeping the chunk tree, like archive/tar.Reader.
+// It is useful for the huge binary or the non-seekable stream (e.g. stdin).
+type StreamReader struct {
+	r      io.Reader
+	format *chunkFormat
+	pos    int64
+	remain int64
+	pad    int64
+	frames []streamFrame
+	path   []string
+	done   bool
+	err    error
+}
+
+// streamFrame is the grouped chunk in reading.
+type streamFrame struct {
+	remain int64
+	pad    int64
+	names  chunkNamer
+}
+
+// NewStreamReader creates a new StreamReader.
+// The unread payloads are skipped by Seek if r is io.Seeker, otherwise they are discarded.
+// WithReadPadding is supported, and the other options are ignored.
+func NewStreamReader(r io.Reader, opts ...ReaderOption) *StreamReader {
+	cfg := newReaderConfig(opts)
+	return &StreamReader{r: r, format: cfg.riffFormat()}
+}
+
+// Next advances to the next chunk in the depth-first order, and returns its header.
+// It returns io.EOF at the end of the root RIFF chunk.
+func (r *StreamReader) Next() (*StreamHeader, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	h, err := r.next()
+	if err == io.EOF && r.done {
+		r.err = err
+		return nil, err
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		err = ErrInvalidFormat
+	}
+	if err != nil {
+		r.err = err
+		return nil, err
+	}
+	return h, nil
+}
+
+func (r *StreamReader) next() (*StreamHeader, error) {
+	if err := r.skip(); err != nil {
+		return nil, err
+	}
+
+	// close the finished grouped chunks, and skip their pad bytes
+	for len(r.frames) > 0 && r.frames[len(r.frames)-1].remain == 0 {
+		r.pad = r.frames[len(r.frames)-1].pad
+		r.frames = r.frames[:len(r.frames)-1]
+		if len(r.path) > 0 {
+			r.path = r.path[:len(r.path)-1]
+		}
+		if len(r.frames) == 0 {
+			// the pad byte of the root RIFF chunk may be omitted at the end of the stream
+			break
+		}
+		if err := r.skip(); err != nil {
+			return nil, err
+		}
+	}
+	if len(r.frames) == 0 && r.pos != 0 {
+		return nil, r.finish()
+	}
+
+	f, tree := r.format, r.format.tree
+	buf := make([]byte, f.headerBytes())
+	if err := r.readFull(buf); err != nil {
+		return nil, err
+	}
+	id, bodyLen, err := f.parseHeader(buf)
+	if err != nil {
+		return nil, err
+	}
+	if bodyLen > math.MaxUint32 {
+		return nil, ErrInvalidFormat
+	}
+
+	h := &StreamHeader{Depth: len(r.frames)}
+	copy(h.ID[:], id)
+	h.Location = ChunkLocation{HeaderOffset: r.pos - f.headerBytes(), BodyOffset: r.pos, DeclaredSize: uint32(bodyLen)}
+
+	var parent *streamFrame
+	var pad int64
+	if len(r.frames) == 0 {
+		if !tree.isRoot(id) {
+			return nil, ErrInvalidFormat
+		}
+		pad = f.padding(uint64(bodyLen))
+	} else {
+		parent = &r.frames[len(r.frames)-1]
+		size := f.headerBytes() + bodyLen
+		if parent.remain < size {
+			return nil, ErrInvalidFormat
+		}
+
+		// the pad byte of the last chunk in the parent may be omitted
+		pad = f.padding(uint64(bodyLen))
+		if pad > parent.remain-size {
+			pad = parent.remain - size
+		}
+		parent.remain -= size + pad
+		h.Location.PadBytes = pad
+	}
+
+	if !tree.isGroup(id) {
+		r.remain = bodyLen
+		r.pad = pad
+		h.Path = joinChunkPath(append(r.path[:len(r.path):len(r.path)], parent.names.next(h.ID[:], nil)))
+		return h, nil
+	}
+
+	if bodyLen < int64(f.idBytes) {
+		return nil, ErrInvalidFormat
+	}
+	if err := r.readFull(h.Type[:]); err != nil {
+		return nil, err
+	}
+	if parent != nil {
+		r.path = append(r.path, parent.names.next(h.ID[:], h.Type[:]))
+	}
+	h.Path = joinChunkPath(r.path)
+	r.frames = append(r.frames, streamFrame{remain: bodyLen - int64(f.idBytes), pad: pad, names: chunkNamer{}})
+	return h, nil
+}
+
+// maxConsecutiveEmptyReads is the maximum number of the reads which return 0 bytes without error in a row. (same as bufio)
+const maxConsecutiveEmptyReads = 100
+
+// finish verifies the stream ends with the root RIFF chunk and its pad byte.
+func (r *StreamReader) finish() error {
+	var buf [1]byte
+	for i := 0; ; i++ {
+		n, err := r.r.Read(buf[:])
+		if n != 0 && r.pad > 0 {
+			r.pos += int64(n)
+			r.pad -= int64(n)
+			continue
+		} else if n != 0 {
+			// too long payload (too small payload size)
+			return ErrInvalidFormat
+		} else if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		// try again for the reader which returns 0 bytes without error
+		if i+1 >= maxConsecutiveEmptyReads {
+			return io.ErrNoProgress
+		}
+	}
+
+	// verify the skipped payloads are not truncated
+	if s, ok := r.r.(io.Seeker); ok {
+		cur, err := s.Seek(0, io.SeekCurrent)
+		if err == nil {
+			end, err := s.Seek(0, io.SeekEnd)
+			if err == nil {
+				// keep the position of the caller
+				_, err = s.Seek(cur, io.SeekStart)
+			}
+			if err != nil {
+				return fmt.Errorf("seek: %w", err)
+			}
+			if end < cur {
+				return ErrInvalidFormat
+			}
+		}
+	}
+
+	r.done = true
+	return io.EOF
+}
+
+// skip skips the unread payload of the current sub-chunk and the pad bytes after it.
+func (r *StreamReader) skip() error {
+	size := r.remain + r.pad
+	if size == 0 {
+		return nil
+	}
+
+	if s, ok := r.r.(io.Seeker); ok {
+		if _, err := s.Seek(size, io.SeekCurrent); err == nil {
+			r.pos += size
+			r.remain, r.pad = 0, 0
+			return nil
+		}
+		// fallback to discard for the non-seekable stream (e.g. pipe)
+	}
+
+	n, err := io.CopyN(io.Discard, r.r, size)
+	r.pos += n
+	if n < r.remain {
+		r.remain -= n
+	} else {
+		r.pad -= n - r.remain
+		r.remain = 0
+	}
+	return err
+}
+
+func (r *StreamReader) readFull(p []byte) error {
+	n, err := io.ReadFull(r.r, p)
+	r.pos += int64(n)
+	return err
+}
+
+// Read reads the payload of the current sub-chunk. It returns io.EOF at the end of the payload.
+func (r *StreamReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	if r.remain == 0 {
+		return 0, io.EOF
+	}
+
+	if int64(len(p)) > r.remain {
+		p = p[:r.remain]
+	}
+	n, err := r.r.Read(p)
+	r.pos += int64(n)
+	r.remain -= int64(n)
+	if err == io.EOF && r.remain > 0 {
+		err = io.ErrUnexpectedEOF
+	} else if err == io.EOF {
+		err = nil
+	}
+	return n, err
+}
+
+// Offset returns the number of bytes processed from the head of the stream.
+func (r *StreamReader) Offset() int64 {
+	return r.pos
+}
@@ -0,0 +1,209 @@
+package riffbin_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/karupanerura/riffbin"
+)
+
+// pureReader hides the methods other than Read.
+type pureReader struct {
+	r io.Reader
+}
+
+func (r *pureReader) Read(p []byte) (int, error) {
+	return r.r.Read(p)
+}
+
+// emptyReader returns 0 bytes without error forever.
+type emptyReader struct{}
+
+func (emptyReader) Read([]byte) (int, error) {
+	return 0, nil
+}
+
+func TestStreamReader(t *testing.T) {
+	t.Parallel()
+
+	var src bytes.Buffer
+	if _, err := riffbin.NewCompletedChunkWriter(&src).Write(newComplexRIFFChunk()); err != nil {
+		t.Fatal(err)
+	}
+
+	type entry struct {
+		Path    string
+		Depth   int
+		Grouped bool
+		Header  int64
+		Size    uint32
+		Payload []byte
+	}
+
+	expected := []entry{
+		{Path: ".", Depth: 0, Grouped: true, Header: 0, Size: 121},
+		{Path: "LIST-LST1", Depth: 1, Grouped: true, Header: 12, Size: 58},
+		{Path: "LIST-LST1/ENT1", Depth: 2, Header: 24, Size: 1, Payload: []byte{0x01}},
+		{Path: "LIST-LST1/LIST-LST2", Depth: 2, Grouped: true, Header: 33, Size: 25},
+		{Path: "LIST-LST1/LIST-LST2/ENT2", Depth: 3, Header: 45, Size: 2, Payload: []byte{0x01, 0x02}},
+		{Path: "LIST-LST1/LIST-LST2/ENT3", Depth: 3, Header: 55, Size: 3, Payload: []byte{0x01, 0x02, 0x03}},
+		{Path: "LIST-LST1/ENT4", Depth: 2, Header: 66, Size: 4, Payload: []byte{0x01, 0x02, 0x03, 0x04}},
+		{Path: "ENT5", Depth: 1, Header: 78, Size: 5, Payload: []byte{0x01, 0x02, 0x03, 0x04, 0x05}},
+		{Path: "LIST-LST3", Depth: 1, Grouped: true, Header: 91, Size: 4},
+		{Path: "LIST-LST4", Depth: 1, Grouped: true, Header: 103, Size: 18},
+		{Path: "LIST-LST4/ENT6", Depth: 2, Header: 115, Size: 6, Payload: []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06}},
+	}
+
+	for _, tc := range []struct {
+		name        string
+		newReader   func(b []byte) io.Reader
+		readPayload bool
+	}{
+		{name: "Seekable", newReader: func(b []byte) io.Reader { return bytes.NewReader(b) }, readPayload: true},
+		{name: "SeekableSkip", newReader: func(b []byte) io.Reader { return bytes.NewReader(b) }},
+		{name: "Stream", newReader: func(b []byte) io.Reader { return &pureReader{r: bytes.NewReader(b)} }, readPayload: true},
+		{name: "StreamSkip", newReader: func(b []byte) io.Reader { return &pureReader{r: bytes.NewReader(b)} }},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := riffbin.NewStreamReader(tc.newReader(src.Bytes()))
+			var got []entry
+			for {
+				h, err := r.Next()
+				if err == io.EOF {
+					break
+				} else if err != nil {
+					t.Fatal(err)
+				}
+
+				e := entry{Path: h.Path, Depth: h.Depth, Grouped: h.IsGrouped(), Header: h.Location.HeaderOffset, Size: h.Location.DeclaredSize}
+				if tc.readPayload && !h.IsGrouped() {
+					if e.Payload, err = io.ReadAll(r); err != nil {
+						t.Fatal(err)
+					}
+				}
+				got = append(got, e)
+			}
+			if r.Offset() != int64(src.Len()) {
+				t.Errorf("unexpected offset: %d", r.Offset())
+			}
+
+			want := expected
+			if !tc.readPayload {
+				want = make([]entry, len(expected))
+				for i, e := range expected {
+					e.Payload = nil
+					want[i] = e
+				}
+			}
+			if df := cmp.Diff(got, want); df != "" {
+				t.Errorf("diff = %s", df)
+			}
+
+			// sticky EOF
+			if _, err := r.Next(); err != io.EOF {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+
+	t.Run("Padded", func(t *testing.T) {
+		t.Parallel()
+
+		var padded bytes.Buffer
+		if _, err := riffbin.NewCompletedChunkWriter(&padded, riffbin.WithWritePadding()).Write(newComplexRIFFChunk()); err != nil {
+			t.Fatal(err)
+		}
+		locs := &riffbin.ChunkLocations{}
+		c, err := riffbin.ReadSections(bytes.NewReader(padded.Bytes()), riffbin.WithReadPadding(), riffbin.WithChunkLocations(locs))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, stream := range []bool{false, true} {
+			var rr io.Reader = bytes.NewReader(padded.Bytes())
+			if stream {
+				rr = &pureReader{r: rr}
+			}
+
+			r := riffbin.NewStreamReader(rr, riffbin.WithReadPadding())
+			var paths []string
+			for {
+				h, err := r.Next()
+				if err == io.EOF {
+					break
+				} else if err != nil {
+					t.Fatalf("stream=%v: %v", stream, err)
+				}
+
+				chunk, err := riffbin.LookupChunk(c, h.Path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				loc, _ := locs.Lookup(chunk)
+				if h.Location != loc {
+					t.Errorf("stream=%v: %s: unexpected location: %+v (expected: %+v)", stream, h.Path, h.Location, loc)
+				}
+				paths = append(paths, h.Path)
+			}
+			if r.Offset() != int64(padded.Len()) {
+				t.Errorf("stream=%v: unexpected offset: %d", stream, r.Offset())
+			}
+			if len(paths) != len(expected) {
+				t.Errorf("stream=%v: unexpected chunks: %v", stream, paths)
+			}
+		}
+	})
+
+	t.Run("NoProgress", func(t *testing.T) {
+		t.Parallel()
+
+		r := riffbin.NewStreamReader(io.MultiReader(bytes.NewReader(src.Bytes()), emptyReader{}))
+		var err error
+		for err == nil {
+			_, err = r.Next()
+		}
+		if !errors.Is(err, io.ErrNoProgress) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+
+		cases := map[string][]byte{
+			"Empty":         {},
+			"NotRIFF":       []byte("LIST\x04\x00\x00\x00ABCD"),
+			"TooLongRIFF":   append(append([]byte{}, src.Bytes()...), 0x00),
+			"TooLargeChild": []byte("RIFF\x10\x00\x00\x00ABCDEFGH\x05\x00\x00\x00\x01\x02\x03\x04"),
+			"ShortListType": []byte("RIFF\x0E\x00\x00\x00ABCDLIST\x02\x00\x00\x00EF"),
+		}
+		for _, size := range []int{7, 20, 50, src.Len() - 1} {
+			cases[fmt.Sprintf("Truncated%d", size)] = src.Bytes()[:size]
+		}
+
+		for name, b := range cases {
+			for _, stream := range []bool{false, true} {
+				var rr io.Reader = bytes.NewReader(b)
+				if stream {
+					rr = &pureReader{r: rr}
+				}
+
+				r := riffbin.NewStreamReader(rr)
+				var err error
+				for err == nil {
+					_, err = r.Next()
+				}
+				if !errors.Is(err, riffbin.ErrInvalidFormat) {
+					t.Errorf("%s (stream=%v): unexpected error: %v", name, stream, err)
+				}
+			}
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package riffbin
 
 import (
+	"context"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -18,173 +19,251 @@ type ChunkWriter interface {
 
 // CompletedChunkWriter is a RIFF chunk writer for the completed chunk.
 type CompletedChunkWriter struct {
-	w io.Writer
+	w   io.Writer
+	cfg writerConfig
 }
 
 var _ ChunkWriter = (*CompletedChunkWriter)(nil)
 
-func NewCompletedChunkWriter(w io.Writer) *CompletedChunkWriter {
-	return &CompletedChunkWriter{w: w}
+func NewCompletedChunkWriter(w io.Writer, opts ...WriterOption) *CompletedChunkWriter {
+	return &CompletedChunkWriter{w: w, cfg: newWriterConfig(opts)}
 }
 
 // Write writes the RIFF message to the underlying data stream.
 // It returns the number of bytes written and any error encountered that caused the write to stop early. (same as Write of io.Writer)
 func (w *CompletedChunkWriter) Write(c *RIFFChunk) (int64, error) {
-	return writeChunk(w.w, c, false)
+	return w.WriteContext(context.Background(), c, nil)
+}
+
+// WriteContext is same as Write, but it stops writing when ctx is done.
+// progress is called with the path of the chunk in writing and the total bytes written if it is not nil.
+func (w *CompletedChunkWriter) WriteContext(ctx context.Context, c *RIFFChunk, progress ProgressFunc) (int64, error) {
+	return writeChunk(&writeState{ctx: ctx, w: w.w, progress: progress, format: w.cfg.format}, w.cfg.layout(c, 0))
 }
 
 // IncompleteChunkWriter is a RIFF chunk writer for the incomplete chunk.
 type IncompleteChunkWriter struct {
 	w    io.WriteSeeker
 	head int64
+	cfg  writerConfig
 }
 
 var _ ChunkWriter = (*IncompleteChunkWriter)(nil)
 
 // NewIncompleteChunkWriter creates a new IncompleteChunkWriter.
-func NewIncompleteChunkWriter(w io.WriteSeeker) (*IncompleteChunkWriter, error) {
+func NewIncompleteChunkWriter(w io.WriteSeeker, opts ...WriterOption) (*IncompleteChunkWriter, error) {
 	pos, err := w.Seek(0, io.SeekCurrent)
 	if err != nil {
 		return nil, fmt.Errorf("seek: %w", err)
 	}
 
-	return &IncompleteChunkWriter{w: w, head: pos}, nil
+	return &IncompleteChunkWriter{w: w, head: pos, cfg: newWriterConfig(opts)}, nil
 }
 
 // Write writes the RIFF message to the underlying data stream, and re-write the bytes of the all chunk headers size to fix incomplete body bytes by random write.
 // It returns the number of bytes written and any error encountered that caused the write to stop early. (same as Write of io.Writer)
-func (w *IncompleteChunkWriter) Write(c *RIFFChunk) (n int64, err error) {
-	n, err = writeChunk(w.w, c, true)
+func (w *IncompleteChunkWriter) Write(c *RIFFChunk) (int64, error) {
+	return w.WriteContext(context.Background(), c, nil)
+}
+
+// WriteContext is same as Write, but it stops writing when ctx is done.
+// progress is called with the path of the chunk in writing and the total bytes written if it is not nil.
+// The chunk headers are not re-written if it is stopped by ctx.
+func (w *IncompleteChunkWriter) WriteContext(ctx context.Context, c *RIFFChunk, progress ProgressFunc) (n int64, err error) {
+	c = w.cfg.layout(c, w.head)
+	n, err = writeChunk(&writeState{ctx: ctx, w: w.w, progress: progress, format: w.cfg.format, allowIncomplete: true, base: w.head}, c)
 	if err != nil {
 		err = fmt.Errorf("writeChunk at first: %w", err)
 		return
 	}
 
-	// XXX: shared state for absolute seek position
-	posState := w.head
-
-	var chunkBodyRandomWriter func(b uint32) error
-	if ww, ok := w.w.(io.WriterAt); ok {
-		// io.WriterAt for optimize
-		chunkBodyRandomWriter = func(b uint32) error {
-			_, err := writeChunkBodySizeAt(ww, b, posState)
-			if err != nil {
-				return fmt.Errorf("writeChunkBodySizeAt: %w", err)
-			}
+	err = patchBodySizes(w.w, w.cfg.format, c, w.head, n)
+	return
+}
 
-			return nil
-		}
-	} else {
+// patchBodySizes re-writes the body sizes of the all chunks in c of n bytes written at head to fix the incomplete body bytes by random write.
+// The seek position is moved to the end of the written bytes after patching.
+func patchBodySizes(w io.WriteSeeker, f *chunkFormat, c interface{}, head, n int64) (err error) {
+	pw := &patchWriter{w: w}
+	if !pw.writerAt() {
 		// revert seek position
 		defer func() {
-			_, err = w.w.Seek(w.head+n, io.SeekStart)
-			if err != nil {
-				err = fmt.Errorf("seek: %w", err)
+			if _, seekErr := w.Seek(head+n, io.SeekStart); seekErr != nil && err == nil {
+				err = fmt.Errorf("seek: %w", seekErr)
 			}
 		}()
-
-		// random write by io.WriteSeeker
-		chunkBodyRandomWriter = func(b uint32) error {
-			_, err := w.w.Seek(posState, io.SeekStart)
-			if err != nil {
-				return fmt.Errorf("seek: %w", err)
-			}
-
-			_, err = writeChunkBodySize(w.w, b)
-			if err != nil {
-				return fmt.Errorf("writeChunkBodySizeAt: %w", err)
-			}
-
-			return nil
-		}
 	}
 
+	// XXX: shared state for absolute seek position
+	posState := head
+
 	// write complete to re-write finally fixed body size
-	err = writeComplete(c, &posState, chunkBodyRandomWriter)
-	if err != nil {
-		err = fmt.Errorf("write complete: %w", err)
-		return
+	if err := writeComplete(f, c, &posState, pw.patch); err != nil {
+		return fmt.Errorf("write complete: %w", err)
 	}
-
-	return
+	return nil
 }
 
-func writeComplete(c Chunk, pos *int64, f func(b uint32) error) error {
-	*pos += idBytes
-	b := c.BodySize()
-	err := f(b)
-	if err != nil {
-		return err
+// writeComplete patches the size field of c placed at pos and the chunks in c, and moves pos to the next chunk.
+func writeComplete(f *chunkFormat, c interface{}, pos *int64, patch func(b []byte, off int64) error) error {
+	if f.chunkBytes(c) == 0 {
+		// skipped JUNK chunk
+		return nil
 	}
-	*pos += sizeBytes
 
-	switch cc := c.(type) {
-	case groupedChunk:
-		*pos += typeBytes
-		for _, p := range cc.payload() {
-			err := writeComplete(p, pos, f)
-			if err != nil {
-				return err
+	b := f.bodySize(c)
+	buf := make([]byte, f.sizeBytes)
+	f.putSize(buf, b)
+	if err := patch(buf, *pos+int64(f.idBytes)); err != nil {
+		return err
+	}
+	*pos += f.headerBytes()
+
+	if _, ok := f.tree.groupType(c); ok {
+		*pos += int64(f.idBytes)
+		if section, ok := unloadedPayload(c); ok {
+			*pos += section.Size()
+		} else {
+			for _, p := range f.tree.payload(c) {
+				if err := writeComplete(f, p, pos, patch); err != nil {
+					return err
+				}
 			}
 		}
-	case SubChunk:
+	} else {
 		*pos += int64(b)
 	}
+	*pos += f.padding(b)
 
 	return nil
 }
 
-func writeChunk(w io.Writer, c Chunk, allowIncomplete bool) (n int64, err error) {
-	n, err = writeChunkHeader(w, c)
-	if err != nil {
-		err = fmt.Errorf("chunk[%q] header: %w", string(c.ChunkID()), err)
-		return
+// patchWriter rewrites the written bytes at the absolute position by random write.
+// It uses io.WriterAt if it is available, or moves the seek position of io.WriteSeeker.
+type patchWriter struct {
+	w io.WriteSeeker
+}
+
+// writerAt returns true if the seek position is kept.
+func (pw *patchWriter) writerAt() bool {
+	_, ok := pw.w.(io.WriterAt)
+	return ok
+}
+
+func (pw *patchWriter) patch(b []byte, off int64) error {
+	if ww, ok := pw.w.(io.WriterAt); ok {
+		// io.WriterAt for optimize
+		if _, err := ww.WriteAt(b, off); err != nil {
+			return fmt.Errorf("patch at %d: %w", off, err)
+		}
+		return nil
 	}
 
-	var nn int64
-	nn, err = writeChunkBody(w, c, allowIncomplete)
-	n += nn
-	if err != nil {
-		err = fmt.Errorf("chunk[%q] body: %w", string(c.ChunkID()), err)
-		return
+	// random write by io.WriteSeeker
+	if _, err := pw.w.Seek(off, io.SeekStart); err != nil {
+		return fmt.Errorf("seek: %w", err)
+	}
+	if _, err := pw.w.Write(b); err != nil {
+		return fmt.Errorf("patch at %d: %w", off, err)
 	}
+	return nil
+}
 
-	return
+// writeState is a state of writing to be shared in the all chunks.
+// It writes to the destination with the context, and reports the progress.
+type writeState struct {
+	ctx             context.Context
+	w               io.Writer
+	progress        ProgressFunc
+	format          *chunkFormat
+	allowIncomplete bool
+	path            []string
+	base            int64
+	n               int64
 }
 
-func writeChunkHeader(w io.Writer, c Chunk) (n int64, err error) {
-	var nn int
+func (s *writeState) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		if err = s.ctx.Err(); err != nil {
+			return
+		}
+
+		b := p
+		if len(b) > maxIOBytes {
+			b = b[:maxIOBytes]
+		}
 
-	nn, err = w.Write(c.ChunkID())
-	n = int64(nn)
-	if err != nil {
-		err = fmt.Errorf("id: %w", err)
-		return
+		var nn int
+		nn, err = s.w.Write(b)
+		n += nn
+		p = p[nn:]
+		s.advance(int64(nn))
+		if err != nil {
+			return
+		}
 	}
+	return
+}
 
-	nn, err = writeChunkBodySize(w, c.BodySize())
-	n += int64(nn)
-	if err != nil {
-		err = fmt.Errorf("size: %w", err)
+// advance reports n bytes are processed in the current chunk.
+func (s *writeState) advance(n int64) {
+	if n == 0 {
 		return
 	}
 
-	if cc, ok := c.(groupedChunk); ok {
-		nn, err = w.Write(cc.groupType())
-		n += int64(nn)
-		if err != nil {
-			err = fmt.Errorf("type: %w", err)
+	s.n += n
+	if s.progress != nil {
+		s.progress(joinChunkPath(s.path), s.n)
+	}
+}
+
+func (s *writeState) push(name string) {
+	s.path = append(s.path, name)
+}
+
+func (s *writeState) pop() {
+	s.path = s.path[:len(s.path)-1]
+}
+
+func writeChunk(s *writeState, c interface{}) (n int64, err error) {
+	if j, ok := c.(*junkChunk); ok {
+		// the preceding incomplete sub-chunks may move the position
+		j.fit(s.base + s.n)
+		if j.skip {
 			return
 		}
 	}
 
+	label := s.format.tree.label(s.format.tree.chunkID(c))
+	n, err = writeChunkHeader(s, s.format, c)
+	if err != nil {
+		err = fmt.Errorf("chunk[%s] header: %w", label, err)
+		return
+	}
+
+	var nn int64
+	nn, err = writeChunkBody(s, c)
+	n += nn
+	if err != nil {
+		err = fmt.Errorf("chunk[%s] body: %w", label, err)
+		return
+	}
+
 	return
 }
 
-func writeChunkBodySize(w io.Writer, b uint32) (int, error) {
-	var buf [sizeBytes]byte
-	binary.LittleEndian.PutUint32(buf[:], b)
-	return w.Write(buf[:])
+// writeChunkHeader writes the chunk header and the type of the grouped chunk at once.
+// So the header bytes are reported under the chunk at once, as same as the chunk readers.
+func writeChunkHeader(w io.Writer, f *chunkFormat, c interface{}) (int64, error) {
+	buf := make([]byte, f.headerBytes(), f.headerBytes()+int64(f.idBytes))
+	copy(buf, f.tree.chunkID(c))
+	f.putSize(buf[f.idBytes:], f.bodySize(c))
+	if typ, ok := f.tree.groupType(c); ok {
+		buf = append(buf, typ...)
+	}
+
+	n, err := w.Write(buf)
+	return int64(n), err
 }
 
 func writeChunkBodySizeAt(w io.WriterAt, b uint32, off int64) (int, error) {
@@ -193,27 +272,56 @@ func writeChunkBodySizeAt(w io.WriterAt, b uint32, off int64) (int, error) {
 	return w.WriteAt(buf[:], off)
 }
 
-func writeChunkBody(w io.Writer, c Chunk, allowIncomplete bool) (n int64, err error) {
-	switch cc := c.(type) {
-	case groupedChunk:
+func writeChunkBody(s *writeState, c interface{}) (n int64, err error) {
+	f := s.format
+	if _, ok := f.tree.groupType(c); ok {
+		if section, ok := unloadedPayload(c); ok {
+			// write as it is
+			n, err = io.Copy(s, section)
+			return
+		}
+
 		var nn int64
-		for i, p := range cc.payload() {
-			nn, err = writeChunk(w, p, allowIncomplete)
+		names := chunkNamer{}
+		for i, p := range f.tree.payload(c) {
+			s.push(names.nextName(f.chunkName(p)))
+			nn, err = writeChunk(s, p)
+			s.pop()
 			n += nn
 			if err != nil {
 				err = fmt.Errorf("payload[%d]: %w", i, err)
 				return
 			}
+
+			nn, err = s.writePadding(f.padding(f.bodySize(p)))
+			n += nn
+			if err != nil {
+				err = fmt.Errorf("payload[%d] padding: %w", i, err)
+				return
+			}
 		}
-	case SubChunk:
-		if !allowIncomplete && cc.Incomplete() {
-			err = ErrUnexpectedIncompleteChunk
-			return
-		}
+		return
+	}
 
-		n, err = io.Copy(w, cc)
-	default:
+	sc, ok := f.tree.subChunk(c)
+	if !ok {
 		panic(fmt.Sprintf("unknown chunk type: %+v", c))
 	}
+	if !s.allowIncomplete && sc.Incomplete() {
+		err = ErrUnexpectedIncompleteChunk
+		return
+	}
+
+	n, err = io.Copy(s, sc)
 	return
 }
+
+// writePadding writes the zero bytes of n bytes after the chunk.
+func (s *writeState) writePadding(n int64) (int64, error) {
+	if n == 0 {
+		return 0, nil
+	}
+
+	nn, err := s.Write(make([]byte, n))
+	return int64(nn), err
+}
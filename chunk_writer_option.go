@@ -0,0 +1,199 @@
+package riffbin
+
+import (
+	"bytes"
+	"io"
+)
+
+var junkID = [idBytes]byte{'J', 'U', 'N', 'K'}
+
+// WriterOption is an option for the RIFF chunk writers.
+type WriterOption func(*writerConfig)
+
+type writerConfig struct {
+	align    uint32
+	alignIDs [][idBytes]byte
+
+	// format is the format of the chunks to write
+	format *chunkFormat
+}
+
+func newWriterConfig(opts []WriterOption) writerConfig {
+	cfg := writerConfig{format: riffChunkFormat}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithPayloadAlignment aligns the payload offsets of the chunks to the multiple of align bytes by inserting a JUNK chunk before each of them.
+// ids are matched with the ID of sub-chunks and the type of LIST chunks (e.g. "data" of WAVE, "movi" of AVI).
+// The payload of a LIST chunk is the payload of the first sub-chunk in it (e.g. "00dc" in "movi").
+// The offsets are absolute in the destination of IncompleteChunkWriter and ParallelChunkWriter, and relative to the head of the written bytes for CompletedChunkWriter.
+// The JUNK chunk is not inserted if the payload is aligned already, and the inserted JUNK chunks are included in the body size of the parent chunks.
+func WithPayloadAlignment(align uint32, ids ...[idBytes]byte) WriterOption {
+	return func(cfg *writerConfig) {
+		cfg.align = align
+		cfg.alignIDs = append(cfg.alignIDs, ids...)
+	}
+}
+
+// WithWritePadding writes the pad byte after each chunk of odd size as the RIFF spec requires.
+// The pad bytes are included in the body size of the parent chunks in the written headers, though BodySize of the parent chunks does not include them.
+func WithWritePadding() WriterOption {
+	return func(cfg *writerConfig) {
+		cfg.format = riffPaddedChunkFormat
+	}
+}
+
+// PaddedChunkBytes returns the bytes of c written with WithWritePadding, including the header and the pad bytes.
+// It is useful to compute the offsets of the chunks in the padded formats. (e.g. the pool table of DLS)
+func PaddedChunkBytes(c Chunk) int64 {
+	return riffPaddedChunkFormat.chunkBytes(c)
+}
+
+// layout returns the chunk to write actually at the offset pos of the destination.
+// It may be a copy of c with the inserted chunks, and c is never modified.
+func (cfg *writerConfig) layout(c *RIFFChunk, pos int64) *RIFFChunk {
+	if cfg.align <= 1 || len(cfg.alignIDs) == 0 {
+		return c
+	}
+
+	cc := cfg.insertJunkChunks(c).(*RIFFChunk)
+	fitJunkChunks(cfg.format, cc, pos)
+	return cc
+}
+
+func (cfg *writerConfig) insertJunkChunks(c Chunk) Chunk {
+	gc, ok := c.(groupedChunk)
+	if !ok {
+		return c
+	}
+	if _, ok := unloadedPayload(c); ok {
+		// the payload is written as it is
+		return c
+	}
+
+	payload := make([]Chunk, 0, len(gc.payload()))
+	for _, p := range gc.payload() {
+		pp := cfg.insertJunkChunks(p)
+		if cfg.shouldAlign(p) {
+			payload = append(payload, &junkChunk{align: cfg.align, next: pp})
+		}
+		payload = append(payload, pp)
+	}
+
+	switch cc := c.(type) {
+	case *RIFFChunk:
+		return &RIFFChunk{FormType: cc.FormType, Payload: payload}
+	case *ListChunk:
+		return &ListChunk{ListType: cc.ListType, Payload: payload}
+	}
+	panic("should not reach here")
+}
+
+func (cfg *writerConfig) shouldAlign(c Chunk) bool {
+	key := c.ChunkID()
+	if cc, ok := c.(*ListChunk); ok {
+		key = cc.ListType[:]
+	}
+
+	for _, id := range cfg.alignIDs {
+		if bytes.Equal(id[:], key) {
+			return true
+		}
+	}
+	return false
+}
+
+// fitJunkChunks fits the size of the JUNK chunks in c placed at pos, and returns the position of the next chunk.
+// The sizes of the incomplete sub-chunks are unknown yet, so they are fit again on writing.
+func fitJunkChunks(f *chunkFormat, c Chunk, pos int64) int64 {
+	if j, ok := c.(*junkChunk); ok {
+		j.fit(pos)
+		return pos + f.chunkBytes(j)
+	}
+
+	if _, ok := unloadedPayload(c); ok {
+		return pos + f.chunkBytes(c)
+	}
+	if gc, ok := c.(groupedChunk); ok {
+		next := pos + HeaderBytes + typeBytes
+		for _, p := range gc.payload() {
+			next = fitJunkChunks(f, p, next)
+		}
+		return next + f.padding(uint64(next-pos-HeaderBytes))
+	}
+	return pos + f.chunkBytes(c)
+}
+
+// payloadLead returns the bytes from the head of c to the payload to be aligned.
+// It is the payload of the first sub-chunk for the grouped chunk, unless the sub-chunk is aligned by itself.
+func payloadLead(c Chunk) int64 {
+	gc, ok := c.(groupedChunk)
+	if !ok {
+		return HeaderBytes
+	}
+
+	lead := int64(HeaderBytes + typeBytes)
+	if _, ok := unloadedPayload(c); ok {
+		return lead
+	}
+	if payload := gc.payload(); len(payload) != 0 {
+		if _, ok := payload[0].(*junkChunk); !ok {
+			lead += payloadLead(payload[0])
+		}
+	}
+	return lead
+}
+
+// junkChunk is a JUNK chunk to align the payload offset of the next chunk.
+// It is skipped if the payload is aligned without it.
+type junkChunk struct {
+	align uint32
+	next  Chunk
+	skip  bool
+	size  uint32
+	read  uint32
+}
+
+var _ SubChunk = (*junkChunk)(nil)
+
+// fit fits the size of the chunk placed at pos to align the payload offset of the next chunk.
+func (c *junkChunk) fit(pos int64) {
+	align := int64(c.align)
+	off := pos + payloadLead(c.next)
+	c.skip = off%align == 0
+	c.size = 0
+	if !c.skip {
+		c.size = uint32((align - (off+HeaderBytes)%align) % align)
+	}
+}
+
+func (c *junkChunk) ChunkID() []byte {
+	return junkID[:]
+}
+
+func (c *junkChunk) BodySize() uint32 {
+	return c.size
+}
+
+func (c *junkChunk) Incomplete() bool {
+	return false
+}
+
+func (c *junkChunk) Read(p []byte) (int, error) {
+	if c.read >= c.size {
+		return 0, io.EOF
+	}
+
+	n := len(p)
+	if rest := c.size - c.read; uint32(n) > rest {
+		n = int(rest)
+	}
+	for i := range p[:n] {
+		p[i] = 0
+	}
+	c.read += uint32(n)
+	return n, nil
+}
@@ -0,0 +1,277 @@
+package riffbin_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/karupanerura/riffbin"
+)
+
+func TestWithPayloadAlignment(t *testing.T) {
+	t.Parallel()
+
+	newChunk := func(incomplete bool) *riffbin.RIFFChunk {
+		var data riffbin.Chunk = &riffbin.OnMemorySubChunk{ID: [4]byte{'d', 'a', 't', 'a'}, Payload: []byte("payload")}
+		var note riffbin.Chunk = &riffbin.OnMemorySubChunk{ID: [4]byte{'n', 'o', 't', 'e'}, Payload: []byte("foo")}
+		if incomplete {
+			data = riffbin.NewIncompleteSubChunk([4]byte{'d', 'a', 't', 'a'}, strings.NewReader("payload"))
+			note = riffbin.NewIncompleteSubChunk([4]byte{'n', 'o', 't', 'e'}, strings.NewReader("foo"))
+		}
+		return &riffbin.RIFFChunk{
+			FormType: [4]byte{'T', 'E', 'S', 'T'},
+			Payload: []riffbin.Chunk{
+				&riffbin.OnMemorySubChunk{ID: [4]byte{'f', 'm', 't', ' '}, Payload: []byte{0x01, 0x02, 0x03}},
+				note,
+				data,
+				&riffbin.ListChunk{
+					ListType: [4]byte{'m', 'o', 'v', 'i'},
+					Payload: []riffbin.Chunk{
+						&riffbin.OnMemorySubChunk{ID: [4]byte{'0', '0', 'd', 'c'}, Payload: []byte{0x01}},
+					},
+				},
+			},
+		}
+	}
+	opt := riffbin.WithPayloadAlignment(16, [4]byte{'d', 'a', 't', 'a'}, [4]byte{'m', 'o', 'v', 'i'})
+
+	var expected bytes.Buffer
+	n, err := riffbin.NewCompletedChunkWriter(&expected, opt).Write(newChunk(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(expected.Len()) {
+		t.Errorf("n should be %d but got %d", expected.Len(), n)
+	}
+
+	t.Run("Completed", func(t *testing.T) {
+		t.Parallel()
+		b := expected.Bytes()
+		for _, id := range []string{"data", "00dc"} {
+			if off := bytes.Index(b, []byte(id)) + riffbin.HeaderBytes; off%16 != 0 {
+				t.Errorf("%s payload is not aligned: %d", id, off)
+				t.Log(hex.Dump(b))
+			}
+		}
+
+		got, err := riffbin.ReadFull(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Payload) != 6 {
+			t.Fatalf("unexpected payload length: %d", len(got.Payload))
+		}
+		for _, i := range []int{2, 4} {
+			if id := string(got.Payload[i].ChunkID()); id != "JUNK" {
+				t.Errorf("Payload[%d] should be JUNK but got %q", i, id)
+			}
+		}
+		if df := cmp.Diff(got.Payload[3], &riffbin.OnMemorySubChunk{ID: [4]byte{'d', 'a', 't', 'a'}, Payload: []byte("payload")}, cmpopts.IgnoreUnexported(riffbin.OnMemorySubChunk{})); df != "" {
+			t.Errorf("diff = %s", df)
+		}
+	})
+
+	t.Run("Incomplete", func(t *testing.T) {
+		t.Parallel()
+		f, err := os.CreateTemp("", "riffbin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+
+		w, err := riffbin.NewIncompleteChunkWriter(f, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(newChunk(true)); err != nil {
+			t.Fatal(err)
+		}
+
+		if got, err := os.ReadFile(f.Name()); err != nil {
+			t.Fatal(err)
+		} else if !bytes.Equal(got, expected.Bytes()) {
+			t.Error("unexpected bytes are written")
+			t.Log(hex.Dump(got))
+		}
+	})
+
+	t.Run("IncompleteAtOffset", func(t *testing.T) {
+		t.Parallel()
+		f, err := os.CreateTemp("", "riffbin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+
+		// the offsets are absolute in the file
+		if _, err := f.Write([]byte{0x00, 0x00, 0x00, 0x00, 0x00}); err != nil {
+			t.Fatal(err)
+		}
+		w, err := riffbin.NewIncompleteChunkWriter(f, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(newChunk(true)); err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := os.ReadFile(f.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, id := range []string{"data", "00dc"} {
+			if off := bytes.Index(b, []byte(id)) + riffbin.HeaderBytes; off%16 != 0 {
+				t.Errorf("%s payload is not aligned: %d", id, off)
+				t.Log(hex.Dump(b))
+			}
+		}
+		if _, err := riffbin.ReadFull(bytes.NewReader(b[5:])); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("AlreadyAligned", func(t *testing.T) {
+		t.Parallel()
+		newChunk := func() *riffbin.RIFFChunk {
+			return &riffbin.RIFFChunk{
+				FormType: [4]byte{'T', 'E', 'S', 'T'},
+				Payload: []riffbin.Chunk{
+					&riffbin.OnMemorySubChunk{ID: [4]byte{'d', 'a', 't', 'a'}, Payload: []byte("payload")},
+				},
+			}
+		}
+
+		var aligned, plain bytes.Buffer
+		if _, err := riffbin.NewCompletedChunkWriter(&aligned, riffbin.WithPayloadAlignment(4, [4]byte{'d', 'a', 't', 'a'})).Write(newChunk()); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := riffbin.NewCompletedChunkWriter(&plain).Write(newChunk()); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(aligned.Bytes(), plain.Bytes()) {
+			t.Error("JUNK chunk should not be inserted")
+			t.Log(hex.Dump(aligned.Bytes()))
+		}
+	})
+
+	t.Run("Parallel", func(t *testing.T) {
+		t.Parallel()
+
+		var w bufferWriterAt
+		if _, err := riffbin.NewParallelChunkWriter(&w, 2, opt).Write(newChunk(false)); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(w.Bytes(), expected.Bytes()) {
+			t.Error("unexpected bytes are written")
+			t.Log(hex.Dump(w.Bytes()))
+		}
+	})
+}
+
+func TestWithPadding(t *testing.T) {
+	t.Parallel()
+
+	newChunk := func() *riffbin.RIFFChunk {
+		return &riffbin.RIFFChunk{
+			FormType: [4]byte{'T', 'E', 'S', 'T'},
+			Payload: []riffbin.Chunk{
+				&riffbin.ListChunk{
+					ListType: [4]byte{'I', 'N', 'F', 'O'},
+					Payload: []riffbin.Chunk{
+						&riffbin.OnMemorySubChunk{ID: [4]byte{'I', 'N', 'A', 'M'}, Payload: []byte("foo")},
+						&riffbin.OnMemorySubChunk{ID: [4]byte{'I', 'C', 'M', 'T'}, Payload: []byte("bar")},
+					},
+				},
+				&riffbin.OnMemorySubChunk{ID: [4]byte{'d', 'a', 't', 'a'}, Payload: []byte{0x01}},
+			},
+		}
+	}
+
+	var buf bytes.Buffer
+	n, err := riffbin.NewCompletedChunkWriter(&buf, riffbin.WithWritePadding()).Write(newChunk())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []byte("RIFF\x32\x00\x00\x00TEST" +
+		"LIST\x1c\x00\x00\x00INFO" + "INAM\x03\x00\x00\x00foo\x00" + "ICMT\x03\x00\x00\x00bar\x00" +
+		"data\x01\x00\x00\x00\x01\x00")
+	if !bytes.Equal(buf.Bytes(), expected) || n != int64(len(expected)) {
+		t.Fatalf("unexpected binary: %s", hex.Dump(buf.Bytes()))
+	}
+
+	c := newChunk()
+	if got := riffbin.PaddedChunkBytes(c); got != int64(len(expected)) {
+		t.Errorf("unexpected PaddedChunkBytes: %d", got)
+	}
+	if got := riffbin.PaddedChunkBytes(c.Payload[0]); got != 8+0x1c {
+		t.Errorf("unexpected PaddedChunkBytes of LIST-INFO: %d", got)
+	}
+
+	t.Run("Parallel", func(t *testing.T) {
+		t.Parallel()
+
+		f := writeTempFile(t, bytes.Repeat([]byte{0xff}, len(expected)))
+		if _, err := riffbin.NewParallelChunkWriter(f, 2, riffbin.WithWritePadding()).Write(newChunk()); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := os.ReadFile(f.Name()); !bytes.Equal(got, expected) {
+			t.Errorf("unexpected binary: %s", hex.Dump(got))
+		}
+	})
+
+	t.Run("Incomplete", func(t *testing.T) {
+		t.Parallel()
+
+		c := newChunk()
+		c.Payload[1] = riffbin.NewIncompleteSubChunk([4]byte{'d', 'a', 't', 'a'}, bytes.NewReader([]byte{0x01}))
+
+		f := writeTempFile(t, nil)
+		w, err := riffbin.NewIncompleteChunkWriter(f, riffbin.WithWritePadding())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(c); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := os.ReadFile(f.Name()); !bytes.Equal(got, expected) {
+			t.Errorf("unexpected binary: %s", hex.Dump(got))
+		}
+	})
+
+	t.Run("Read", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := riffbin.ReadFull(bytes.NewReader(expected)); err != riffbin.ErrInvalidFormat {
+			t.Errorf("unexpected error without padding: %v", err)
+		}
+
+		opts := []cmp.Option{cmpopts.IgnoreUnexported(riffbin.OnMemorySubChunk{}, riffbin.ListChunk{})}
+		for name, input := range map[string][]byte{
+			"Padded": expected,
+			// the pad byte of the last chunk may be omitted
+			"Omitted": append([]byte("RIFF\x31\x00\x00\x00"), expected[8:len(expected)-1]...),
+		} {
+			got, err := riffbin.ReadFull(bytes.NewReader(input), riffbin.WithReadPadding())
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if df := cmp.Diff(newChunk(), got, opts...); df != "" {
+				t.Errorf("%s: diff = %s", name, df)
+			}
+		}
+
+		lazy, err := riffbin.ReadSectionsLazy(bytes.NewReader(expected), riffbin.WithReadPadding())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if chunks, err := lazy.Payload[0].(*riffbin.ListChunk).Chunks(); err != nil || len(chunks) != 2 {
+			t.Errorf("unexpected LIST payload: %v %v", chunks, err)
+		}
+	})
+}
@@ -0,0 +1,161 @@
+package riffbin
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+)
+
+// ParallelChunkWriter is a RIFF chunk writer for the completed chunk to copy the sub-chunk payloads concurrently.
+// It computes the offsets of the all chunks before writing, so the all sub-chunks must be completed.
+type ParallelChunkWriter struct {
+	w           io.WriterAt
+	concurrency int
+	cfg         writerConfig
+}
+
+var _ ChunkWriter = (*ParallelChunkWriter)(nil)
+
+// NewParallelChunkWriter creates a new ParallelChunkWriter.
+// concurrency is the maximum number of the sub-chunk payloads to copy at the same time, and runtime.GOMAXPROCS(0) is used if it is less than 1.
+func NewParallelChunkWriter(w io.WriterAt, concurrency int, opts ...WriterOption) *ParallelChunkWriter {
+	if concurrency < 1 {
+		concurrency = runtime.GOMAXPROCS(0)
+	}
+	return &ParallelChunkWriter{w: w, concurrency: concurrency, cfg: newWriterConfig(opts)}
+}
+
+// Write writes the RIFF message to the head of the underlying data stream.
+// The written bytes are same as CompletedChunkWriter, but the sub-chunk payloads are copied concurrently by random write.
+// It returns the number of bytes written and any error encountered that caused the write to stop early. (same as Write of io.Writer)
+func (w *ParallelChunkWriter) Write(c *RIFFChunk) (int64, error) {
+	var jobs []parallelWriteJob
+	n, err := writeChunkHeadersAt(w.w, w.cfg.format, w.cfg.layout(c, 0), 0, &jobs)
+	if err != nil {
+		return n, err
+	}
+
+	var (
+		mu       sync.Mutex
+		firstErr error
+	)
+	ch := make(chan parallelWriteJob)
+	abort := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < w.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range ch {
+				nn, err := job.copy(w.w)
+
+				mu.Lock()
+				n += nn
+				if err != nil && firstErr == nil {
+					firstErr = err
+					close(abort)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+dispatch:
+	for _, job := range jobs {
+		select {
+		case ch <- job:
+		case <-abort:
+			break dispatch
+		}
+	}
+	close(ch)
+	wg.Wait()
+
+	return n, firstErr
+}
+
+type parallelWriteJob struct {
+	offset int64
+	chunk  SubChunk
+}
+
+func (j *parallelWriteJob) copy(w io.WriterAt) (n int64, err error) {
+	n, err = io.CopyN(&offsetWriter{w: w, off: j.offset}, j.chunk, int64(j.chunk.BodySize()))
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		err = fmt.Errorf("chunk[%q] body: %w", string(j.chunk.ChunkID()), err)
+	}
+	return
+}
+
+// writeChunkHeadersAt writes the all chunk headers and the padding of c placed at off, and collects the sub-chunk payloads to jobs.
+func writeChunkHeadersAt(w io.WriterAt, f *chunkFormat, c Chunk, off int64, jobs *[]parallelWriteJob) (n int64, err error) {
+	if f.chunkBytes(c) == 0 {
+		// skipped JUNK chunk
+		return
+	}
+
+	ow := &offsetWriter{w: w, off: off}
+	n, err = writeChunkHeader(ow, f, c)
+	if err != nil {
+		err = fmt.Errorf("chunk[%q] header: %w", string(c.ChunkID()), err)
+		return
+	}
+	off += n
+
+	switch cc := c.(type) {
+	case groupedChunk:
+		if section, ok := unloadedPayload(c); ok {
+			// copy as it is
+			*jobs = append(*jobs, parallelWriteJob{offset: off, chunk: &InStreamSubChunk{ID: listID, SectionReader: section}})
+			break
+		}
+		for i, p := range cc.payload() {
+			var nn int64
+			nn, err = writeChunkHeadersAt(w, f, p, off, jobs)
+			n += nn
+			if err != nil {
+				err = fmt.Errorf("chunk[%q] body: payload[%d]: %w", string(c.ChunkID()), i, err)
+				return
+			}
+			off += f.chunkBytes(p)
+
+			// the padding is not written by the jobs
+			if pad := f.padding(f.bodySize(p)); pad > 0 {
+				var pn int
+				pn, err = w.WriteAt(make([]byte, pad), off-pad)
+				n += int64(pn)
+				if err != nil {
+					err = fmt.Errorf("chunk[%q] body: payload[%d] padding: %w", string(c.ChunkID()), i, err)
+					return
+				}
+			}
+		}
+	case SubChunk:
+		if cc.Incomplete() {
+			err = fmt.Errorf("chunk[%q] body: %w", string(c.ChunkID()), ErrUnexpectedIncompleteChunk)
+			return
+		}
+
+		*jobs = append(*jobs, parallelWriteJob{offset: off, chunk: cc})
+	default:
+		panic(fmt.Sprintf("unknown chunk type: %+v", c))
+	}
+	return
+}
+
+// offsetWriter is an io.Writer to write sequentially to io.WriterAt from the offset.
+type offsetWriter struct {
+	w   io.WriterAt
+	off int64
+}
+
+func (w *offsetWriter) Write(p []byte) (n int, err error) {
+	n, err = w.w.WriteAt(p, w.off)
+	w.off += int64(n)
+	return
+}
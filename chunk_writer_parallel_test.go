@@ -0,0 +1,166 @@
+package riffbin_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/karupanerura/riffbin"
+)
+
+// bufferWriterAt is an in-memory io.WriterAt for concurrent use
+type bufferWriterAt struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (w *bufferWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if end := off + int64(len(p)); end > int64(len(w.buf)) {
+		w.buf = append(w.buf, make([]byte, end-int64(len(w.buf)))...)
+	}
+	return copy(w.buf[off:], p), nil
+}
+
+func (w *bufferWriterAt) Bytes() []byte {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf
+}
+
+func newComplexRIFFChunk() *riffbin.RIFFChunk {
+	return &riffbin.RIFFChunk{
+		FormType: [4]byte{'T', 'E', 'S', 'T'},
+		Payload: []riffbin.Chunk{
+			&riffbin.ListChunk{
+				ListType: [4]byte{'L', 'S', 'T', '1'},
+				Payload: []riffbin.Chunk{
+					&riffbin.OnMemorySubChunk{
+						ID:      [4]byte{'E', 'N', 'T', '1'},
+						Payload: []byte{0x01},
+					},
+					&riffbin.ListChunk{
+						ListType: [4]byte{'L', 'S', 'T', '2'},
+						Payload: []riffbin.Chunk{
+							&riffbin.OnMemorySubChunk{
+								ID:      [4]byte{'E', 'N', 'T', '2'},
+								Payload: []byte{0x01, 0x02},
+							},
+							&riffbin.OnMemorySubChunk{
+								ID:      [4]byte{'E', 'N', 'T', '3'},
+								Payload: []byte{0x01, 0x02, 0x03},
+							},
+						},
+					},
+					&riffbin.OnMemorySubChunk{
+						ID:      [4]byte{'E', 'N', 'T', '4'},
+						Payload: []byte{0x01, 0x02, 0x03, 0x04},
+					},
+				},
+			},
+			&riffbin.OnMemorySubChunk{
+				ID:      [4]byte{'E', 'N', 'T', '5'},
+				Payload: []byte{0x01, 0x02, 0x03, 0x04, 0x05},
+			},
+			&riffbin.ListChunk{
+				ListType: [4]byte{'L', 'S', 'T', '3'},
+				Payload:  []riffbin.Chunk{},
+			},
+			&riffbin.ListChunk{
+				ListType: [4]byte{'L', 'S', 'T', '4'},
+				Payload: []riffbin.Chunk{
+					&riffbin.OnMemorySubChunk{
+						ID:      [4]byte{'E', 'N', 'T', '6'},
+						Payload: []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestParallelChunkWriter(t *testing.T) {
+	t.Parallel()
+
+	var expected bytes.Buffer
+	if _, err := riffbin.NewCompletedChunkWriter(&expected).Write(newComplexRIFFChunk()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, concurrency := range []int{0, 1, 4} {
+		concurrency := concurrency
+		t.Run("OnMemory", func(t *testing.T) {
+			t.Parallel()
+
+			var w bufferWriterAt
+			n, err := riffbin.NewParallelChunkWriter(&w, concurrency).Write(newComplexRIFFChunk())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != int64(expected.Len()) {
+				t.Errorf("n should be %d but got %d", expected.Len(), n)
+			}
+			if !bytes.Equal(w.Bytes(), expected.Bytes()) {
+				t.Errorf("unexpected bytes are written (concurrency=%d)", concurrency)
+				t.Log(hex.Dump(w.Bytes()))
+			}
+		})
+
+		t.Run("InStream", func(t *testing.T) {
+			t.Parallel()
+
+			riffChunk, err := riffbin.ReadSections(bytes.NewReader(expected.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var w bufferWriterAt
+			n, err := riffbin.NewParallelChunkWriter(&w, concurrency).Write(riffChunk)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != int64(expected.Len()) {
+				t.Errorf("n should be %d but got %d", expected.Len(), n)
+			}
+			if !bytes.Equal(w.Bytes(), expected.Bytes()) {
+				t.Errorf("unexpected bytes are written (concurrency=%d)", concurrency)
+				t.Log(hex.Dump(w.Bytes()))
+			}
+		})
+	}
+
+	t.Run("UnexpectedIncompleteError", func(t *testing.T) {
+		t.Parallel()
+		_, err := riffbin.NewParallelChunkWriter(&bufferWriterAt{}, 1).Write(&riffbin.RIFFChunk{
+			FormType: [4]byte{'T', 'E', 'S', 'T'},
+			Payload: []riffbin.Chunk{
+				riffbin.NewIncompleteSubChunk([4]byte{'E', 'N', 'T', '1'}, strings.NewReader("sample")),
+			},
+		})
+		if !errors.Is(err, riffbin.ErrUnexpectedIncompleteChunk) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("TooShortPayload", func(t *testing.T) {
+		t.Parallel()
+		_, err := riffbin.NewParallelChunkWriter(&bufferWriterAt{}, 1).Write(&riffbin.RIFFChunk{
+			FormType: [4]byte{'T', 'E', 'S', 'T'},
+			Payload: []riffbin.Chunk{
+				&riffbin.InStreamSubChunk{
+					ID:            [4]byte{'E', 'N', 'T', '1'},
+					SectionReader: io.NewSectionReader(strings.NewReader("foo"), 0, 6),
+				},
+			},
+		})
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
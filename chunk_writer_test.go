@@ -2,6 +2,7 @@ package riffbin_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/hex"
 	"errors"
@@ -108,7 +109,7 @@ func TestCompletedChunkWriter(t *testing.T) {
 			t.Parallel()
 			if got, err := riffbin.ReadFull(&buf); err != nil {
 				t.Fatal(err)
-			} else if df := cmp.Diff(got, riffChunk, cmpopts.IgnoreUnexported(riffbin.OnMemorySubChunk{})); df != "" {
+			} else if df := cmp.Diff(got, riffChunk, cmpopts.IgnoreUnexported(riffbin.ListChunk{}, riffbin.OnMemorySubChunk{})); df != "" {
 				t.Error(df)
 			}
 		})
@@ -199,7 +200,7 @@ func TestCompletedChunkWriter(t *testing.T) {
 			t.Parallel()
 			if got, err := riffbin.ReadFull(&buf); err != nil {
 				t.Fatal(err)
-			} else if df := cmp.Diff(got, riffChunk, cmpopts.IgnoreUnexported(riffbin.OnMemorySubChunk{})); df != "" {
+			} else if df := cmp.Diff(got, riffChunk, cmpopts.IgnoreUnexported(riffbin.ListChunk{}, riffbin.OnMemorySubChunk{})); df != "" {
 				t.Error(df)
 			}
 		})
@@ -246,7 +247,7 @@ func TestCompletedChunkWriter(t *testing.T) {
 			t.Parallel()
 			if got, err := riffbin.ReadFull(&buf); err != nil {
 				t.Fatal(err)
-			} else if df := cmp.Diff(got, riffChunk, cmpopts.IgnoreUnexported(riffbin.OnMemorySubChunk{})); df != "" {
+			} else if df := cmp.Diff(got, riffChunk, cmpopts.IgnoreUnexported(riffbin.ListChunk{}, riffbin.OnMemorySubChunk{})); df != "" {
 				t.Error(df)
 			}
 		})
@@ -612,6 +613,78 @@ func TestInompletedChunkWriter(t *testing.T) {
 	})
 }
 
+func TestWriteContext(t *testing.T) {
+	t.Parallel()
+	t.Run("Progress", func(t *testing.T) {
+		t.Parallel()
+
+		var got []progressRecord
+		_, err := riffbin.NewCompletedChunkWriter(io.Discard).WriteContext(context.Background(), &riffbin.RIFFChunk{
+			FormType: [4]byte{'T', 'E', 'S', 'T'},
+			Payload: []riffbin.Chunk{
+				&riffbin.ListChunk{
+					ListType: [4]byte{'L', 'S', 'T', '1'},
+					Payload: []riffbin.Chunk{
+						&riffbin.OnMemorySubChunk{ID: [4]byte{'E', 'N', 'T', '1'}, Payload: []byte{0x01}},
+					},
+				},
+				&riffbin.OnMemorySubChunk{ID: [4]byte{'E', 'N', 'T', '1'}, Payload: []byte{0x01, 0x02}},
+			},
+		}, func(path string, n int64) {
+			got = append(got, progressRecord{path, n})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []progressRecord{
+			{".", 12},
+			{"LIST-LST1", 24},
+			{"LIST-LST1/ENT1", 32},
+			{"LIST-LST1/ENT1", 33},
+			{"ENT1", 41},
+			{"ENT1", 43},
+		}
+		if df := cmp.Diff(expected, got); df != "" {
+			t.Errorf("diff = %s", df)
+		}
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		t.Parallel()
+		f, err := os.CreateTemp("", "riffbin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+
+		w, err := riffbin.NewIncompleteChunkWriter(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// infinite payload
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		_, err = w.WriteContext(ctx, &riffbin.RIFFChunk{
+			FormType: [4]byte{'T', 'E', 'S', 'T'},
+			Payload: []riffbin.Chunk{
+				riffbin.NewIncompleteSubChunk([4]byte{'E', 'N', 'T', '1'}, callbackReader(func(p []byte) (int, error) {
+					return len(p), nil
+				})),
+			},
+		}, func(path string, n int64) {
+			if n > 1<<20 {
+				cancel()
+			}
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
 func ExampleCompletedChunkWriter_Write() {
 	encoder := base64.NewEncoder(base64.StdEncoding, os.Stdout)
 	_, err := riffbin.NewCompletedChunkWriter(encoder).Write(&riffbin.RIFFChunk{
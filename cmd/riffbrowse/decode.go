@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/karupanerura/riffbin"
+)
+
+// decoders decode the head of the payload of the known sub-chunks by ID.
+var decoders = map[string]func(b []byte) []string{
+	"fmt ": decodeFormat,
+	"fact": decodeFact,
+	"avih": decodeAVIMainHeader,
+	"strh": decodeAVIStreamHeader,
+}
+
+// maxDecodeBytes is the max bytes of the payload to decode.
+const maxDecodeBytes = 256
+
+var formatTags = map[uint16]string{
+	0x0001: "PCM",
+	0x0003: "IEEE float",
+	0x0006: "A-law",
+	0x0007: "mu-law",
+	0x0055: "MPEG Layer 3",
+	0xFFFE: "extensible",
+}
+
+func decodeFormat(b []byte) []string {
+	if len(b) < 16 {
+		return nil
+	}
+	tag := binary.LittleEndian.Uint16(b[0:])
+	name, ok := formatTags[tag]
+	if !ok {
+		name = "unknown"
+	}
+	return []string{
+		fmt.Sprintf("Format:          0x%04X (%s)", tag, name),
+		fmt.Sprintf("Channels:        %d", binary.LittleEndian.Uint16(b[2:])),
+		fmt.Sprintf("Sample rate:     %d", binary.LittleEndian.Uint32(b[4:])),
+		fmt.Sprintf("Byte rate:       %d", binary.LittleEndian.Uint32(b[8:])),
+		fmt.Sprintf("Block align:     %d", binary.LittleEndian.Uint16(b[12:])),
+		fmt.Sprintf("Bits per sample: %d", binary.LittleEndian.Uint16(b[14:])),
+	}
+}
+
+func decodeFact(b []byte) []string {
+	if len(b) < 4 {
+		return nil
+	}
+	return []string{fmt.Sprintf("Sample length: %d", binary.LittleEndian.Uint32(b))}
+}
+
+func decodeAVIMainHeader(b []byte) []string {
+	if len(b) < 56 {
+		return nil
+	}
+	return []string{
+		fmt.Sprintf("Micro sec per frame:   %d", binary.LittleEndian.Uint32(b[0:])),
+		fmt.Sprintf("Max bytes per sec:     %d", binary.LittleEndian.Uint32(b[4:])),
+		fmt.Sprintf("Padding granularity:   %d", binary.LittleEndian.Uint32(b[8:])),
+		fmt.Sprintf("Flags:                 0x%08X", binary.LittleEndian.Uint32(b[12:])),
+		fmt.Sprintf("Total frames:          %d", binary.LittleEndian.Uint32(b[16:])),
+		fmt.Sprintf("Initial frames:        %d", binary.LittleEndian.Uint32(b[20:])),
+		fmt.Sprintf("Streams:               %d", binary.LittleEndian.Uint32(b[24:])),
+		fmt.Sprintf("Suggested buffer size: %d", binary.LittleEndian.Uint32(b[28:])),
+		fmt.Sprintf("Width:                 %d", binary.LittleEndian.Uint32(b[32:])),
+		fmt.Sprintf("Height:                %d", binary.LittleEndian.Uint32(b[36:])),
+	}
+}
+
+func decodeAVIStreamHeader(b []byte) []string {
+	if len(b) < 48 {
+		return nil
+	}
+	return []string{
+		fmt.Sprintf("Type:        %q", b[0:4]),
+		fmt.Sprintf("Handler:     %q", b[4:8]),
+		fmt.Sprintf("Flags:       0x%08X", binary.LittleEndian.Uint32(b[8:])),
+		fmt.Sprintf("Scale:       %d", binary.LittleEndian.Uint32(b[20:])),
+		fmt.Sprintf("Rate:        %d", binary.LittleEndian.Uint32(b[24:])),
+		fmt.Sprintf("Start:       %d", binary.LittleEndian.Uint32(b[28:])),
+		fmt.Sprintf("Length:      %d", binary.LittleEndian.Uint32(b[32:])),
+		fmt.Sprintf("Sample size: %d", binary.LittleEndian.Uint32(b[44:])),
+	}
+}
+
+// decodeInfo decodes the NUL-terminated string of the sub-chunk in LIST-INFO.
+func decodeInfo(b []byte) []string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return []string{fmt.Sprintf("%q", b)}
+}
+
+// decode decodes the sub-chunk of n if it is known.
+func decode(n *node) []string {
+	r, size, ok := payloadReaderAt(n.chunk)
+	if !ok {
+		return nil
+	}
+
+	f := decoders[string(n.chunk.ChunkID())]
+	if l, ok := n.parent.chunkType(); ok && l == "INFO" {
+		f = decodeInfo
+	}
+	if f == nil {
+		return nil
+	}
+
+	if size > maxDecodeBytes {
+		size = maxDecodeBytes
+	}
+	b := make([]byte, size)
+	if _, err := r.ReadAt(b, 0); err != nil && err != io.EOF {
+		return []string{fmt.Sprintf("error: %s", err.Error())}
+	}
+	return f(b)
+}
+
+// payloadReaderAt returns the payload of the sub-chunk to read lazily without consuming it.
+func payloadReaderAt(c riffbin.Chunk) (io.ReaderAt, int64, bool) {
+	switch cc := c.(type) {
+	case *riffbin.InStreamSubChunk:
+		return cc.SectionReader, cc.Size(), true
+	case *riffbin.OnMemorySubChunk:
+		return bytes.NewReader(cc.Payload), int64(len(cc.Payload)), true
+	}
+	return nil, 0, false
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/binary"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/karupanerura/riffbin"
+)
+
+func TestDecoders(t *testing.T) {
+	t.Parallel()
+
+	avih := make([]byte, 56)
+	for i := 0; i < 10; i++ {
+		binary.LittleEndian.PutUint32(avih[i*4:], uint32(i+1))
+	}
+	strh := make([]byte, 48)
+	copy(strh, "vidsDIB ")
+	for i := 2; i < 12; i++ {
+		binary.LittleEndian.PutUint32(strh[i*4:], uint32(i))
+	}
+
+	for _, tc := range []struct {
+		name     string
+		f        func(b []byte) []string
+		b        []byte
+		expected []string
+	}{
+		{
+			name: "Format",
+			f:    decodeFormat,
+			b:    []byte{0x03, 0x00, 0x01, 0x00, 0x80, 0xBB, 0x00, 0x00, 0x00, 0xEE, 0x02, 0x00, 0x04, 0x00, 0x20, 0x00},
+			expected: []string{
+				"Format:          0x0003 (IEEE float)",
+				"Channels:        1",
+				"Sample rate:     48000",
+				"Byte rate:       192000",
+				"Block align:     4",
+				"Bits per sample: 32",
+			},
+		},
+		{
+			name:     "UnknownFormat",
+			f:        decodeFormat,
+			b:        []byte{0x34, 0x12, 0x01, 0x00, 0x80, 0xBB, 0x00, 0x00, 0x00, 0xEE, 0x02, 0x00, 0x04, 0x00, 0x20, 0x00},
+			expected: []string{"Format:          0x1234 (unknown)", "Channels:        1", "Sample rate:     48000", "Byte rate:       192000", "Block align:     4", "Bits per sample: 32"},
+		},
+		{
+			name: "ShortFormat",
+			f:    decodeFormat,
+			b:    make([]byte, 14),
+		},
+		{
+			name:     "Fact",
+			f:        decodeFact,
+			b:        []byte{0x10, 0x27, 0x00, 0x00},
+			expected: []string{"Sample length: 10000"},
+		},
+		{
+			name: "ShortFact",
+			f:    decodeFact,
+			b:    []byte{0x10, 0x27},
+		},
+		{
+			name: "AVIMainHeader",
+			f:    decodeAVIMainHeader,
+			b:    avih,
+			expected: []string{
+				"Micro sec per frame:   1",
+				"Max bytes per sec:     2",
+				"Padding granularity:   3",
+				"Flags:                 0x00000004",
+				"Total frames:          5",
+				"Initial frames:        6",
+				"Streams:               7",
+				"Suggested buffer size: 8",
+				"Width:                 9",
+				"Height:                10",
+			},
+		},
+		{
+			name: "ShortAVIMainHeader",
+			f:    decodeAVIMainHeader,
+			b:    avih[:55],
+		},
+		{
+			name: "AVIStreamHeader",
+			f:    decodeAVIStreamHeader,
+			b:    strh,
+			expected: []string{
+				`Type:        "vids"`,
+				`Handler:     "DIB "`,
+				"Flags:       0x00000002",
+				"Scale:       5",
+				"Rate:        6",
+				"Start:       7",
+				"Length:      8",
+				"Sample size: 11",
+			},
+		},
+		{
+			name: "ShortAVIStreamHeader",
+			f:    decodeAVIStreamHeader,
+			b:    strh[:47],
+		},
+		{
+			name:     "Info",
+			f:        decodeInfo,
+			b:        []byte("riffbin\x00garbage"),
+			expected: []string{`"riffbin"`},
+		},
+		{
+			name:     "InfoWithoutNUL",
+			f:        decodeInfo,
+			b:        []byte("riff\"bin"),
+			expected: []string{`"riff\"bin"`},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if df := cmp.Diff(tc.f(tc.b), tc.expected); df != "" {
+				t.Errorf("diff = %s", df)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	t.Parallel()
+
+	f, err := os.Open("testdata/test.wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	riffChunk, err := riffbin.ReadSectionsLazy(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr, err := newTree(riffChunk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// LIST-INFO is loaded on expanding it
+	if err := tr.toggle(tr.root.children[2]); err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := map[string][]string{}
+	for _, n := range tr.rows {
+		decoded[n.path] = decode(n)
+	}
+	expected := map[string][]string{
+		".":              nil,
+		"fmt ":           {"Format:          0x0001 (PCM)", "Channels:        2", "Sample rate:     44100", "Byte rate:       176400", "Block align:     4", "Bits per sample: 16"},
+		"fact":           {"Sample length: 2"},
+		"LIST-INFO":      nil,
+		"LIST-INFO/INAM": {`"riffbin"`},
+		"LIST-INFO/ISFT": {`"test"`},
+		"data":           nil,
+	}
+	if df := cmp.Diff(decoded, expected); df != "" {
+		t.Errorf("diff = %s", df)
+	}
+}
@@ -0,0 +1,434 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/karupanerura/riffbin"
+)
+
+const help = "j/k:move  h/l:collapse/expand  enter:toggle  [/]:scroll hex  /:search  n/N:next/prev  q:quit"
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s RIFF-file\n\n%s\n", os.Args[0], help)
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	name := flag.Arg(0)
+
+	f, err := os.Open(name)
+	if err != nil {
+		log.Fatalf("%s: %s", err.Error(), name)
+	}
+	defer f.Close()
+
+	// the LIST chunks are loaded on expanding them
+	locs := &riffbin.ChunkLocations{}
+	riffChunk, err := riffbin.ReadSectionsLazy(f, riffbin.WithChunkLocations(locs))
+	if err != nil {
+		log.Fatalf("%s: %s", err.Error(), name)
+	}
+
+	t, err := newTree(riffChunk)
+	if err != nil {
+		log.Fatalf("%s: %s", err.Error(), name)
+	}
+	if err := run(&browser{tree: t, locations: locs, perLine: 16}); err != nil {
+		log.Fatalf("%s: %s", err.Error(), name)
+	}
+}
+
+func run(b *browser) error {
+	restore, err := makeRaw(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("raw mode: %w", err)
+	}
+	defer restore()
+
+	w := bufio.NewWriter(os.Stdout)
+	// alternate screen and hidden cursor
+	w.WriteString("\x1b[?1049h\x1b[?25l")
+	defer func() {
+		w.WriteString("\x1b[?25h\x1b[?1049l")
+		w.Flush()
+	}()
+
+	// the terminal size is queried only on resizing
+	resized := make(chan os.Signal, 1)
+	notifyResize(resized)
+	defer stopResize(resized)
+	width, height, err := terminalSize(os.Stdout)
+	if err != nil {
+		return fmt.Errorf("terminal size: %w", err)
+	}
+
+	keys := make(chan keyEvent)
+	go readKeys(newKeyReader(os.Stdin), keys)
+	for {
+		b.render(w, width, height)
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		select {
+		case <-resized:
+			if width, height, err = terminalSize(os.Stdout); err != nil {
+				return fmt.Errorf("terminal size: %w", err)
+			}
+		case ev := <-keys:
+			if ev.err != nil {
+				return ev.err
+			}
+			if b.prompting {
+				b.edit(ev.key)
+			} else if !b.handle(ev.key, height) {
+				return nil
+			}
+		}
+	}
+}
+
+// browser is the state of the screen.
+type browser struct {
+	tree      *tree
+	locations *riffbin.ChunkLocations
+	cursor    int
+	top       int
+	hexTop    int64
+	perLine   int64
+	query     string
+	prompting bool
+	status    string
+}
+
+func (b *browser) selected() *node {
+	return b.tree.rows[b.cursor]
+}
+
+func (b *browser) move(row int) {
+	if row >= len(b.tree.rows) {
+		row = len(b.tree.rows) - 1
+	}
+	if row < 0 {
+		row = 0
+	}
+	if row != b.cursor {
+		b.hexTop = 0
+	}
+	b.cursor = row
+}
+
+// handle handles the key, and returns false to quit.
+func (b *browser) handle(key string, height int) bool {
+	b.status = ""
+	page := height - 2
+	var err error
+	switch key {
+	case "q", "\x03":
+		return false
+	case "j", "down":
+		b.move(b.cursor + 1)
+	case "k", "up":
+		b.move(b.cursor - 1)
+	case "pgdown", "\x06":
+		b.move(b.cursor + page)
+	case "pgup", "\x02":
+		b.move(b.cursor - page)
+	case "g", "home":
+		b.move(0)
+	case "G", "end":
+		b.move(len(b.tree.rows) - 1)
+	case "enter", " ":
+		err = b.tree.toggle(b.selected())
+	case "l", "right":
+		if n := b.selected(); n.grouped() && !n.expanded {
+			err = b.tree.toggle(n)
+		}
+	case "h", "left":
+		if n := b.selected(); n.expanded {
+			err = b.tree.toggle(n)
+		} else if n.parent != nil {
+			b.move(b.tree.reveal(n.parent))
+		}
+	case "]":
+		if _, size, ok := payloadReaderAt(b.selected().chunk); ok && b.hexTop+int64(page)*b.perLine < size {
+			b.hexTop += int64(page) * b.perLine
+		}
+	case "[":
+		if b.hexTop -= int64(page) * b.perLine; b.hexTop < 0 {
+			b.hexTop = 0
+		}
+	case "/":
+		b.prompting = true
+		b.query = ""
+	case "n":
+		b.search(false)
+	case "N":
+		b.search(true)
+	}
+	if err != nil {
+		b.status = err.Error()
+	}
+	return true
+}
+
+// edit edits the search query in the prompt.
+func (b *browser) edit(key string) {
+	switch key {
+	case "enter":
+		b.prompting = false
+		b.search(false)
+	case "esc", "\x03":
+		b.prompting = false
+	case "backspace":
+		if len(b.query) > 0 {
+			b.query = b.query[:len(b.query)-1]
+		}
+	default:
+		if len(key) == 1 && key[0] >= 0x20 && key[0] < 0x7f && len(b.query) < 4 {
+			b.query += key
+		}
+	}
+}
+
+func (b *browser) search(backward bool) {
+	if b.query == "" {
+		return
+	}
+
+	n, err := b.tree.search(b.selected(), b.query, backward)
+	if err != nil {
+		b.status = err.Error()
+	} else if n == nil {
+		b.status = fmt.Sprintf("%q is not found", b.query)
+	} else {
+		b.move(b.tree.reveal(n))
+	}
+}
+
+func (b *browser) render(w io.Writer, width, height int) {
+	if height < 3 || width < 40 {
+		fmt.Fprint(w, "\x1b[H\x1b[2Jterminal is too small")
+		return
+	}
+
+	rows := height - 1
+	if b.cursor < b.top {
+		b.top = b.cursor
+	} else if b.cursor >= b.top+rows {
+		b.top = b.cursor - rows + 1
+	}
+
+	treeWidth := width * 2 / 5
+	panelWidth := width - treeWidth - 1
+	panel := b.panel(panelWidth, rows)
+
+	fmt.Fprint(w, "\x1b[H")
+	for i := 0; i < rows; i++ {
+		var line string
+		if row := b.top + i; row < len(b.tree.rows) {
+			line = fit(label(b.tree.rows[row]), treeWidth)
+			if row == b.cursor {
+				line = "\x1b[7m" + line + "\x1b[0m"
+			}
+		} else {
+			line = strings.Repeat(" ", treeWidth)
+		}
+
+		var p string
+		if i < len(panel) {
+			p = panel[i]
+		}
+		fmt.Fprintf(w, "%s│%s\x1b[K\r\n", line, fit(p, panelWidth))
+	}
+
+	status := b.status
+	if b.prompting {
+		status = "/" + b.query
+	} else if status == "" {
+		status = help
+	}
+	fmt.Fprintf(w, "\x1b[7m%s\x1b[0m", fit(status, width))
+}
+
+// label is the row of the tree.
+func label(n *node) string {
+	mark := " "
+	if n.grouped() {
+		mark = "+"
+		if n.expanded {
+			mark = "-"
+		}
+	}
+	return fmt.Sprintf("%s%s %s [%d]", strings.Repeat("  ", n.depth), mark, n.name, n.chunk.BodySize())
+}
+
+// panel is the side panel of the selected chunk with the details, the decoded fields and the hex dump.
+func (b *browser) panel(width, height int) []string {
+	n := b.selected()
+	lines := []string{n.path, fmt.Sprintf("ID: %q  Size: %d", n.chunk.ChunkID(), n.chunk.BodySize())}
+	if typ, ok := n.chunkType(); ok {
+		lines[1] += fmt.Sprintf("  Type: %q", typ)
+		if n.loaded {
+			lines[1] += fmt.Sprintf("  Chunks: %d", len(n.children))
+		}
+	}
+	if loc, ok := b.locations.Lookup(n.chunk); ok {
+		lines = append(lines, fmt.Sprintf("Header: @%d  Body: @%d  Declared: %d", loc.HeaderOffset, loc.BodyOffset, loc.DeclaredSize))
+	}
+
+	if decoded := decode(n); len(decoded) != 0 {
+		lines = append(lines, "")
+		lines = append(lines, decoded...)
+	}
+
+	r, size, ok := payloadReaderAt(n.chunk)
+	if !ok {
+		return lines
+	}
+	lines = append(lines, "")
+
+	// dump the visible lines only
+	perLine := int64(16)
+	if width < 13+4*16 {
+		perLine = 8
+	}
+	if perLine != b.perLine {
+		b.perLine = perLine
+		b.hexTop -= b.hexTop % perLine
+	}
+	buf := make([]byte, perLine)
+	for off := b.hexTop; off < size && len(lines) < height; off += perLine {
+		nn, err := r.ReadAt(buf[:min64(perLine, size-off)], off)
+		if err != nil && err != io.EOF {
+			lines = append(lines, fmt.Sprintf("error: %s", err.Error()))
+			break
+		}
+		lines = append(lines, hexLine(off, buf[:nn], int(perLine)))
+	}
+	return lines
+}
+
+// hexLine formats a line of the hex dump like hex.Dump.
+func hexLine(off int64, b []byte, perLine int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%08x  ", off)
+	for i := 0; i < perLine; i++ {
+		if i < len(b) {
+			fmt.Fprintf(&sb, "%02x ", b[i])
+		} else {
+			sb.WriteString("   ")
+		}
+	}
+	sb.WriteString(" |")
+	for _, c := range b {
+		if c < 0x20 || c > 0x7e {
+			c = '.'
+		}
+		sb.WriteByte(c)
+	}
+	sb.WriteString("|")
+	return sb.String()
+}
+
+// fit truncates or pads s to width runes.
+func fit(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+
+	var sb strings.Builder
+	for i, r := range []rune(s) {
+		if i == width {
+			break
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// keyReader reads the keys from the terminal in raw mode.
+type keyReader struct {
+	r       io.Reader
+	buf     [16]byte
+	pending []string
+}
+
+func newKeyReader(r io.Reader) *keyReader {
+	return &keyReader{r: r}
+}
+
+var escapeKeys = map[string]string{
+	"\x1b[A": "up", "\x1bOA": "up",
+	"\x1b[B": "down", "\x1bOB": "down",
+	"\x1b[C": "right", "\x1bOC": "right",
+	"\x1b[D": "left", "\x1bOD": "left",
+	"\x1b[5~": "pgup",
+	"\x1b[6~": "pgdown",
+	"\x1b[H":  "home", "\x1b[1~": "home", "\x1bOH": "home",
+	"\x1b[F": "end", "\x1b[4~": "end", "\x1bOF": "end",
+	"\x1b": "esc",
+	"\r":   "enter",
+	"\n":   "enter",
+	"\x7f": "backspace",
+	"\b":   "backspace",
+}
+
+// keyEvent is a key or an error read by readKeys.
+type keyEvent struct {
+	key string
+	err error
+}
+
+// readKeys sends the keys read by r to ch until an error occurs.
+func readKeys(r *keyReader, ch chan<- keyEvent) {
+	for {
+		key, err := r.next()
+		ch <- keyEvent{key: key, err: err}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// next reads a key. The escape sequence is assumed to be read at once, and the other bytes read at once (e.g. pasted text) are split into the keys.
+func (r *keyReader) next() (string, error) {
+	if len(r.pending) == 0 {
+		n, err := r.r.Read(r.buf[:])
+		if err != nil {
+			return "", err
+		}
+
+		if seq := string(r.buf[:n]); r.buf[0] == 0x1b {
+			r.pending = append(r.pending, seq)
+		} else {
+			for i := range seq {
+				r.pending = append(r.pending, seq[i:i+1])
+			}
+		}
+	}
+
+	key := r.pending[0]
+	r.pending = r.pending[1:]
+	if name, ok := escapeKeys[key]; ok {
+		return name, nil
+	}
+	return key, nil
+}
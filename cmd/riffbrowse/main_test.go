@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// run as the command in the sub-process of runCommand
+	if os.Getenv("RIFFBROWSE_TEST_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runCommand runs the command with args without the terminal, and returns the output and the exit code.
+func runCommand(t *testing.T, args ...string) (string, int) {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "RIFFBROWSE_TEST_MAIN=1")
+	out, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return string(out), exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return string(out), 0
+}
+
+func TestCommand(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		args     []string
+		code     int
+		contains string
+	}{
+		{name: "NoArgs", code: 2, contains: "Usage:"},
+		{name: "TooManyArgs", args: []string{"testdata/test.wav", "testdata/test.wav"}, code: 2, contains: "Usage:"},
+		{name: "NotFound", args: []string{"testdata/notfound.wav"}, code: 1, contains: "testdata/notfound.wav"},
+		{name: "NotRIFF", args: []string{"main.go"}, code: 1, contains: "invlaid format: main.go"},
+		{name: "NotTerminal", args: []string{"testdata/test.wav"}, code: 1, contains: "raw mode: "},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if out, code := runCommand(t, tc.args...); code != tc.code || !strings.Contains(out, tc.contains) {
+				t.Errorf("unexpected result: %d\n%s", code, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+func ioctl(f *os.File, req uintptr, arg unsafe.Pointer) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), req, uintptr(arg)); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// makeRaw puts the terminal into raw mode, and returns the function to restore it.
+func makeRaw(f *os.File) (func() error, error) {
+	var saved syscall.Termios
+	if err := ioctl(f, syscall.TCGETS, unsafe.Pointer(&saved)); err != nil {
+		return nil, err
+	}
+
+	raw := saved
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := ioctl(f, syscall.TCSETS, unsafe.Pointer(&raw)); err != nil {
+		return nil, err
+	}
+
+	return func() error {
+		return ioctl(f, syscall.TCSETS, unsafe.Pointer(&saved))
+	}, nil
+}
+
+// terminalSize returns the columns and the rows of the terminal.
+func terminalSize(f *os.File) (int, int, error) {
+	var ws struct {
+		Row, Col, Xpixel, Ypixel uint16
+	}
+	if err := ioctl(f, syscall.TIOCGWINSZ, unsafe.Pointer(&ws)); err != nil {
+		return 0, 0, err
+	}
+	return int(ws.Col), int(ws.Row), nil
+}
@@ -0,0 +1,49 @@
+//go:build !linux && !windows && !plan9 && !js
+// +build !linux,!windows,!plan9,!js
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// stty runs stty(1) for the terminal.
+func stty(f *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = f
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+// makeRaw puts the terminal into raw mode, and returns the function to restore it.
+func makeRaw(f *os.File) (func() error, error) {
+	saved, err := stty(f, "-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty(f, "raw", "-echo"); err != nil {
+		return nil, err
+	}
+
+	return func() error {
+		_, err := stty(f, strings.TrimSpace(saved))
+		return err
+	}, nil
+}
+
+// terminalSize returns the columns and the rows of the terminal.
+func terminalSize(f *os.File) (int, int, error) {
+	out, err := stty(f, "size")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var rows, cols int
+	if _, err := fmt.Sscan(out, &rows, &cols); err != nil {
+		return 0, 0, err
+	}
+	return cols, rows, nil
+}
@@ -0,0 +1,20 @@
+//go:build !windows && !plan9 && !js
+// +build !windows,!plan9,!js
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyResize relays SIGWINCH to ch on resizing the terminal.
+func notifyResize(ch chan<- os.Signal) {
+	signal.Notify(ch, syscall.SIGWINCH)
+}
+
+// stopResize stops relaying to ch.
+func stopResize(ch chan<- os.Signal) {
+	signal.Stop(ch)
+}
@@ -0,0 +1,24 @@
+//go:build windows || plan9 || js
+// +build windows plan9 js
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// errUnsupportedTerminal is returned on the platforms without the terminal control of the POSIX.
+var errUnsupportedTerminal = errors.New("unsupported terminal on this platform")
+
+func makeRaw(f *os.File) (func() error, error) {
+	return nil, errUnsupportedTerminal
+}
+
+func terminalSize(f *os.File) (int, int, error) {
+	return 0, 0, errUnsupportedTerminal
+}
+
+func notifyResize(ch chan<- os.Signal) {}
+
+func stopResize(ch chan<- os.Signal) {}
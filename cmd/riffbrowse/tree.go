@@ -0,0 +1,193 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/karupanerura/riffbin"
+)
+
+// node is a chunk in the tree. The children are loaded on demand to browse the huge LIST chunks.
+type node struct {
+	name     string
+	path     string
+	chunk    riffbin.Chunk
+	depth    int
+	parent   *node
+	children []*node
+	loaded   bool
+	expanded bool
+}
+
+// chunkType returns the form type or the list type if the chunk is grouped.
+func (n *node) chunkType() (string, bool) {
+	switch c := n.chunk.(type) {
+	case *riffbin.RIFFChunk:
+		return string(c.FormType[:]), true
+	case *riffbin.ListChunk:
+		return string(c.ListType[:]), true
+	}
+	return "", false
+}
+
+func (n *node) grouped() bool {
+	_, ok := n.chunkType()
+	return ok
+}
+
+// matches returns true if the ID or the type of the chunk starts with the FourCC query.
+func (n *node) matches(query string) bool {
+	if strings.HasPrefix(string(n.chunk.ChunkID()), query) {
+		return true
+	}
+	typ, ok := n.chunkType()
+	return ok && strings.HasPrefix(typ, query)
+}
+
+// tree is the chunk tree with the rows of the expanded nodes.
+type tree struct {
+	fsys fs.FS
+	root *node
+	rows []*node
+	all  []*node
+}
+
+func newTree(c *riffbin.RIFFChunk) (*tree, error) {
+	t := &tree{
+		fsys: riffbin.NewFS(c),
+		root: &node{name: "RIFF-" + string(c.FormType[:]), path: ".", chunk: c, expanded: true},
+	}
+	if err := t.load(t.root); err != nil {
+		return nil, err
+	}
+	t.flatten()
+	return t, nil
+}
+
+// load loads the children of n in the order of the payload.
+func (t *tree) load(n *node) error {
+	if n.loaded || !n.grouped() {
+		return nil
+	}
+
+	f, err := t.fsys.Open(n.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	dir, ok := f.(fs.ReadDirFile)
+	if !ok {
+		return errors.New("not a directory")
+	}
+	entries, err := dir.ReadDir(-1)
+	if err != nil {
+		return err
+	}
+
+	n.children = make([]*node, len(entries))
+	for i, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		n.children[i] = &node{
+			name:   entry.Name(),
+			path:   path.Join(n.path, entry.Name()),
+			chunk:  info.Sys().(riffbin.Chunk),
+			depth:  n.depth + 1,
+			parent: n,
+		}
+	}
+	n.loaded = true
+	return nil
+}
+
+// flatten lists the nodes to show as rows.
+func (t *tree) flatten() {
+	t.rows = t.rows[:0]
+	var walk func(n *node)
+	walk = func(n *node) {
+		t.rows = append(t.rows, n)
+		if !n.expanded {
+			return
+		}
+		for _, child := range n.children {
+			walk(child)
+		}
+	}
+	walk(t.root)
+}
+
+// toggle expands or collapses n.
+func (t *tree) toggle(n *node) error {
+	if !n.grouped() {
+		return nil
+	}
+	if err := t.load(n); err != nil {
+		return err
+	}
+	n.expanded = !n.expanded
+	t.flatten()
+	return nil
+}
+
+// reveal expands the ancestors of n, and returns the row of n.
+func (t *tree) reveal(n *node) int {
+	for p := n.parent; p != nil; p = p.parent {
+		p.expanded = true
+	}
+	t.flatten()
+	for i, row := range t.rows {
+		if row == n {
+			return i
+		}
+	}
+	return 0
+}
+
+// search finds the next node matching the query after from in the preorder, or before it if backward.
+// It loads the whole tree at the first time.
+func (t *tree) search(from *node, query string, backward bool) (*node, error) {
+	if t.all == nil {
+		var walk func(n *node) error
+		walk = func(n *node) error {
+			t.all = append(t.all, n)
+			if err := t.load(n); err != nil {
+				return err
+			}
+			for _, child := range n.children {
+				if err := walk(child); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if err := walk(t.root); err != nil {
+			t.all = nil
+			return nil, err
+		}
+	}
+
+	start := 0
+	for i, n := range t.all {
+		if n == from {
+			start = i
+			break
+		}
+	}
+
+	step := 1
+	if backward {
+		step = len(t.all) - 1
+	}
+	for i := 1; i <= len(t.all); i++ {
+		n := t.all[(start+i*step)%len(t.all)]
+		if n.matches(query) {
+			return n, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/karupanerura/riffbin"
+)
+
+var (
+	output      = flag.String("o", "", "output file (in split mode, it is a format with %d for the part number, e.g. \"part-%d.wav\")")
+	splitBytes  = flag.String("split-bytes", "", "split at the comma-separated byte offsets in the data chunk")
+	splitFrames = flag.String("split-frames", "", "split at the comma-separated sample frame offsets")
+	splitCue    = flag.Bool("split-cue", false, "split at the cue points")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage:\n  %[1]s -o OUTPUT-file WAVE-file...\n  %[1]s (-split-bytes|-split-frames|-split-cue) -o OUTPUT-format WAVE-file\n\nThe WAVE-files must have the identical fmt chunk to be concatenated.\n\nOptions:\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	var modes int
+	for _, split := range []bool{*splitBytes != "", *splitFrames != "", *splitCue} {
+		if split {
+			modes++
+		}
+	}
+	if *output == "" || flag.NArg() == 0 || modes > 1 || (modes == 1 && flag.NArg() != 1) {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if modes == 1 && !validFormat(*output) {
+		fmt.Fprintf(os.Stderr, "output format must contain %%d for the part number: %s\n", *output)
+		os.Exit(2)
+	}
+
+	if modes == 0 {
+		concat(flag.Args())
+	} else {
+		split(flag.Arg(0))
+	}
+}
+
+// readWAVE reads the file with the pad bytes, and reads it again without them if it fails.
+// It returns true if the file is read with the pad bytes.
+func readWAVE(name string) (*os.File, *riffbin.RIFFChunk, bool) {
+	f, err := os.Open(name)
+	if err != nil {
+		log.Fatalf("%s: %s", err.Error(), name)
+	}
+
+	riffChunk, err := riffbin.ReadSections(f, riffbin.WithReadPadding())
+	if err == nil {
+		return f, riffChunk, true
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		log.Fatalf("%s: %s", err.Error(), name)
+	}
+	riffChunk, err = riffbin.ReadSections(f)
+	if err != nil {
+		log.Fatalf("%s: %s", err.Error(), name)
+	}
+	return f, riffChunk, false
+}
+
+// writerOptions returns the options to write the output padded if any input is padded.
+func writerOptions(padded bool) []riffbin.WriterOption {
+	if padded {
+		return []riffbin.WriterOption{riffbin.WithWritePadding()}
+	}
+	return nil
+}
+
+func concat(names []string) {
+	files := make([]*os.File, len(names))
+	srcs := make([]*riffbin.RIFFChunk, len(names))
+	anyPadded := false
+	for i, name := range names {
+		f, riffChunk, padded := readWAVE(name)
+		defer f.Close()
+		files[i] = f
+		srcs[i] = riffChunk
+		anyPadded = anyPadded || padded
+	}
+	if err := checkOutput(*output, files); err != nil {
+		log.Fatalf("%s: %s", err.Error(), *output)
+	}
+
+	w, err := os.Create(*output)
+	if err != nil {
+		log.Fatalf("%s: %s", err.Error(), *output)
+	}
+	if _, err := riffbin.ConcatWAVE(w, srcs, writerOptions(anyPadded)...); err != nil {
+		log.Fatalf("%s: %s", err.Error(), *output)
+	}
+	if err := w.Close(); err != nil {
+		log.Fatalf("%s: %s", err.Error(), *output)
+	}
+}
+
+func split(name string) {
+	f, riffChunk, padded := readWAVE(name)
+	defer f.Close()
+
+	var offsets []int64
+	var err error
+	switch {
+	case *splitBytes != "":
+		offsets, err = parseOffsets(*splitBytes, 1)
+	case *splitFrames != "":
+		var blockAlign int64
+		blockAlign, err = riffbin.WAVEBlockAlign(riffChunk)
+		if err == nil {
+			offsets, err = parseOffsets(*splitFrames, blockAlign)
+		}
+	case *splitCue:
+		offsets, err = riffbin.WAVECueOffsets(riffChunk)
+		if err == nil && len(offsets) != 0 && offsets[0] == 0 {
+			// the cue point at the head does not split
+			offsets = offsets[1:]
+		}
+	}
+	if err != nil {
+		log.Fatalf("%s: %s", err.Error(), name)
+	}
+
+	parts, err := riffbin.SplitWAVE(riffChunk, offsets)
+	if err != nil {
+		log.Fatalf("%s: %s", err.Error(), name)
+	}
+	for i, part := range parts {
+		out := fmt.Sprintf(*output, i+1)
+		if err := checkOutput(out, []*os.File{f}); err != nil {
+			log.Fatalf("%s: %s", err.Error(), out)
+		}
+		w, err := os.Create(out)
+		if err != nil {
+			log.Fatalf("%s: %s", err.Error(), out)
+		}
+		if _, err := riffbin.NewCompletedChunkWriter(w, writerOptions(padded)...).Write(part); err != nil {
+			log.Fatalf("%s: %s", err.Error(), out)
+		}
+		if err := w.Close(); err != nil {
+			log.Fatalf("%s: %s", err.Error(), out)
+		}
+	}
+}
+
+var formatVerb = regexp.MustCompile(`%[-+# 0-9]*[a-zA-Z%]`)
+
+// validFormat returns true if format has exactly one %d verb for the part number.
+func validFormat(format string) bool {
+	var verbs []string
+	for _, verb := range formatVerb.FindAllString(format, -1) {
+		if verb != "%%" {
+			verbs = append(verbs, verb)
+		}
+	}
+	return len(verbs) == 1 && strings.HasSuffix(verbs[0], "d")
+}
+
+// checkOutput returns an error if out is one of srcs, because the chunks are still read from srcs on writing.
+func checkOutput(out string, srcs []*os.File) error {
+	info, err := os.Stat(out)
+	if err != nil {
+		return nil
+	}
+	for _, src := range srcs {
+		srcInfo, err := src.Stat()
+		if err != nil {
+			return err
+		}
+		if os.SameFile(info, srcInfo) {
+			return errors.New("output file must not be WAVE-file")
+		}
+	}
+	return nil
+}
+
+// parseOffsets parses the comma-separated offsets, and multiplies them by unit.
+func parseOffsets(s string, unit int64) ([]int64, error) {
+	fields := strings.Split(s, ",")
+	offsets := make([]int64, len(fields))
+	for i, field := range fields {
+		off, err := strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid offset: %w", err)
+		}
+		offsets[i] = off * unit
+	}
+	return offsets, nil
+}
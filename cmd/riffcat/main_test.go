@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/karupanerura/riffbin"
+)
+
+func TestMain(m *testing.M) {
+	// run as the command in the sub-process of runCommand
+	if os.Getenv("RIFFCAT_TEST_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runCommand runs the command with args, and returns the output and the exit code.
+func runCommand(t *testing.T, args ...string) (string, int) {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "RIFFCAT_TEST_MAIN=1")
+	out, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return string(out), exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return string(out), 0
+}
+
+// copyTestdata copies the file in testdata to the temporary directory to be used as the output.
+func copyTestdata(t *testing.T, name string) string {
+	t.Helper()
+
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(file, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+// readData reads the payload of the data chunk in the WAVE file with the pad bytes.
+func readData(t *testing.T, name string) []byte {
+	t.Helper()
+
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	riffChunk, err := riffbin.ReadFull(bytes.NewReader(b), riffbin.WithReadPadding())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return riffChunk.Payload[len(riffChunk.Payload)-1].(*riffbin.OnMemorySubChunk).Payload
+}
+
+func TestCommand(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		args     []string
+		contains string
+	}{
+		{name: "NoArgs", contains: "Usage:"},
+		{name: "NoOutput", args: []string{"testdata/a.wav"}, contains: "Usage:"},
+		{name: "MultipleModes", args: []string{"-split-bytes", "2", "-split-cue", "-o", "part-%d.wav", "testdata/a.wav"}, contains: "Usage:"},
+		{name: "SplitMultipleFiles", args: []string{"-split-bytes", "2", "-o", "part-%d.wav", "testdata/a.wav", "testdata/b.wav"}, contains: "Usage:"},
+		{name: "SplitWithoutVerb", args: []string{"-split-bytes", "2", "-o", "part.wav", "testdata/a.wav"}, contains: "output format must contain %d for the part number: part.wav"},
+		{name: "SplitWithWrongVerb", args: []string{"-split-bytes", "2", "-o", "part-%s.wav", "testdata/a.wav"}, contains: "output format must contain %d for the part number: part-%s.wav"},
+		{name: "SplitWithExtraVerb", args: []string{"-split-bytes", "2", "-o", "%d/part-%d.wav", "testdata/a.wav"}, contains: "output format must contain %d for the part number: %d/part-%d.wav"},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if out, code := runCommand(t, tc.args...); code != 2 || !strings.Contains(out, tc.contains) {
+				t.Errorf("unexpected result: %d\n%s", code, out)
+			}
+		})
+	}
+
+	t.Run("Concat", func(t *testing.T) {
+		t.Parallel()
+
+		out := filepath.Join(t.TempDir(), "out.wav")
+		if msg, code := runCommand(t, "-o", out, "testdata/a.wav", "testdata/b.wav"); code != 0 {
+			t.Fatalf("unexpected result: %d\n%s", code, msg)
+		}
+		if data := readData(t, out); !bytes.Equal(data, []byte{0x01, 0x00, 0x02, 0x00, 0x03, 0x00, 0x04, 0x00, 0x05, 0x00}) {
+			t.Errorf("unexpected data: %v", data)
+		}
+	})
+
+	t.Run("ConcatPadded", func(t *testing.T) {
+		t.Parallel()
+
+		// the odd-sized LIST-INFO of the first WAVE is carried over with the pad byte
+		out := filepath.Join(t.TempDir(), "out.wav")
+		if msg, code := runCommand(t, "-o", out, "testdata/padded.wav", "testdata/a.wav"); code != 0 {
+			t.Fatalf("unexpected result: %d\n%s", code, msg)
+		}
+		if data := readData(t, out); !bytes.Equal(data, []byte{0x06, 0x00, 0x07, 0x00, 0x01, 0x00, 0x02, 0x00}) {
+			t.Errorf("unexpected data: %v", data)
+		}
+	})
+
+	t.Run("ConcatSameFile", func(t *testing.T) {
+		t.Parallel()
+
+		file := copyTestdata(t, "a.wav")
+		if out, code := runCommand(t, "-o", file, file, "testdata/b.wav"); code != 1 || !strings.Contains(out, "output file must not be WAVE-file") {
+			t.Errorf("unexpected result: %d\n%s", code, out)
+		}
+
+		original, _ := os.ReadFile("testdata/a.wav")
+		if got, _ := os.ReadFile(file); !bytes.Equal(got, original) {
+			t.Error("input file is modified")
+		}
+	})
+
+	t.Run("Split", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		if out, code := runCommand(t, "-split-frames", "1,2", "-o", filepath.Join(dir, "part-%d.wav"), "testdata/b.wav"); code != 0 {
+			t.Fatalf("unexpected result: %d\n%s", code, out)
+		}
+		for i, expected := range [][]byte{{0x03, 0x00}, {0x04, 0x00}, {0x05, 0x00}} {
+			if data := readData(t, filepath.Join(dir, "part-"+strconv.Itoa(i+1)+".wav")); !bytes.Equal(data, expected) {
+				t.Errorf("part %d: unexpected data: %v", i+1, data)
+			}
+		}
+	})
+
+	t.Run("SplitPadded", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		if out, code := runCommand(t, "-split-bytes", "2", "-o", filepath.Join(dir, "part-%d.wav"), "testdata/padded.wav"); code != 0 {
+			t.Fatalf("unexpected result: %d\n%s", code, out)
+		}
+		for i, expected := range [][]byte{{0x06, 0x00}, {0x07, 0x00}} {
+			if data := readData(t, filepath.Join(dir, "part-"+strconv.Itoa(i+1)+".wav")); !bytes.Equal(data, expected) {
+				t.Errorf("part %d: unexpected data: %v", i+1, data)
+			}
+		}
+	})
+
+	t.Run("SplitSameFile", func(t *testing.T) {
+		t.Parallel()
+
+		file := copyTestdata(t, "b.wav")
+		renamed := filepath.Join(filepath.Dir(file), "part-1.wav")
+		if err := os.Rename(file, renamed); err != nil {
+			t.Fatal(err)
+		}
+		if out, code := runCommand(t, "-split-bytes", "2", "-o", filepath.Join(filepath.Dir(file), "part-%d.wav"), renamed); code != 1 || !strings.Contains(out, "output file must not be WAVE-file") {
+			t.Errorf("unexpected result: %d\n%s", code, out)
+		}
+
+		original, _ := os.ReadFile("testdata/b.wav")
+		if got, _ := os.ReadFile(renamed); !bytes.Equal(got, original) {
+			t.Error("input file is modified")
+		}
+	})
+}
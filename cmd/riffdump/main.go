@@ -1,63 +1,210 @@
 package main
 
 import (
+	"bufio"
 	"encoding/hex"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"path"
 	"strings"
 
 	"github.com/karupanerura/riffbin"
 )
 
+var (
+	jsonOutput  = flag.Bool("json", false, "print the chunks as JSON lines")
+	treeOnly    = flag.Bool("tree", false, "print the chunk tree with sizes only (no hex dump)")
+	showOffsets = flag.Bool("offsets", false, "print the absolute offsets of the chunk headers")
+	maxDepth    = flag.Int("depth", -1, "max depth of the chunks to print (0 is the root RIFF chunk, -1 is unlimited)")
+	maxBytes    = flag.Int64("bytes", -1, "max bytes of the payload to dump (-1 is unlimited, and the default is 4096 with -json)")
+	pathPattern = flag.String("path", "", "print only the chunks whose path matches the pattern (e.g. \"LIST-INFO/*\")")
+	noPadding   = flag.Bool("no-padding", false, "read the chunks without the pad bytes (it is detected for RIFF-file other than stdin)")
+)
+
 func main() {
-	if len(os.Args) != 2 {
-		log.Printf("Usage: %s RIFF-file", os.Args[0])
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [options] RIFF-file\n\nRIFF-file can be \"-\" to read from stdin.\n\nOptions:\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *jsonOutput && !isFlagSet("bytes") {
+		*maxBytes = jsonDefaultBytes
+	}
+	if *pathPattern != "" {
+		if _, err := path.Match(*pathPattern, ""); err != nil {
+			log.Fatalf("invalid path pattern: %s", err.Error())
+		}
 	}
 
-	f, err := os.Open(os.Args[1])
-	if err != nil {
-		log.Fatalf("%s: %s", err.Error(), os.Args[1])
+	name := flag.Arg(0)
+	var src io.Reader
+	padded := !*noPadding
+	if name == "-" {
+		src = bufio.NewReader(os.Stdin)
+	} else {
+		f, err := os.Open(name)
+		if err != nil {
+			log.Fatalf("%s: %s", err.Error(), name)
+		}
+		defer f.Close()
+		src = f
+
+		if padded {
+			if padded, err = detectPadding(f); err != nil {
+				log.Fatalf("%s: %s", err.Error(), name)
+			}
+		}
 	}
-	defer f.Close()
 
-	riffChunk, err := riffbin.ReadSections(f)
+	var opts []riffbin.ReaderOption
+	if padded {
+		opts = append(opts, riffbin.WithReadPadding())
+	}
+	w := bufio.NewWriter(os.Stdout)
+	r := riffbin.NewStreamReader(src, opts...)
+	err := dump(w, r)
+	if flushErr := w.Flush(); err == nil {
+		err = flushErr
+	}
 	if err != nil {
-		pos, _ := f.Seek(0, io.SeekCurrent)
-		log.Fatalf("%s: %s at %d", err.Error(), os.Args[1], pos)
+		log.Fatalf("%s: %s at %d", err.Error(), name, r.Offset())
 	}
+}
 
-	dumpChunk(riffChunk, 0)
+// detectPadding scans the chunk headers with the pad bytes, and returns true if they are read to the end.
+// The payloads are skipped by Seek, and f is rewound to the head.
+func detectPadding(f *os.File) (bool, error) {
+	r := riffbin.NewStreamReader(f, riffbin.WithReadPadding())
+	var err error
+	for err == nil {
+		_, err = r.Next()
+	}
+	if _, seekErr := f.Seek(0, io.SeekStart); seekErr != nil {
+		return false, seekErr
+	}
+	return err == io.EOF, nil
 }
 
-func dumpChunk(chunk riffbin.Chunk, level int) {
-	indent := strings.Repeat("  ", level)
-	io.WriteString(os.Stdout, indent)
-	switch c := chunk.(type) {
-	case *riffbin.RIFFChunk:
-		fmt.Printf("RIFF[%s:%d]:\n", c.FormType, c.BodySize())
-		for _, cc := range c.Payload {
-			dumpChunk(cc, level+1)
+func dump(w io.Writer, r *riffbin.StreamReader) error {
+	for {
+		h, err := r.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if *maxDepth >= 0 && h.Depth > *maxDepth {
+			continue
+		}
+		if *pathPattern != "" {
+			if ok, _ := path.Match(*pathPattern, h.Path); !ok {
+				continue
+			}
 		}
-		return
-	case *riffbin.ListChunk:
-		fmt.Printf("LIST[%s:%d]:\n", c.ListType, c.BodySize())
-		for _, cc := range c.Payload {
-			dumpChunk(cc, level+1)
+
+		if *jsonOutput {
+			err = dumpJSON(w, r, h)
+		} else {
+			err = dumpText(w, r, h)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// jsonDefaultBytes is the max bytes of the payload in JSON if -bytes is not given, not to read the large payloads on memory.
+const jsonDefaultBytes = 4096
+
+func isFlagSet(name string) (set bool) {
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return
+}
+
+// payload returns the reader of the payload to dump.
+func payload(r *riffbin.StreamReader) io.Reader {
+	if *maxBytes >= 0 {
+		return io.LimitReader(r, *maxBytes)
+	}
+	return r
+}
+
+func dumpText(w io.Writer, r *riffbin.StreamReader, h *riffbin.StreamHeader) error {
+	indent := strings.Repeat("  ", h.Depth)
+	io.WriteString(w, indent)
+
+	var offset string
+	if *showOffsets {
+		offset = fmt.Sprintf("@%d", h.Location.HeaderOffset)
+	}
+	if h.IsGrouped() {
+		fmt.Fprintf(w, "%s[%s:%d]%s:\n", h.ID, h.Type, h.Location.DeclaredSize, offset)
+		return nil
+	}
+
+	fmt.Fprintf(w, "%s[%d]%s\n", h.ID, h.Location.DeclaredSize, offset)
+	if *treeOnly {
+		return nil
+	}
+
+	io.WriteString(w, indent)
+	io.WriteString(w, indent)
+	replacer := strings.NewReplacer("\n", "\n"+indent+indent)
+	dumper := hex.Dumper(&replacerWriter{w: w, replacer: replacer})
+	if _, err := io.Copy(dumper, payload(r)); err != nil {
+		return err
+	}
+	dumper.Close()
+	_, err := w.Write([]byte{'\n'})
+	return err
+}
+
+type jsonEntry struct {
+	Path      string `json:"path"`
+	ID        string `json:"id"`
+	Type      string `json:"type,omitempty"`
+	Depth     int    `json:"depth"`
+	Size      uint32 `json:"size"`
+	Offset    *int64 `json:"offset,omitempty"`
+	Data      string `json:"data,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+func dumpJSON(w io.Writer, r *riffbin.StreamReader, h *riffbin.StreamHeader) error {
+	e := jsonEntry{
+		Path:  h.Path,
+		ID:    string(h.ID[:]),
+		Depth: h.Depth,
+		Size:  h.Location.DeclaredSize,
+	}
+	if h.IsGrouped() {
+		e.Type = string(h.Type[:])
+	}
+	if *showOffsets {
+		e.Offset = &h.Location.HeaderOffset
+	}
+	if !h.IsGrouped() && !*treeOnly {
+		b, err := io.ReadAll(payload(r))
+		if err != nil {
+			return err
 		}
-		return
-	case riffbin.SubChunk:
-		fmt.Printf("%s[%d]\n", c.ChunkID(), c.BodySize())
-		io.WriteString(os.Stdout, indent)
-		io.WriteString(os.Stdout, indent)
-		replacer := strings.NewReplacer("\n", "\n"+indent+indent)
-		dumper := hex.Dumper(&replacerWriter{w: os.Stdout, replacer: replacer})
-		_, _ = io.Copy(dumper, c)
-		dumper.Close()
-		os.Stdout.Write([]byte{'\n'})
-		return
+		e.Data = hex.EncodeToString(b)
+		e.Truncated = int64(len(b)) < int64(h.Location.DeclaredSize)
 	}
+	return json.NewEncoder(w).Encode(&e)
 }
 
 type replacerWriter struct {
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// run as the command in the sub-process of runCommand
+	if os.Getenv("RIFFDUMP_TEST_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runCommand runs the command with args and stdin, and returns the stdout, the stderr and the exit code.
+func runCommand(t *testing.T, stdin io.Reader, args ...string) (string, string, int) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "RIFFDUMP_TEST_MAIN=1")
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = stdin, &stdout, &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return stdout.String(), stderr.String(), exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return stdout.String(), stderr.String(), 0
+}
+
+func TestCommand(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		args     []string
+		code     int
+		contains string
+	}{
+		{name: "NoArgs", code: 2, contains: "Usage:"},
+		{name: "InvalidPattern", args: []string{"-path", "[", "testdata/test.wav"}, code: 1, contains: "invalid path pattern"},
+		{name: "FileNotFound", args: []string{"testdata/notfound.wav"}, code: 1, contains: "testdata/notfound.wav"},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, stderr, code := runCommand(t, nil, tc.args...); code != tc.code || !strings.Contains(stderr, tc.contains) {
+				t.Errorf("unexpected result: %d\n%s", code, stderr)
+			}
+		})
+	}
+
+	t.Run("UnpaddedStdin", func(t *testing.T) {
+		t.Parallel()
+
+		// the padding cannot be detected for stdin, so it is read with the pad bytes without -no-padding
+		f, err := os.Open("testdata/unpadded.wav")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		if _, stderr, code := runCommand(t, f, "-tree", "-"); code != 1 || !strings.Contains(stderr, "invlaid format: - at 68") {
+			t.Errorf("unexpected result: %d\n%s", code, stderr)
+		}
+	})
+}
+
+func TestGolden(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		golden string
+		args   []string
+		stdin  string
+	}{
+		{golden: "test.wav.golden", args: []string{"testdata/test.wav"}},
+		{golden: "test.wav.depth.golden", args: []string{"-depth", "1", "-bytes", "2", "testdata/test.wav"}},
+		{golden: "test.wav.path.golden", args: []string{"-path", "LIST-INFO/*", "-offsets", "testdata/test.wav"}},
+		{golden: "padded.webp.golden", args: []string{"-offsets", "testdata/padded.webp"}},
+		{golden: "padded.webp.json.golden", args: []string{"-json", "-offsets", "testdata/padded.webp"}},
+		{golden: "padded.webp.json.golden", args: []string{"-json", "-offsets", "-"}, stdin: "testdata/padded.webp"},
+		{golden: "unpadded.wav.golden", args: []string{"-tree", "-offsets", "testdata/unpadded.wav"}},
+		{golden: "unpadded.wav.golden", args: []string{"-tree", "-offsets", "-no-padding", "-"}, stdin: "testdata/unpadded.wav"},
+	} {
+		tc := tc
+		t.Run(strings.Join(tc.args, " "), func(t *testing.T) {
+			t.Parallel()
+
+			var stdin io.Reader
+			if tc.stdin != "" {
+				f, err := os.Open(tc.stdin)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer f.Close()
+				stdin = f
+			}
+
+			stdout, stderr, code := runCommand(t, stdin, tc.args...)
+			if code != 0 {
+				t.Fatalf("unexpected result: %d\n%s", code, stderr)
+			}
+			expected, err := os.ReadFile(filepath.Join("testdata", tc.golden))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stdout != string(expected) {
+				t.Errorf("unexpected output:\n%s\nexpected:\n%s", stdout, expected)
+			}
+		})
+	}
+}
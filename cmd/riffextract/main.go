@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/karupanerura/riffbin"
+)
+
+var (
+	output     = flag.String("o", "-", "output file (\"-\" is stdout)")
+	wholeChunk = flag.Bool("chunk", false, "extract the whole chunk binary with the header and the pad byte instead of the payload (required for RIFF/LIST chunks)")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [options] RIFF-file PATH\n\nPATH is the path of the chunk printed by riffdump -tree (e.g. \"LIST-INFO/INAM\", \"data\").\n\nOptions:\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	name, chunkPath := flag.Arg(0), flag.Arg(1)
+
+	f, err := os.Open(name)
+	if err != nil {
+		log.Fatalf("%s: %s", err.Error(), name)
+	}
+	defer f.Close()
+
+	locs, riffChunk, err := read(f)
+	if err != nil {
+		log.Fatalf("%s: %s", err.Error(), name)
+	}
+
+	chunk, err := riffbin.LookupChunk(riffChunk, chunkPath)
+	if err != nil {
+		log.Fatalf("%s: %s", err.Error(), name)
+	}
+
+	var r io.Reader
+	if *wholeChunk {
+		loc, _ := locs.Lookup(chunk)
+		r = io.NewSectionReader(f, loc.HeaderOffset, riffbin.HeaderBytes+int64(loc.DeclaredSize)+loc.PadBytes)
+	} else if c, ok := chunk.(*riffbin.InStreamSubChunk); ok {
+		r = c
+	} else {
+		log.Fatalf("%s is a grouped chunk, use -chunk to extract it: %s", chunkPath, name)
+	}
+
+	w := os.Stdout
+	if *output != "-" {
+		// the chunk is still read from f on writing
+		if info, err := os.Stat(*output); err == nil {
+			srcInfo, err := f.Stat()
+			if err != nil {
+				log.Fatalf("%s: %s", err.Error(), name)
+			}
+			if os.SameFile(info, srcInfo) {
+				log.Fatalf("output file must not be RIFF-file: %s", *output)
+			}
+		}
+
+		w, err = os.Create(*output)
+		if err != nil {
+			log.Fatalf("%s: %s", err.Error(), *output)
+		}
+	}
+
+	if _, err := io.Copy(w, r); err != nil {
+		log.Fatalf("%s: %s", err.Error(), *output)
+	}
+	if err := w.Close(); err != nil {
+		log.Fatalf("%s: %s", err.Error(), *output)
+	}
+}
+
+// read reads the file with the pad bytes, and reads it again without them if it fails.
+func read(f *os.File) (*riffbin.ChunkLocations, *riffbin.RIFFChunk, error) {
+	locs := &riffbin.ChunkLocations{}
+	riffChunk, err := riffbin.ReadSections(f, riffbin.WithReadPadding(), riffbin.WithChunkLocations(locs))
+	if err == nil {
+		return locs, riffChunk, nil
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, nil, err
+	}
+	locs = &riffbin.ChunkLocations{}
+	riffChunk, err = riffbin.ReadSections(f, riffbin.WithChunkLocations(locs))
+	return locs, riffChunk, err
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// run as the command in the sub-process of runCommand
+	if os.Getenv("RIFFEXTRACT_TEST_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runCommand runs the command with args, and returns the stdout, the stderr and the exit code.
+func runCommand(t *testing.T, args ...string) (string, string, int) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "RIFFEXTRACT_TEST_MAIN=1")
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return stdout.String(), stderr.String(), exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return stdout.String(), stderr.String(), 0
+}
+
+// copyTestdata copies the file in testdata to the temporary directory to be used as the output.
+func copyTestdata(t *testing.T, name string) string {
+	t.Helper()
+
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(file, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestCommand(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		args     []string
+		code     int
+		stdout   string
+		contains string
+	}{
+		{name: "NoArgs", code: 2, contains: "Usage:"},
+		{name: "NoPath", args: []string{"testdata/test.wav"}, code: 2, contains: "Usage:"},
+		{name: "Payload", args: []string{"testdata/test.wav", "LIST-INFO/INAM"}, stdout: "riffbin\x00"},
+		{name: "WholeChunk", args: []string{"-chunk", "testdata/test.wav", "data"}, stdout: "data\x04\x00\x00\x00\x01\x00\x02\x00"},
+		{name: "PaddedPayload", args: []string{"testdata/padded.webp", "EXIF"}, stdout: "Exif!"},
+		{name: "PaddedWholeChunk", args: []string{"-chunk", "testdata/padded.webp", "EXIF"}, stdout: "EXIF\x05\x00\x00\x00Exif!\x00"},
+		{name: "Grouped", args: []string{"testdata/test.wav", "LIST-INFO"}, code: 1, contains: "LIST-INFO is a grouped chunk, use -chunk to extract it: testdata/test.wav"},
+		{name: "PathNotFound", args: []string{"testdata/test.wav", "LIST-INFO/ISFT"}, code: 1, contains: "testdata/test.wav"},
+		{name: "FileNotFound", args: []string{"testdata/notfound.wav", "data"}, code: 1, contains: "testdata/notfound.wav"},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			stdout, stderr, code := runCommand(t, tc.args...)
+			if code != tc.code || stdout != tc.stdout || !strings.Contains(stderr, tc.contains) {
+				t.Errorf("unexpected result: %d\n%q\n%s", code, stdout, stderr)
+			}
+		})
+	}
+
+	t.Run("Output", func(t *testing.T) {
+		t.Parallel()
+
+		out := filepath.Join(t.TempDir(), "info.chunk")
+		if _, stderr, code := runCommand(t, "-chunk", "-o", out, "testdata/test.wav", "LIST-INFO"); code != 0 {
+			t.Fatalf("unexpected result: %d\n%s", code, stderr)
+		}
+
+		expected := []byte("LIST\x14\x00\x00\x00INFOINAM\x08\x00\x00\x00riffbin\x00")
+		if got, _ := os.ReadFile(out); !bytes.Equal(got, expected) {
+			t.Errorf("unexpected output: %q", got)
+		}
+	})
+
+	t.Run("SameFile", func(t *testing.T) {
+		t.Parallel()
+
+		file := copyTestdata(t, "test.wav")
+		if _, stderr, code := runCommand(t, "-chunk", "-o", file, file, "data"); code != 1 || !strings.Contains(stderr, "output file must not be RIFF-file") {
+			t.Errorf("unexpected result: %d\n%s", code, stderr)
+		}
+
+		original, _ := os.ReadFile("testdata/test.wav")
+		if got, _ := os.ReadFile(file); !bytes.Equal(got, original) {
+			t.Error("input file is modified")
+		}
+	})
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/karupanerura/riffbin"
+)
+
+var (
+	output     = flag.String("o", "-", "output file (\"-\" is stdout, it must not be RIFF-file or PAYLOAD-file)")
+	replace    = flag.String("replace", "", "replace the chunk at the path")
+	remove     = flag.String("delete", "", "delete the chunk at the path")
+	before     = flag.String("before", "", "insert the chunk before the chunk at the path")
+	after      = flag.String("after", "", "insert the chunk after the chunk at the path")
+	appendTo   = flag.String("append", "", "append the chunk to the RIFF/LIST chunk at the path (\".\" is the root RIFF chunk)")
+	chunkID    = flag.String("id", "", "ID of the inserted sub-chunk (default: ID of the replaced chunk)")
+	wholeChunk = flag.Bool("chunk", false, "PAYLOAD-file is the whole chunk binary with the header (e.g. extracted by riffextract -chunk)")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [options] (-replace|-delete|-before|-after|-append) PATH RIFF-file [PAYLOAD-file]\n\nPATH is the path of the chunk printed by riffdump -tree (e.g. \"LIST-INFO/INAM\", \"data\").\nPAYLOAD-file can be \"-\" to read from stdin.\n\nOptions:\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	var ops int
+	for _, op := range []string{*replace, *remove, *before, *after, *appendTo} {
+		if op != "" {
+			ops++
+		}
+	}
+	if ops != 1 || (*remove != "" && flag.NArg() != 1) || (*remove == "" && flag.NArg() != 2) {
+		flag.Usage()
+		os.Exit(2)
+	}
+	name := flag.Arg(0)
+
+	f, err := os.Open(name)
+	if err != nil {
+		log.Fatalf("%s: %s", err.Error(), name)
+	}
+	defer f.Close()
+
+	riffChunk, padded, err := read(f)
+	if err != nil {
+		log.Fatalf("%s: %s", err.Error(), name)
+	}
+
+	srcs := []*os.File{f}
+	if *remove != "" {
+		err = riffbin.DeleteChunk(riffChunk, *remove)
+	} else {
+		var chunk riffbin.Chunk
+		var payload *os.File
+		chunk, payload, err = readNewChunk(riffChunk, flag.Arg(1))
+		if err != nil {
+			log.Fatalf("%s: %s", err.Error(), flag.Arg(1))
+		}
+		srcs = append(srcs, payload)
+
+		switch {
+		case *replace != "":
+			err = riffbin.ReplaceChunk(riffChunk, *replace, chunk)
+		case *before != "":
+			err = riffbin.InsertChunkBefore(riffChunk, *before, chunk)
+		case *after != "":
+			err = riffbin.InsertChunkAfter(riffChunk, *after, chunk)
+		case *appendTo != "":
+			err = riffbin.AppendChunk(riffChunk, *appendTo, chunk)
+		}
+	}
+	if err != nil {
+		log.Fatalf("%s: %s", err.Error(), name)
+	}
+
+	var opts []riffbin.WriterOption
+	if padded {
+		opts = append(opts, riffbin.WithWritePadding())
+	}
+	if err := write(srcs, riffChunk, opts); err != nil {
+		log.Fatalf("%s: %s", err.Error(), *output)
+	}
+}
+
+// read reads the file with the pad bytes, and reads it again without them if it fails.
+// It returns true if the file is read with the pad bytes.
+func read(f *os.File) (*riffbin.RIFFChunk, bool, error) {
+	riffChunk, err := riffbin.ReadSections(f, riffbin.WithReadPadding())
+	if err == nil {
+		return riffChunk, true, nil
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, false, err
+	}
+	riffChunk, err = riffbin.ReadSections(f)
+	return riffChunk, false, err
+}
+
+// readNewChunk reads the chunk to insert from the payload file, and returns it with the payload file.
+func readNewChunk(riffChunk *riffbin.RIFFChunk, name string) (riffbin.Chunk, *os.File, error) {
+	var f *os.File
+	if name == "-" {
+		f = os.Stdin
+	} else {
+		var err error
+		f, err = os.Open(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		// f is closed on exit because the payload is read on writing
+	}
+
+	if *wholeChunk {
+		b, err := io.ReadAll(f)
+		if err != nil {
+			return nil, nil, err
+		}
+		chunk, err := parseChunk(b)
+		return chunk, f, err
+	}
+
+	id, err := newChunkID(riffChunk)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if name == "-" {
+		payload, err := io.ReadAll(f)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &riffbin.OnMemorySubChunk{ID: id, Payload: payload}, f, nil
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	return &riffbin.InStreamSubChunk{ID: id, SectionReader: io.NewSectionReader(f, 0, info.Size())}, f, nil
+}
+
+func newChunkID(riffChunk *riffbin.RIFFChunk) ([4]byte, error) {
+	var id [4]byte
+	if *chunkID != "" {
+		if len(*chunkID) != len(id) {
+			return id, fmt.Errorf("invalid chunk ID: %q", *chunkID)
+		}
+		copy(id[:], *chunkID)
+		return id, nil
+	}
+	if *replace == "" {
+		return id, errors.New("-id is required to insert a sub-chunk")
+	}
+
+	chunk, err := riffbin.LookupChunk(riffChunk, *replace)
+	if err != nil {
+		return id, err
+	}
+	copy(id[:], chunk.ChunkID())
+	return id, nil
+}
+
+// parseChunk parses the whole chunk binary by wrapping it in RIFF chunk.
+// The grouped chunk is parsed with the pad bytes first as same as RIFF-file.
+func parseChunk(b []byte) (riffbin.Chunk, error) {
+	head := []byte{'R', 'I', 'F', 'F', 0, 0, 0, 0, 'W', 'R', 'A', 'P'}
+	binary.LittleEndian.PutUint32(head[4:8], uint32(len(b)+4))
+
+	wrapper, err := riffbin.ReadFull(io.MultiReader(bytes.NewReader(head), bytes.NewReader(b)), riffbin.WithReadPadding())
+	if err != nil {
+		wrapper, err = riffbin.ReadFull(io.MultiReader(bytes.NewReader(head), bytes.NewReader(b)))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapper.Payload) != 1 {
+		return nil, errors.New("it must contain exactly one chunk")
+	}
+	return wrapper.Payload[0], nil
+}
+
+// write writes riffChunk to the output file. srcs are RIFF-file and PAYLOAD-file.
+func write(srcs []*os.File, riffChunk *riffbin.RIFFChunk, opts []riffbin.WriterOption) error {
+	if *output == "-" {
+		_, err := riffbin.NewCompletedChunkWriter(os.Stdout, opts...).Write(riffChunk)
+		return err
+	}
+
+	// the chunks are still read from srcs on writing
+	if info, err := os.Stat(*output); err == nil {
+		for i, src := range srcs {
+			srcInfo, err := src.Stat()
+			if err != nil {
+				return err
+			}
+			if !os.SameFile(info, srcInfo) {
+				continue
+			}
+			if i == 0 {
+				return errors.New("output file must not be RIFF-file")
+			}
+			return errors.New("output file must not be PAYLOAD-file")
+		}
+	}
+
+	w, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if _, err := riffbin.NewCompletedChunkWriter(w, opts...).Write(riffChunk); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/karupanerura/riffbin"
+)
+
+func TestMain(m *testing.M) {
+	// run as the command in the sub-process of runCommand
+	if os.Getenv("RIFFINSERT_TEST_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runCommand runs the command with args, and returns the stdout, the stderr and the exit code.
+func runCommand(t *testing.T, args ...string) (string, string, int) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "RIFFINSERT_TEST_MAIN=1")
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return stdout.String(), stderr.String(), exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return stdout.String(), stderr.String(), 0
+}
+
+// copyTestdata copies the file in testdata to the temporary directory to be used as the output.
+func copyTestdata(t *testing.T, name string) string {
+	t.Helper()
+
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(file, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+// chunkPaths returns the paths of the sub-chunks in the RIFF binary with the payloads.
+func chunkPaths(t *testing.T, b []byte) map[string]string {
+	t.Helper()
+
+	riffChunk, err := riffbin.ReadFull(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paths := map[string]string{}
+	var walk func(prefix string, payload []riffbin.Chunk)
+	walk = func(prefix string, payload []riffbin.Chunk) {
+		for _, chunk := range payload {
+			switch c := chunk.(type) {
+			case *riffbin.ListChunk:
+				walk(prefix+"LIST-"+string(c.ListType[:])+"/", c.Payload)
+			case *riffbin.OnMemorySubChunk:
+				paths[prefix+string(c.ID[:])] = string(c.Payload)
+			}
+		}
+	}
+	walk("", riffChunk.Payload)
+	return paths
+}
+
+func TestCommand(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		args     []string
+		code     int
+		contains string
+	}{
+		{name: "NoArgs", code: 2, contains: "Usage:"},
+		{name: "MultipleOps", args: []string{"-delete", "data", "-append", ".", "testdata/test.wav", "testdata/name.txt"}, code: 2, contains: "Usage:"},
+		{name: "DeleteWithPayload", args: []string{"-delete", "data", "testdata/test.wav", "testdata/name.txt"}, code: 2, contains: "Usage:"},
+		{name: "InsertWithoutPayload", args: []string{"-append", ".", "testdata/test.wav"}, code: 2, contains: "Usage:"},
+		{name: "InsertWithoutID", args: []string{"-append", "LIST-INFO", "testdata/test.wav", "testdata/name.txt"}, code: 1, contains: "-id is required to insert a sub-chunk: testdata/name.txt"},
+		{name: "InvalidID", args: []string{"-id", "ID", "-append", "LIST-INFO", "testdata/test.wav", "testdata/name.txt"}, code: 1, contains: `invalid chunk ID: "ID"`},
+		{name: "PathNotFound", args: []string{"-delete", "LIST-INFO/ISFT", "testdata/test.wav"}, code: 1, contains: "testdata/test.wav"},
+		{name: "NotChunk", args: []string{"-chunk", "-append", "LIST-INFO", "testdata/test.wav", "testdata/name.txt"}, code: 1, contains: "testdata/name.txt"},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if stdout, stderr, code := runCommand(t, tc.args...); code != tc.code || stdout != "" || !strings.Contains(stderr, tc.contains) {
+				t.Errorf("unexpected result: %d\n%q\n%s", code, stdout, stderr)
+			}
+		})
+	}
+
+	for _, tc := range []struct {
+		name     string
+		args     []string
+		expected map[string]string
+	}{
+		{
+			name:     "Replace",
+			args:     []string{"-replace", "LIST-INFO/INAM", "testdata/test.wav", "testdata/name.txt"},
+			expected: map[string]string{"fmt ": "\x01\x00\x01\x00\x40\x1f\x00\x00\x80\x3e\x00\x00\x02\x00\x10\x00", "LIST-INFO/INAM": "renamed\x00", "data": "\x01\x00\x02\x00"},
+		},
+		{
+			name:     "Delete",
+			args:     []string{"-delete", "LIST-INFO", "testdata/test.wav"},
+			expected: map[string]string{"fmt ": "\x01\x00\x01\x00\x40\x1f\x00\x00\x80\x3e\x00\x00\x02\x00\x10\x00", "data": "\x01\x00\x02\x00"},
+		},
+		{
+			name:     "InsertBefore",
+			args:     []string{"-id", "note", "-before", "data", "testdata/test.wav", "testdata/name.txt"},
+			expected: map[string]string{"fmt ": "\x01\x00\x01\x00\x40\x1f\x00\x00\x80\x3e\x00\x00\x02\x00\x10\x00", "LIST-INFO/INAM": "riffbin\x00", "note": "renamed\x00", "data": "\x01\x00\x02\x00"},
+		},
+		{
+			name:     "AppendChunk",
+			args:     []string{"-chunk", "-append", "LIST-INFO", "testdata/test.wav", "testdata/isft.chunk"},
+			expected: map[string]string{"fmt ": "\x01\x00\x01\x00\x40\x1f\x00\x00\x80\x3e\x00\x00\x02\x00\x10\x00", "LIST-INFO/INAM": "riffbin\x00", "LIST-INFO/ISFT": "test\x00\x00", "data": "\x01\x00\x02\x00"},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			stdout, stderr, code := runCommand(t, tc.args...)
+			if code != 0 {
+				t.Fatalf("unexpected result: %d\n%s", code, stderr)
+			}
+			if df := cmp.Diff(chunkPaths(t, []byte(stdout)), tc.expected); df != "" {
+				t.Errorf("diff = %s", df)
+			}
+		})
+	}
+
+	t.Run("Output", func(t *testing.T) {
+		t.Parallel()
+
+		out := filepath.Join(t.TempDir(), "out.wav")
+		if _, stderr, code := runCommand(t, "-o", out, "-delete", "LIST-INFO/INAM", "testdata/test.wav"); code != 0 {
+			t.Fatalf("unexpected result: %d\n%s", code, stderr)
+		}
+
+		b, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := chunkPaths(t, b)["LIST-INFO/INAM"]; ok || len(b) != 60 {
+			t.Errorf("unexpected output: %q", b)
+		}
+	})
+
+	t.Run("Padded", func(t *testing.T) {
+		t.Parallel()
+
+		// the odd-sized payload is inserted into the padded WebP with the pad byte
+		stdout, stderr, code := runCommand(t, "-id", "XMP ", "-before", "VP8L", "testdata/padded.webp", "testdata/odd.txt")
+		if code != 0 {
+			t.Fatalf("unexpected result: %d\n%s", code, stderr)
+		}
+
+		expected := "RIFF\x3c\x00\x00\x00WEBP" +
+			"VP8X\x0a\x00\x00\x00\x08\x00\x00\x00\x00\x00\x00\x00\x00\x00" +
+			"EXIF\x05\x00\x00\x00Exif!\x00" +
+			"XMP \x03\x00\x00\x00abc\x00" +
+			"VP8L\x04\x00\x00\x00\x2f\x00\x00\x00"
+		if stdout != expected {
+			t.Errorf("unexpected output: %q", stdout)
+		}
+	})
+
+	t.Run("PaddedChunk", func(t *testing.T) {
+		t.Parallel()
+
+		chunk := filepath.Join(t.TempDir(), "exif.chunk")
+		if err := os.WriteFile(chunk, []byte("EXIF\x05\x00\x00\x00Exif!\x00"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		stdout, stderr, code := runCommand(t, "-chunk", "-replace", "EXIF", "testdata/padded.webp", chunk)
+		if code != 0 {
+			t.Fatalf("unexpected result: %d\n%s", code, stderr)
+		}
+
+		original, _ := os.ReadFile("testdata/padded.webp")
+		if stdout != string(original) {
+			t.Errorf("unexpected output: %q", stdout)
+		}
+	})
+
+	t.Run("SameFile", func(t *testing.T) {
+		t.Parallel()
+
+		file := copyTestdata(t, "test.wav")
+		if _, stderr, code := runCommand(t, "-o", file, "-delete", "LIST-INFO", file); code != 1 || !strings.Contains(stderr, "output file must not be RIFF-file") {
+			t.Errorf("unexpected result: %d\n%s", code, stderr)
+		}
+
+		original, _ := os.ReadFile("testdata/test.wav")
+		if got, _ := os.ReadFile(file); !bytes.Equal(got, original) {
+			t.Error("input file is modified")
+		}
+	})
+
+	t.Run("SamePayloadFile", func(t *testing.T) {
+		t.Parallel()
+
+		file := copyTestdata(t, "name.txt")
+		if _, stderr, code := runCommand(t, "-o", file, "-replace", "LIST-INFO/INAM", "testdata/test.wav", file); code != 1 || !strings.Contains(stderr, "output file must not be PAYLOAD-file") {
+			t.Errorf("unexpected result: %d\n%s", code, stderr)
+		}
+
+		original, _ := os.ReadFile("testdata/name.txt")
+		if got, _ := os.ReadFile(file); !bytes.Equal(got, original) {
+			t.Error("payload file is modified")
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/karupanerura/riffbin"
+)
+
+var (
+	dryRun = flag.Bool("n", false, "dry-run: print the fixes without writing")
+	pad    = flag.Bool("pad", false, "pad the partial trailing chunk with zero bytes instead of truncating it")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [options] RIFF-file...\n\nRIFF-file is rewritten in place. Only the size fields of the chunk headers are rewritten, and the file is truncated or extended.\n\nOptions:\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var opts []riffbin.RepairOption
+	if *pad {
+		opts = append(opts, riffbin.WithPartialChunkPadding())
+	}
+
+	failed := false
+	for _, file := range flag.Args() {
+		if err := repair(file, opts); err != nil {
+			log.Printf("%s: %s", err.Error(), file)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// repair repairs the file in place and prints the fixes.
+func repair(file string, opts []riffbin.RepairOption) error {
+	flags := os.O_RDWR
+	if *dryRun {
+		flags = os.O_RDONLY
+	}
+	f, err := os.OpenFile(file, flags, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	plan, err := riffbin.PlanRepair(f, info.Size(), opts...)
+	if err != nil {
+		return err
+	}
+	if !plan.NeedsRepair() {
+		fmt.Printf("%s: ok\n", file)
+		return nil
+	}
+
+	for _, fix := range plan.Fixes {
+		fmt.Printf("%s: %s\n", file, fix)
+	}
+	if plan.Size != plan.NewSize {
+		fmt.Printf("%s: file size %d -> %d\n", file, plan.Size, plan.NewSize)
+	}
+	if *dryRun {
+		return nil
+	}
+
+	if err := plan.Apply(f); err != nil {
+		return err
+	}
+	return f.Close()
+}
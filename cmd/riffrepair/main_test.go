@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/karupanerura/riffbin"
+)
+
+func TestMain(m *testing.M) {
+	// run as the command in the sub-process of runCommand
+	if os.Getenv("RIFFREPAIR_TEST_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runCommand runs the command with args, and returns the output and the exit code.
+func runCommand(t *testing.T, args ...string) (string, int) {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "RIFFREPAIR_TEST_MAIN=1")
+	out, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return string(out), exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return string(out), 0
+}
+
+// copyTestdata copies the file in testdata to the temporary directory to be rewritten in place.
+func copyTestdata(t *testing.T, name string) string {
+	t.Helper()
+
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(file, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestCommand(t *testing.T) {
+	t.Parallel()
+
+	t.Run("NoArgs", func(t *testing.T) {
+		t.Parallel()
+
+		if out, code := runCommand(t); code != 2 || !strings.Contains(out, "Usage:") {
+			t.Errorf("unexpected result: %d\n%s", code, out)
+		}
+	})
+
+	t.Run("Valid", func(t *testing.T) {
+		t.Parallel()
+
+		if out, code := runCommand(t, "testdata/valid.wav"); code != 0 || out != "testdata/valid.wav: ok\n" {
+			t.Errorf("unexpected result: %d\n%s", code, out)
+		}
+	})
+
+	t.Run("Padded", func(t *testing.T) {
+		t.Parallel()
+
+		// the odd-sized LIST-INFO is followed by the pad byte
+		if out, code := runCommand(t, "testdata/padded.wav"); code != 0 || out != "testdata/padded.wav: ok\n" {
+			t.Errorf("unexpected result: %d\n%s", code, out)
+		}
+	})
+
+	t.Run("NotRIFF", func(t *testing.T) {
+		t.Parallel()
+
+		if out, code := runCommand(t, "testdata/notriff.wav", "testdata/valid.wav"); code != 1 || !strings.Contains(out, "invlaid format: testdata/notriff.wav") {
+			t.Errorf("unexpected result: %d\n%s", code, out)
+		}
+	})
+
+	t.Run("DryRun", func(t *testing.T) {
+		t.Parallel()
+
+		file := copyTestdata(t, "unfixed.wav")
+		out, code := runCommand(t, "-n", file)
+		expected := file + ": data@36: size 0 -> 113\n" + file + ": .@0: size 0 -> 149\n"
+		if code != 0 || out != expected {
+			t.Errorf("unexpected result: %d\n%s", code, out)
+		}
+
+		original, _ := os.ReadFile("testdata/unfixed.wav")
+		if got, _ := os.ReadFile(file); !bytes.Equal(got, original) {
+			t.Error("file is modified by dry-run")
+		}
+	})
+
+	t.Run("InPlace", func(t *testing.T) {
+		t.Parallel()
+
+		file := copyTestdata(t, "unfixed.wav")
+		if out, code := runCommand(t, file); code != 0 {
+			t.Fatalf("unexpected result: %d\n%s", code, out)
+		}
+
+		b, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		riffChunk, err := riffbin.ReadFull(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the payload like the chunk header is kept in the data chunk
+		if data := riffChunk.Payload[1].(*riffbin.OnMemorySubChunk); len(b) != 157 || len(data.Payload) != 113 || !bytes.HasPrefix(data.Payload, []byte("abcd")) {
+			t.Errorf("unexpected data: %d bytes in %d bytes", len(data.Payload), len(b))
+		}
+
+		if out, code := runCommand(t, file); code != 0 || out != file+": ok\n" {
+			t.Errorf("unexpected result on the repaired file: %d\n%s", code, out)
+		}
+	})
+}
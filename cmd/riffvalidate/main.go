@@ -0,0 +1,101 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/karupanerura/riffbin"
+)
+
+var (
+	profileName = flag.String("profile", "auto", "profile to validate with: auto, none, wave, avi or webp (auto selects the profile by the form type)")
+	strict      = flag.Bool("strict", false, "exit non-zero on warnings too")
+	quiet       = flag.Bool("q", false, "print the errors only")
+)
+
+var profiles = map[string]*riffbin.Profile{
+	"none": nil,
+	"wave": riffbin.ProfileWAVE,
+	"avi":  riffbin.ProfileAVI,
+	"webp": riffbin.ProfileWebP,
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [options] RIFF-file...\n\nOptions:\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	name := strings.ToLower(*profileName)
+	if _, ok := profiles[name]; !ok && name != "auto" {
+		fmt.Fprintf(os.Stderr, "unknown profile: %s\n", *profileName)
+		os.Exit(2)
+	}
+
+	failed := false
+	for _, file := range flag.Args() {
+		if !validate(file, name) {
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// validate validates the file and prints the findings. It returns false if the file fails.
+func validate(file, profileName string) bool {
+	f, err := os.Open(file)
+	if err != nil {
+		fmt.Printf("%s: error: %s\n", file, err.Error())
+		return false
+	}
+	defer f.Close()
+
+	locs, riffChunk, err := read(f)
+	if err != nil {
+		fmt.Printf("%s: error: .: %s\n", file, err.Error())
+		return false
+	}
+
+	profile := profiles[profileName]
+	if profileName == "auto" {
+		profile = riffbin.LookupProfile(riffChunk.FormType)
+	}
+
+	ok := true
+	for _, finding := range riffbin.ValidateWithLocations(riffChunk, profile, locs) {
+		if finding.Severity == riffbin.SeverityError || *strict {
+			ok = false
+		}
+		if finding.Severity == riffbin.SeverityError || !*quiet {
+			fmt.Printf("%s: %s\n", file, finding)
+		}
+	}
+	return ok
+}
+
+// read reads the file with the pad bytes, and reads it again without them if it fails.
+// The missing pad bytes are reported by the validator with the locations.
+func read(f *os.File) (*riffbin.ChunkLocations, *riffbin.RIFFChunk, error) {
+	locs := &riffbin.ChunkLocations{}
+	riffChunk, err := riffbin.ReadSections(f, riffbin.WithReadPadding(), riffbin.WithChunkLocations(locs))
+	if err == nil {
+		return locs, riffChunk, nil
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, nil, err
+	}
+	locs = &riffbin.ChunkLocations{}
+	riffChunk, err = riffbin.ReadSections(f, riffbin.WithChunkLocations(locs))
+	return locs, riffChunk, err
+}
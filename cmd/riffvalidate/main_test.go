@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// run as the command in the sub-process of runCommand
+	if os.Getenv("RIFFVALIDATE_TEST_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runCommand runs the command with args, and returns the output and the exit code.
+func runCommand(t *testing.T, args ...string) (string, int) {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "RIFFVALIDATE_TEST_MAIN=1")
+	out, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return string(out), exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return string(out), 0
+}
+
+func TestCommand(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		args     []string
+		code     int
+		contains string
+	}{
+		{name: "NoArgs", code: 2, contains: "Usage:"},
+		{name: "UnknownProfile", args: []string{"-profile", "foo", "testdata/valid.wav"}, code: 2, contains: "unknown profile: foo"},
+		{name: "Valid", args: []string{"testdata/valid.wav"}, code: 0},
+		{name: "Padded", args: []string{"testdata/padded.webp"}, code: 0},
+		{name: "Unpadded", args: []string{"testdata/unpadded.webp"}, code: 1, contains: "testdata/unpadded.webp: error: VP8 : odd size 3 without pad byte"},
+		{name: "Truncated", args: []string{"testdata/valid.wav", "testdata/truncated.wav"}, code: 1, contains: "testdata/truncated.wav: error: .: invlaid format"},
+		{name: "NotFound", args: []string{"testdata/notfound.wav"}, code: 1, contains: "testdata/notfound.wav: error:"},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			out, code := runCommand(t, tc.args...)
+			if code != tc.code {
+				t.Errorf("unexpected exit code: %d (expected: %d)\n%s", code, tc.code, out)
+			}
+			if !strings.Contains(out, tc.contains) {
+				t.Errorf("unexpected output: %s", out)
+			}
+			if tc.contains == "" && out != "" {
+				t.Errorf("unexpected output: %s", out)
+			}
+		})
+	}
+}
@@ -0,0 +1,338 @@
+package riffbin
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+var (
+	adtlType = [typeBytes]byte{'a', 'd', 't', 'l'}
+	lablID   = [idBytes]byte{'l', 'a', 'b', 'l'}
+	noteID   = [idBytes]byte{'n', 'o', 't', 'e'}
+	ltxtID   = [idBytes]byte{'l', 't', 'x', 't'}
+)
+
+const (
+	// cuePointBytes is the size of a cue point in the cue chunk.
+	cuePointBytes = 24
+
+	// ltxtBytes is the size of the fixed fields of the ltxt chunk.
+	ltxtBytes = 20
+)
+
+// CuePoint is a marker or a region of WAVE in the cue chunk and the associated data list. (LIST-adtl)
+type CuePoint struct {
+	// ID is the unique identifier of the cue point referenced by the associated data.
+	ID uint32
+
+	// Position is the sample frame offset in the data chunk. (dwSampleOffset)
+	Position uint32
+
+	// PlayPosition, ChunkID, ChunkStart and BlockStart are dwPosition, fccChunk, dwChunkStart and dwBlockStart of the cue point.
+	// They are read as is and written back unchanged. The zero ChunkID is written as the data chunk with PlayPosition of Position.
+	PlayPosition uint32
+	ChunkID      [4]byte
+	ChunkStart   uint32
+	BlockStart   uint32
+
+	// Label is the text of the labl chunk.
+	Label string
+
+	// Note is the text of the note chunk.
+	Note string
+
+	// Region is the ltxt chunk if the cue point is a region, or nil if it is a marker.
+	Region *CueRegion
+}
+
+// CueRegion is the labeled text chunk (ltxt) to define the region starting at the cue point.
+type CueRegion struct {
+	// Length is the number of the sample frames in the region.
+	Length uint32
+
+	// Purpose is the purpose of the text. (e.g. "rgn ")
+	Purpose [4]byte
+
+	Country  uint16
+	Language uint16
+	Dialect  uint16
+	CodePage uint16
+
+	// Text is the text of the region.
+	Text string
+}
+
+// CueList is the list of the cue points in the order of the cue chunk.
+type CueList []CuePoint
+
+// Lookup returns the cue point of the ID, or nil if it is not found.
+func (l CueList) Lookup(id uint32) *CuePoint {
+	for i := range l {
+		if l[i].ID == id {
+			return &l[i]
+		}
+	}
+	return nil
+}
+
+// Add appends p to the list with p.ID. (zero is a valid ID)
+func (l *CueList) Add(p CuePoint) error {
+	if l.Lookup(p.ID) != nil {
+		return fmt.Errorf("cue point %d already exists", p.ID)
+	}
+
+	*l = append(*l, p)
+	return nil
+}
+
+// AddAuto appends p to the list with the unused ID instead of p.ID, and returns the ID.
+// The ID is next to the max ID in the list, or the smallest unused ID if the max ID is the limit.
+func (l *CueList) AddAuto(p CuePoint) uint32 {
+	var max uint32
+	for _, q := range *l {
+		if q.ID > max {
+			max = q.ID
+		}
+	}
+	if max < math.MaxUint32 {
+		p.ID = max + 1
+	} else {
+		for p.ID = 0; l.Lookup(p.ID) != nil; p.ID++ {
+		}
+	}
+
+	*l = append(*l, p)
+	return p.ID
+}
+
+// Move moves the cue point of the ID to the sample frame offset. PlayPosition is moved by the same frames.
+func (l CueList) Move(id uint32, position uint32) error {
+	p := l.Lookup(id)
+	if p == nil {
+		return fmt.Errorf("cue point %d is not found", id)
+	}
+	p.PlayPosition += position - p.Position
+	p.Position = position
+	return nil
+}
+
+// Delete deletes the cue point of the ID.
+func (l *CueList) Delete(id uint32) error {
+	for i, p := range *l {
+		if p.ID == id {
+			*l = append((*l)[:i], (*l)[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("cue point %d is not found", id)
+}
+
+// ReadCueList reads the cue points from the first cue chunk and the labels from the first LIST-adtl chunk in the root of the WAVE.
+// The sub-chunk payloads are read with the own cursor, so c can be still written after reading.
+func ReadCueList(c *RIFFChunk) (CueList, error) {
+	if c.FormType != waveType {
+		return nil, fmt.Errorf("form type %q is not WAVE: %w", string(c.FormType[:]), ErrInvalidFormat)
+	}
+
+	cue, adtl := lookupCueChunks(c.Payload)
+	if cue < 0 {
+		return nil, nil
+	}
+
+	b, err := ReadPayload(c.Payload[cue].(SubChunk))
+	if err != nil {
+		return nil, fmt.Errorf("cue: %w", err)
+	}
+	if len(b) < 4 {
+		return nil, fmt.Errorf("cue chunk is too short: %w", ErrInvalidFormat)
+	}
+	count := int64(binary.LittleEndian.Uint32(b))
+	if 4+count*cuePointBytes > int64(len(b)) {
+		return nil, fmt.Errorf("cue chunk is too short for %d points: %w", count, ErrInvalidFormat)
+	}
+
+	l := make(CueList, count)
+	for i := range l {
+		point := b[4+i*cuePointBytes:]
+		l[i].ID = binary.LittleEndian.Uint32(point)
+		l[i].PlayPosition = binary.LittleEndian.Uint32(point[4:])
+		copy(l[i].ChunkID[:], point[8:12])
+		l[i].ChunkStart = binary.LittleEndian.Uint32(point[12:])
+		l[i].BlockStart = binary.LittleEndian.Uint32(point[16:])
+		l[i].Position = binary.LittleEndian.Uint32(point[20:])
+	}
+	if adtl < 0 {
+		return l, nil
+	}
+
+	payload, err := c.Payload[adtl].(*ListChunk).Chunks()
+	if err != nil {
+		return nil, fmt.Errorf("LIST-adtl: %w", err)
+	}
+	for _, chunk := range payload {
+		sc, ok := chunk.(SubChunk)
+		if !ok {
+			continue
+		}
+
+		var id [idBytes]byte
+		copy(id[:], sc.ChunkID())
+		if id != lablID && id != noteID && id != ltxtID {
+			continue
+		}
+
+		b, err := ReadPayload(sc)
+		if err != nil {
+			return nil, fmt.Errorf("LIST-adtl: %s: %w", string(id[:]), err)
+		}
+		if len(b) < 4 || id == ltxtID && len(b) < ltxtBytes {
+			return nil, fmt.Errorf("%s chunk is too short: %w", string(id[:]), ErrInvalidFormat)
+		}
+		p := l.Lookup(binary.LittleEndian.Uint32(b))
+		if p == nil {
+			// dangling label
+			continue
+		}
+
+		switch id {
+		case lablID:
+			p.Label = trimNUL(b[4:])
+		case noteID:
+			p.Note = trimNUL(b[4:])
+		case ltxtID:
+			r := &CueRegion{
+				Length:   binary.LittleEndian.Uint32(b[4:]),
+				Country:  binary.LittleEndian.Uint16(b[12:]),
+				Language: binary.LittleEndian.Uint16(b[14:]),
+				Dialect:  binary.LittleEndian.Uint16(b[16:]),
+				CodePage: binary.LittleEndian.Uint16(b[18:]),
+				Text:     trimNUL(b[ltxtBytes:]),
+			}
+			copy(r.Purpose[:], b[8:12])
+			p.Region = r
+		}
+	}
+	return l, nil
+}
+
+// WriteCueList writes l into the first cue chunk and the first LIST-adtl chunk in the root of the WAVE.
+// The new cue points (with the zero ChunkID) refer to the data chunk of PCM, and the others keep the fields read by ReadCueList. The labl, note and ltxt chunks in LIST-adtl are rewritten, and the other chunks are preserved.
+// The cue chunk and LIST-adtl chunk are removed if they become empty, and they are appended to the root if they do not exist.
+func WriteCueList(c *RIFFChunk, l CueList) error {
+	if c.FormType != waveType {
+		return fmt.Errorf("form type %q is not WAVE: %w", string(c.FormType[:]), ErrInvalidFormat)
+	}
+
+	cue := make([]byte, 4+len(l)*cuePointBytes)
+	binary.LittleEndian.PutUint32(cue, uint32(len(l)))
+	var labels []Chunk
+	for i, p := range l {
+		if l[:i].Lookup(p.ID) != nil {
+			return fmt.Errorf("cue point %d is duplicated", p.ID)
+		}
+
+		point := cue[4+i*cuePointBytes:]
+		binary.LittleEndian.PutUint32(point, p.ID)
+		if p.ChunkID == ([4]byte{}) {
+			binary.LittleEndian.PutUint32(point[4:], p.Position)
+			copy(point[8:], dataID[:])
+		} else {
+			binary.LittleEndian.PutUint32(point[4:], p.PlayPosition)
+			copy(point[8:], p.ChunkID[:])
+			binary.LittleEndian.PutUint32(point[12:], p.ChunkStart)
+			binary.LittleEndian.PutUint32(point[16:], p.BlockStart)
+		}
+		binary.LittleEndian.PutUint32(point[20:], p.Position)
+
+		if p.Label != "" {
+			labels = append(labels, &OnMemorySubChunk{ID: lablID, Payload: cueText(p.ID, nil, p.Label)})
+		}
+		if p.Note != "" {
+			labels = append(labels, &OnMemorySubChunk{ID: noteID, Payload: cueText(p.ID, nil, p.Note)})
+		}
+		if r := p.Region; r != nil {
+			head := make([]byte, ltxtBytes-4)
+			binary.LittleEndian.PutUint32(head, r.Length)
+			copy(head[4:], r.Purpose[:])
+			binary.LittleEndian.PutUint16(head[8:], r.Country)
+			binary.LittleEndian.PutUint16(head[10:], r.Language)
+			binary.LittleEndian.PutUint16(head[12:], r.Dialect)
+			binary.LittleEndian.PutUint16(head[14:], r.CodePage)
+			labels = append(labels, &OnMemorySubChunk{ID: ltxtID, Payload: cueText(p.ID, head, r.Text)})
+		}
+	}
+
+	cueIndex, adtlIndex := lookupCueChunks(c.Payload)
+	if adtlIndex >= 0 {
+		adtl := c.Payload[adtlIndex].(*ListChunk)
+		payload, err := adtl.Chunks()
+		if err != nil {
+			return fmt.Errorf("LIST-adtl: %w", err)
+		}
+
+		kept := make([]Chunk, 0, len(payload)+len(labels))
+		for _, chunk := range payload {
+			if _, ok := chunk.(SubChunk); ok {
+				switch id := chunk.ChunkID(); {
+				case bytes.Equal(id, lablID[:]), bytes.Equal(id, noteID[:]), bytes.Equal(id, ltxtID[:]):
+					continue
+				}
+			}
+			kept = append(kept, chunk)
+		}
+		labels = append(kept, labels...)
+	}
+
+	switch {
+	case adtlIndex >= 0 && len(labels) == 0:
+		c.Payload = append(c.Payload[:adtlIndex], c.Payload[adtlIndex+1:]...)
+	case adtlIndex >= 0:
+		c.Payload[adtlIndex] = &ListChunk{ListType: adtlType, Payload: labels}
+	case len(labels) != 0:
+		c.Payload = append(c.Payload, &ListChunk{ListType: adtlType, Payload: labels})
+	}
+
+	cueIndex, adtlIndex = lookupCueChunks(c.Payload)
+	cueChunk := &OnMemorySubChunk{ID: cueID, Payload: cue}
+	switch {
+	case cueIndex >= 0 && len(l) == 0:
+		c.Payload = append(c.Payload[:cueIndex], c.Payload[cueIndex+1:]...)
+	case cueIndex >= 0:
+		c.Payload[cueIndex] = cueChunk
+	case len(l) != 0:
+		if adtlIndex < 0 {
+			adtlIndex = len(c.Payload)
+		}
+		c.Payload = insertChunk(c.Payload, adtlIndex, cueChunk)
+	}
+	return nil
+}
+
+// lookupCueChunks returns the indexes of the first cue chunk and the first LIST-adtl chunk in payload, or -1 if they are not found.
+func lookupCueChunks(payload []Chunk) (cue int, adtl int) {
+	cue, adtl = -1, -1
+	for i, chunk := range payload {
+		switch cc := chunk.(type) {
+		case *ListChunk:
+			if adtl < 0 && cc.ListType == adtlType {
+				adtl = i
+			}
+		case SubChunk:
+			if cue < 0 && bytes.Equal(cc.ChunkID(), cueID[:]) {
+				cue = i
+			}
+		}
+	}
+	return
+}
+
+// cueText encodes the payload of labl, note or ltxt chunk with the NUL-terminated text.
+func cueText(id uint32, head []byte, text string) []byte {
+	b := make([]byte, 4, 4+len(head)+len(text)+2)
+	binary.LittleEndian.PutUint32(b, id)
+	b = append(b, head...)
+	b = append(b, text...)
+	return padNUL(append(b, 0))
+}
@@ -0,0 +1,188 @@
+package riffbin_test
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/karupanerura/riffbin"
+)
+
+func TestCueList(t *testing.T) {
+	t.Parallel()
+
+	region := &riffbin.CueRegion{Length: 100, Purpose: [4]byte{'r', 'g', 'n', ' '}, Country: 1, Language: 9, CodePage: 437, Text: "chorus"}
+	initial := riffbin.CueList{
+		{ID: 1, Position: 10, Label: "intro"},
+		{ID: 2, Position: 20, Label: "verse", Note: "first take"},
+		{ID: 5, Position: 30, Label: "chorus", Region: region},
+	}
+	unrelated := &riffbin.OnMemorySubChunk{ID: [4]byte{'f', 'i', 'l', 'e'}, Payload: []byte{0x01, 0x00, 0x00, 0x00, 't', 'e', 'x', 't'}}
+
+	// write and read back to parse the chunks as same as files
+	roundTrip := func(t *testing.T, c *riffbin.RIFFChunk) *riffbin.RIFFChunk {
+		t.Helper()
+
+		var buf bytes.Buffer
+		if _, err := riffbin.NewCompletedChunkWriter(&buf).Write(c); err != nil {
+			t.Fatal(err)
+		}
+		riffChunk, err := riffbin.ReadSections(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return riffChunk
+	}
+
+	c := &riffbin.RIFFChunk{FormType: [4]byte{'W', 'A', 'V', 'E'}, Payload: newWaveChunks()}
+	if err := riffbin.WriteCueList(c, initial); err != nil {
+		t.Fatal(err)
+	}
+	if err := riffbin.AppendChunk(c, "LIST-adtl", unrelated); err != nil {
+		t.Fatal(err)
+	}
+	c = roundTrip(t, c)
+
+	l, err := riffbin.ReadCueList(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the new cue points refer to the data chunk
+	data := [4]byte{'d', 'a', 't', 'a'}
+	if df := cmp.Diff(l, riffbin.CueList{
+		{ID: 1, Position: 10, PlayPosition: 10, ChunkID: data, Label: "intro"},
+		{ID: 2, Position: 20, PlayPosition: 20, ChunkID: data, Label: "verse", Note: "first take"},
+		{ID: 5, Position: 30, PlayPosition: 30, ChunkID: data, Label: "chorus", Region: region},
+	}); df != "" {
+		t.Errorf("diff = %s", df)
+	}
+
+	if id := l.AddAuto(riffbin.CuePoint{ID: 1, Position: 40, Label: "outro"}); id != 6 {
+		t.Errorf("unexpected ID: %d", id)
+	}
+	if err := l.Add(riffbin.CuePoint{ID: 0, Position: 0, Label: "head"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Add(riffbin.CuePoint{ID: 1}); err == nil {
+		t.Error("error is expected for the duplicated ID")
+	}
+	if err := l.Move(2, 25); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Delete(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Delete(1); err == nil {
+		t.Error("error is expected for the deleted ID")
+	}
+
+	if err := riffbin.WriteCueList(c, l); err != nil {
+		t.Fatal(err)
+	}
+	c = roundTrip(t, c)
+
+	got, err := riffbin.ReadCueList(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := riffbin.CueList{
+		{ID: 2, Position: 25, PlayPosition: 25, ChunkID: data, Label: "verse", Note: "first take"},
+		{ID: 5, Position: 30, PlayPosition: 30, ChunkID: data, Label: "chorus", Region: region},
+		{ID: 6, Position: 40, PlayPosition: 40, ChunkID: data, Label: "outro"},
+		{ID: 0, Position: 0, PlayPosition: 0, ChunkID: data, Label: "head"},
+	}
+	if df := cmp.Diff(got, expected); df != "" {
+		t.Errorf("diff = %s", df)
+	}
+
+	var names []string
+	for _, chunk := range c.Payload {
+		names = append(names, string(chunk.ChunkID()))
+	}
+	if df := cmp.Diff(names, []string{"fmt ", "data", "cue ", "LIST"}); df != "" {
+		t.Errorf("diff = %s", df)
+	}
+	if _, err := riffbin.LookupChunk(c, "LIST-adtl/file"); err != nil {
+		t.Errorf("unrelated chunk is not preserved: %v", err)
+	}
+	if findings := riffbin.Validate(c, riffbin.ProfileWAVE); len(findings) != 0 {
+		t.Errorf("unexpected findings: %v", findings)
+	}
+
+	// the empty list removes the cue chunk, and keeps LIST-adtl with the unrelated chunk
+	if err := riffbin.WriteCueList(c, nil); err != nil {
+		t.Fatal(err)
+	}
+	names = names[:0]
+	for _, chunk := range c.Payload {
+		names = append(names, string(chunk.ChunkID()))
+	}
+	if df := cmp.Diff(names, []string{"fmt ", "data", "LIST"}); df != "" {
+		t.Errorf("diff = %s", df)
+	}
+}
+
+func TestCueListAddAuto(t *testing.T) {
+	t.Parallel()
+
+	var l riffbin.CueList
+	if id := l.AddAuto(riffbin.CuePoint{}); id != 1 {
+		t.Errorf("unexpected ID: %d", id)
+	}
+
+	// the smallest unused ID is assigned after the limit
+	l = riffbin.CueList{{ID: 0}, {ID: 1}, {ID: 3}, {ID: math.MaxUint32}}
+	if id := l.AddAuto(riffbin.CuePoint{}); id != 2 {
+		t.Errorf("unexpected ID: %d", id)
+	}
+	if len(l) != 5 || l[4].ID != 2 {
+		t.Errorf("unexpected list: %v", l)
+	}
+}
+
+func TestCueListPreserve(t *testing.T) {
+	t.Parallel()
+
+	// the cue points of the playlist with the fields which are not written for the new cue points
+	cue := []byte{
+		0x02, 0x00, 0x00, 0x00,
+		0x01, 0x00, 0x00, 0x00, 0xE8, 0x03, 0x00, 0x00, 's', 'l', 'n', 't', 0x0C, 0x00, 0x00, 0x00, 0x22, 0x00, 0x00, 0x00, 0x0A, 0x00, 0x00, 0x00,
+		0x02, 0x00, 0x00, 0x00, 0xD0, 0x07, 0x00, 0x00, 'd', 'a', 't', 'a', 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x14, 0x00, 0x00, 0x00,
+	}
+	c := &riffbin.RIFFChunk{
+		FormType: [4]byte{'W', 'A', 'V', 'E'},
+		Payload:  append(newWaveChunks(), &riffbin.OnMemorySubChunk{ID: [4]byte{'c', 'u', 'e', ' '}, Payload: cue}),
+	}
+
+	l, err := riffbin.ReadCueList(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := riffbin.CueList{
+		{ID: 1, Position: 10, PlayPosition: 1000, ChunkID: [4]byte{'s', 'l', 'n', 't'}, ChunkStart: 12, BlockStart: 34},
+		{ID: 2, Position: 20, PlayPosition: 2000, ChunkID: [4]byte{'d', 'a', 't', 'a'}, BlockStart: 256},
+	}
+	if df := cmp.Diff(l, expected); df != "" {
+		t.Errorf("diff = %s", df)
+	}
+
+	if err := riffbin.WriteCueList(c, l); err != nil {
+		t.Fatal(err)
+	}
+	chunk, err := riffbin.LookupChunk(c, "cue ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := chunk.(*riffbin.OnMemorySubChunk).Payload; !bytes.Equal(got, cue) {
+		t.Errorf("cue chunk is not preserved: %v", got)
+	}
+
+	// the play position is moved with the position
+	if err := l.Move(2, 25); err != nil {
+		t.Fatal(err)
+	}
+	if p := l.Lookup(2); p.Position != 25 || p.PlayPosition != 2005 || p.BlockStart != 256 {
+		t.Errorf("unexpected cue point: %+v", p)
+	}
+}
@@ -0,0 +1,375 @@
+// Package dls decodes and encodes DLS (Downloadable Sounds) Level 1/2 collections (RIFF DLS) on the riffbin chunk tree.
+// The instruments and the waves are decoded from the LIST chunks, and the pool table offsets into the wave pool are resolved to the wave indices.
+// The wave data is kept as the sub-chunk to stream it without loading on memory.
+package dls
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/karupanerura/riffbin"
+)
+
+var (
+	dlsType  = [4]byte{'D', 'L', 'S', ' '}
+	linsType = [4]byte{'l', 'i', 'n', 's'}
+	wvplType = [4]byte{'w', 'v', 'p', 'l'}
+	waveType = [4]byte{'w', 'a', 'v', 'e'}
+	infoType = [4]byte{'I', 'N', 'F', 'O'}
+	versID   = [4]byte{'v', 'e', 'r', 's'}
+	dlidID   = [4]byte{'d', 'l', 'i', 'd'}
+	colhID   = [4]byte{'c', 'o', 'l', 'h'}
+	ptblID   = [4]byte{'p', 't', 'b', 'l'}
+	fmtID    = [4]byte{'f', 'm', 't', ' '}
+	dataID   = [4]byte{'d', 'a', 't', 'a'}
+)
+
+const (
+	versBytes = 8
+	dlidBytes = 16
+	ptblBytes = 8
+	cueBytes  = 4
+)
+
+// Version is the version of the collection. (vers)
+type Version struct {
+	Major   uint16
+	Minor   uint16
+	Release uint16
+	Build   uint16
+}
+
+// Collection is a DLS collection.
+type Collection struct {
+	// Version is the vers chunk, or nil.
+	Version *Version
+
+	// ID is the dlid chunk, or nil.
+	ID *[dlidBytes]byte
+
+	Instruments []Instrument
+
+	// PoolTable is the indices of Waves for the cues of the pool table. WaveLink.TableIndex is the index of the cue.
+	// It is resolved from the ptbl offsets into the wvpl on decoding, and the offsets are computed from it on encoding.
+	// nil is encoded as the cue of each wave in the same order as Waves.
+	PoolTable []int
+
+	Waves []Wave
+
+	// Info is the sub-chunks of LIST-INFO. (e.g. INAM)
+	Info []riffbin.Chunk
+}
+
+// Wave is a wave in the wave pool. (LIST-wave)
+type Wave struct {
+	// Format is the payload of the fmt chunk. (WAVEFORMATEX)
+	Format []byte
+
+	// Sample is the wsmp chunk, or nil.
+	Sample *WaveSample
+
+	// Data is the data chunk. It is *riffbin.InStreamSubChunk if the collection is read by Read, and it is streamed as it is by WriteTo.
+	Data riffbin.SubChunk
+
+	// Info is the sub-chunks of LIST-INFO. (e.g. INAM)
+	Info []riffbin.Chunk
+}
+
+// Read reads the DLS collection from r by riffbin.ReadSections with the pad bytes.
+func Read(r riffbin.PartialReader) (*Collection, error) {
+	c, err := riffbin.ReadSections(r, riffbin.WithReadPadding())
+	if err != nil {
+		return nil, err
+	}
+	return Decode(c)
+}
+
+// Decode decodes the DLS collection from the chunk tree.
+// The pool table offsets are resolved in the padded layout as the spec.
+// The sub-chunk payloads are read with the own cursor, so c can be still written after decoding.
+func Decode(c *riffbin.RIFFChunk) (*Collection, error) {
+	if c.FormType != dlsType {
+		return nil, fmt.Errorf("form type %q is not DLS: %w", string(c.FormType[:]), riffbin.ErrInvalidFormat)
+	}
+
+	col := &Collection{}
+	var cues []uint32
+	var waveOffsets map[int64]int
+	for _, chunk := range c.Payload {
+		switch cc := chunk.(type) {
+		case *riffbin.ListChunk:
+			chunks, err := cc.Chunks()
+			if err != nil {
+				return nil, err
+			}
+
+			switch cc.ListType {
+			case linsType:
+				for _, ic := range chunks {
+					l, ok := ic.(*riffbin.ListChunk)
+					if !ok || l.ListType != insType {
+						continue
+					}
+					inst, err := decodeInstrument(l)
+					if err != nil {
+						return nil, fmt.Errorf("instrument[%d]: %w", len(col.Instruments), err)
+					}
+					col.Instruments = append(col.Instruments, *inst)
+				}
+			case wvplType:
+				waveOffsets = map[int64]int{}
+				var off int64
+				for _, wc := range chunks {
+					if l, ok := wc.(*riffbin.ListChunk); ok && l.ListType == waveType {
+						wave, err := decodeWave(l)
+						if err != nil {
+							return nil, fmt.Errorf("wave[%d]: %w", len(col.Waves), err)
+						}
+						waveOffsets[off] = len(col.Waves)
+						col.Waves = append(col.Waves, *wave)
+					}
+					off += riffbin.PaddedChunkBytes(wc)
+				}
+			case infoType:
+				col.Info = chunks
+			}
+		case riffbin.SubChunk:
+			var id [4]byte
+			copy(id[:], cc.ChunkID())
+			if id != versID && id != dlidID && id != ptblID {
+				continue
+			}
+			b, err := riffbin.ReadPayload(cc)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", string(id[:]), err)
+			}
+
+			switch id {
+			case versID:
+				if len(b) < versBytes {
+					return nil, fmt.Errorf("vers chunk is too short: %w", riffbin.ErrInvalidFormat)
+				}
+				col.Version = &Version{
+					Major:   binary.LittleEndian.Uint16(b[2:]),
+					Minor:   binary.LittleEndian.Uint16(b[0:]),
+					Release: binary.LittleEndian.Uint16(b[6:]),
+					Build:   binary.LittleEndian.Uint16(b[4:]),
+				}
+			case dlidID:
+				if len(b) < dlidBytes {
+					return nil, fmt.Errorf("dlid chunk is too short: %w", riffbin.ErrInvalidFormat)
+				}
+				col.ID = &[dlidBytes]byte{}
+				copy(col.ID[:], b)
+			case ptblID:
+				if cues, err = decodePoolTable(b); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	// resolve the pool table offsets into the wave indices
+	col.PoolTable = make([]int, 0, len(cues))
+	for i, off := range cues {
+		index, ok := waveOffsets[int64(off)]
+		if !ok {
+			return nil, fmt.Errorf("ptbl cue[%d] offset %d is not a wave in wvpl: %w", i, off, riffbin.ErrInvalidFormat)
+		}
+		col.PoolTable = append(col.PoolTable, index)
+	}
+	return col, nil
+}
+
+func decodePoolTable(b []byte) ([]uint32, error) {
+	if len(b) < ptblBytes {
+		return nil, fmt.Errorf("ptbl chunk is too short: %w", riffbin.ErrInvalidFormat)
+	}
+
+	size := binary.LittleEndian.Uint32(b[0:])
+	n := binary.LittleEndian.Uint32(b[4:])
+	if uint64(size)+uint64(n)*cueBytes > uint64(len(b)) {
+		return nil, fmt.Errorf("ptbl cues are out of the chunk: %w", riffbin.ErrInvalidFormat)
+	}
+
+	cues := make([]uint32, 0, n)
+	for i := uint32(0); i < n; i++ {
+		cues = append(cues, binary.LittleEndian.Uint32(b[size+i*cueBytes:]))
+	}
+	return cues, nil
+}
+
+func decodeWave(l *riffbin.ListChunk) (*Wave, error) {
+	chunks, err := l.Chunks()
+	if err != nil {
+		return nil, err
+	}
+
+	wave := &Wave{}
+	for _, c := range chunks {
+		switch cc := c.(type) {
+		case *riffbin.ListChunk:
+			if cc.ListType == infoType {
+				if wave.Info, err = cc.Chunks(); err != nil {
+					return nil, err
+				}
+			}
+		case riffbin.SubChunk:
+			var id [4]byte
+			copy(id[:], cc.ChunkID())
+			switch id {
+			case fmtID:
+				if wave.Format, err = riffbin.ReadPayload(cc); err != nil {
+					return nil, fmt.Errorf("fmt: %w", err)
+				}
+			case wsmpID:
+				b, err := riffbin.ReadPayload(cc)
+				if err != nil {
+					return nil, fmt.Errorf("wsmp: %w", err)
+				}
+				if wave.Sample, err = decodeWaveSample(b); err != nil {
+					return nil, err
+				}
+			case dataID:
+				wave.Data = cc
+			}
+		}
+	}
+	if wave.Format == nil {
+		return nil, fmt.Errorf("fmt chunk is not found: %w", riffbin.ErrInvalidFormat)
+	}
+	if wave.Data == nil {
+		return nil, fmt.Errorf("data chunk is not found: %w", riffbin.ErrInvalidFormat)
+	}
+	return wave, nil
+}
+
+// Wave returns the wave linked from the region by the pool table.
+func (col *Collection) Wave(link WaveLink) (*Wave, error) {
+	index := int(link.TableIndex)
+	if col.PoolTable != nil {
+		if index >= len(col.PoolTable) {
+			return nil, fmt.Errorf("table index %d is out of the pool table", link.TableIndex)
+		}
+		index = col.PoolTable[index]
+	}
+	if index < 0 || index >= len(col.Waves) {
+		return nil, fmt.Errorf("wave index %d is out of range", index)
+	}
+	return &col.Waves[index], nil
+}
+
+// Encode encodes the collection into the chunk tree.
+// The wave data sub-chunks are shared with col by the own cursor if they are *riffbin.InStreamSubChunk or *riffbin.OnMemorySubChunk.
+func (col *Collection) Encode() (*riffbin.RIFFChunk, error) {
+	var payload []riffbin.Chunk
+	if v := col.Version; v != nil {
+		b := appendUint16(nil, v.Minor)
+		b = appendUint16(b, v.Major)
+		b = appendUint16(b, v.Build)
+		b = appendUint16(b, v.Release)
+		payload = append(payload, &riffbin.OnMemorySubChunk{ID: versID, Payload: b})
+	}
+	if col.ID != nil {
+		payload = append(payload, &riffbin.OnMemorySubChunk{ID: dlidID, Payload: append([]byte(nil), col.ID[:]...)})
+	}
+
+	instruments := make([]riffbin.Chunk, 0, len(col.Instruments))
+	for i := range col.Instruments {
+		instruments = append(instruments, col.Instruments[i].encode())
+	}
+
+	// the offsets of the waves from the head of the wvpl payload
+	waves := make([]riffbin.Chunk, 0, len(col.Waves))
+	offsets := make([]int64, 0, len(col.Waves))
+	var off int64
+	for i := range col.Waves {
+		wc, err := col.Waves[i].encode()
+		if err != nil {
+			return nil, fmt.Errorf("wave[%d]: %w", i, err)
+		}
+		waves = append(waves, wc)
+		offsets = append(offsets, off)
+		off += riffbin.PaddedChunkBytes(wc)
+	}
+
+	poolTable := col.PoolTable
+	if poolTable == nil {
+		poolTable = make([]int, len(col.Waves))
+		for i := range poolTable {
+			poolTable[i] = i
+		}
+	}
+	ptbl := appendUint32(nil, ptblBytes)
+	ptbl = appendUint32(ptbl, uint32(len(poolTable)))
+	for i, index := range poolTable {
+		if index < 0 || index >= len(offsets) {
+			return nil, fmt.Errorf("pool table[%d] wave index %d is out of range", i, index)
+		}
+		if offsets[index] > math.MaxUint32 {
+			return nil, fmt.Errorf("pool table[%d] wave offset %d exceeds the limit", i, offsets[index])
+		}
+		ptbl = appendUint32(ptbl, uint32(offsets[index]))
+	}
+
+	payload = append(payload,
+		&riffbin.OnMemorySubChunk{ID: colhID, Payload: appendUint32(nil, uint32(len(col.Instruments)))},
+		&riffbin.ListChunk{ListType: linsType, Payload: instruments},
+		&riffbin.OnMemorySubChunk{ID: ptblID, Payload: ptbl},
+		&riffbin.ListChunk{ListType: wvplType, Payload: waves},
+	)
+	if len(col.Info) != 0 {
+		payload = append(payload, &riffbin.ListChunk{ListType: infoType, Payload: col.Info})
+	}
+	return &riffbin.RIFFChunk{FormType: dlsType, Payload: payload}, nil
+}
+
+func (w *Wave) encode() (*riffbin.ListChunk, error) {
+	if w.Data == nil {
+		return nil, fmt.Errorf("data chunk is not found: %w", riffbin.ErrInvalidFormat)
+	}
+
+	payload := []riffbin.Chunk{&riffbin.OnMemorySubChunk{ID: fmtID, Payload: w.Format}}
+	if w.Sample != nil {
+		payload = append(payload, w.Sample.encode())
+	}
+	payload = append(payload, dataChunk(w.Data))
+	if len(w.Info) != 0 {
+		payload = append(payload, &riffbin.ListChunk{ListType: infoType, Payload: w.Info})
+	}
+	return &riffbin.ListChunk{ListType: waveType, Payload: payload}, nil
+}
+
+// WriteTo writes the collection to w by riffbin.CompletedChunkWriter with the pad bytes.
+func (col *Collection) WriteTo(w io.Writer) (int64, error) {
+	c, err := col.Encode()
+	if err != nil {
+		return 0, err
+	}
+	return riffbin.NewCompletedChunkWriter(w, riffbin.WithWritePadding()).Write(c)
+}
+
+// dataChunk returns the sub-chunk to write the wave data.
+func dataChunk(c riffbin.SubChunk) riffbin.SubChunk {
+	switch cc := c.(type) {
+	case *riffbin.InStreamSubChunk:
+		return &riffbin.InStreamSubChunk{ID: dataID, SectionReader: io.NewSectionReader(cc.SectionReader, 0, cc.Size())}
+	case *riffbin.OnMemorySubChunk:
+		return &riffbin.OnMemorySubChunk{ID: dataID, Payload: cc.Payload}
+	}
+	return c
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	var buf [2]byte
+	binary.LittleEndian.PutUint16(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	return append(b, buf[:]...)
+}
@@ -0,0 +1,237 @@
+package dls_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/karupanerura/riffbin"
+	"github.com/karupanerura/riffbin/dls"
+)
+
+func newCollection() *dls.Collection {
+	format := []byte{0x01, 0x00, 0x01, 0x00, 0x44, 0xAC, 0x00, 0x00, 0x88, 0x58, 0x01, 0x00, 0x02, 0x00, 0x10, 0x00}
+	return &dls.Collection{
+		Version: &dls.Version{Major: 1, Minor: 2, Release: 3, Build: 4},
+		Instruments: []dls.Instrument{
+			{
+				Bank:    0,
+				Program: 1,
+				Regions: []dls.Region{
+					{KeyLow: 0, KeyHigh: 59, VelocityHigh: 127, Link: dls.WaveLink{Channel: 1, TableIndex: 0}},
+					{
+						Level2: true, KeyLow: 60, KeyHigh: 127, VelocityHigh: 127, Layer: 1,
+						Sample: &dls.WaveSample{UnityNote: 72, FineTune: -5, Loops: []dls.Loop{{Start: 2, Length: 4}}},
+						Link:   dls.WaveLink{Channel: 1, TableIndex: 1},
+						Articulators: []dls.Articulator{
+							{Level2: true, Connections: []dls.Connection{{Destination: 0x0206, Scale: -1000}}},
+						},
+					},
+				},
+				Articulators: []dls.Articulator{
+					{Connections: []dls.Connection{{Source: 1, Destination: 0x0001, Transform: 0, Scale: 65536}}},
+				},
+				Info: []riffbin.Chunk{&riffbin.OnMemorySubChunk{ID: [4]byte{'I', 'N', 'A', 'M'}, Payload: []byte("Piano\x00")}},
+			},
+			{Bank: dls.DrumsFlag, Program: 0},
+		},
+		// the pool table refers the waves in the reversed order
+		PoolTable: []int{1, 0},
+		Waves: []dls.Wave{
+			{Format: format, Data: &riffbin.OnMemorySubChunk{ID: [4]byte{'d', 'a', 't', 'a'}, Payload: []byte{0x00, 0x01, 0x02, 0x03}}},
+			{
+				Format: format,
+				Sample: &dls.WaveSample{UnityNote: 60},
+				Data:   &riffbin.OnMemorySubChunk{ID: [4]byte{'d', 'a', 't', 'a'}, Payload: []byte{0x10, 0x11, 0x12, 0x13, 0x14, 0x15}},
+				Info:   []riffbin.Chunk{&riffbin.OnMemorySubChunk{ID: [4]byte{'I', 'N', 'A', 'M'}, Payload: []byte("hi\x00\x00")}},
+			},
+		},
+	}
+}
+
+func TestCollection(t *testing.T) {
+	t.Parallel()
+
+	col := newCollection()
+	c, err := col.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := riffbin.NewCompletedChunkWriter(&buf).Write(c); err != nil {
+		t.Fatal(err)
+	}
+
+	// the cues of the pool table point the LIST headers of the waves from the head of the wvpl payload
+	wvpl, err := riffbin.LookupChunk(c, "LIST-wvpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := wvpl.(*riffbin.ListChunk).Payload[0]
+	ptbl, err := riffbin.LookupChunk(c, "ptbl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cues := ptbl.(*riffbin.OnMemorySubChunk).Payload
+	if got := binary.LittleEndian.Uint32(cues[8:]); got != riffbin.HeaderBytes+first.BodySize() {
+		t.Errorf("unexpected cue[0]: %d", got)
+	}
+	if got := binary.LittleEndian.Uint32(cues[12:]); got != 0 {
+		t.Errorf("unexpected cue[1]: %d", got)
+	}
+
+	got, err := dls.Read(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := []cmp.Option{
+		cmpopts.IgnoreFields(dls.Wave{}, "Data"),
+		cmp.Transformer("payload", func(c riffbin.Chunk) []byte {
+			b, _ := io.ReadAll(io.NewSectionReader(c.(*riffbin.InStreamSubChunk), 0, int64(c.BodySize())))
+			return b
+		}),
+	}
+	expected := newCollection()
+	for _, info := range [][]riffbin.Chunk{expected.Instruments[0].Info, expected.Waves[1].Info} {
+		for i, c := range info {
+			info[i] = &riffbin.InStreamSubChunk{SectionReader: io.NewSectionReader(bytes.NewReader(c.(*riffbin.OnMemorySubChunk).Payload), 0, int64(c.BodySize()))}
+		}
+	}
+	if df := cmp.Diff(got, expected, opts...); df != "" {
+		t.Errorf("diff = %s", df)
+	}
+
+	wave, err := got.Wave(got.Instruments[0].Regions[0].Link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(wave.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, []byte{0x10, 0x11, 0x12, 0x13, 0x14, 0x15}) {
+		t.Errorf("unexpected data: %v", data)
+	}
+	if _, err := got.Wave(dls.WaveLink{TableIndex: 2}); err == nil {
+		t.Error("error is expected for the out of range table index")
+	}
+}
+
+func TestCollectionPadded(t *testing.T) {
+	t.Parallel()
+
+	// the odd-sized data chunk of the first wave is followed by the pad byte
+	col := newCollection()
+	col.Waves[0].Data = &riffbin.OnMemorySubChunk{ID: [4]byte{'d', 'a', 't', 'a'}, Payload: []byte{0x00, 0x01, 0x02}}
+	var buf bytes.Buffer
+	if _, err := col.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	locs := &riffbin.ChunkLocations{}
+	c, err := riffbin.ReadSections(bytes.NewReader(buf.Bytes()), riffbin.WithReadPadding(), riffbin.WithChunkLocations(locs))
+	if err != nil {
+		t.Fatalf("written collection is not padded: %v", err)
+	}
+	wvpl, err := riffbin.LookupChunk(c, "LIST-wvpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wvplLoc, _ := locs.Lookup(wvpl)
+	secondLoc, _ := locs.Lookup(wvpl.(*riffbin.ListChunk).Payload[1])
+	ptbl, err := riffbin.LookupChunk(c, "ptbl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cues, err := riffbin.ReadPayload(ptbl.(riffbin.SubChunk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, expected := int64(binary.LittleEndian.Uint32(cues[8:])), secondLoc.HeaderOffset-wvplLoc.BodyOffset-4; got != expected {
+		t.Errorf("unexpected cue[0]: %d (expected: %d)", got, expected)
+	}
+
+	got, err := dls.Read(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, expected := range [][]byte{{0x10, 0x11, 0x12, 0x13, 0x14, 0x15}, {0x00, 0x01, 0x02}} {
+		wave, err := got.Wave(dls.WaveLink{TableIndex: uint32(i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data, err := riffbin.ReadPayload(wave.Data); err != nil || !bytes.Equal(data, expected) {
+			t.Errorf("unexpected data of table[%d]: %v, %v", i, data, err)
+		}
+	}
+}
+
+func TestCollectionInRMID(t *testing.T) {
+	t.Parallel()
+
+	rmid, err := riffbin.WrapRMID([]byte("MThd\x00\x00\x00\x06\x00\x00\x00\x00\x01\xe0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := newCollection().Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rmid.Payload = append(rmid.Payload, c)
+
+	var buf bytes.Buffer
+	if _, err := riffbin.NewCompletedChunkWriter(&buf).Write(rmid); err != nil {
+		t.Fatal(err)
+	}
+	got, err := riffbin.ReadSections(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	col, err := dls.Decode(got.Payload[1].(*riffbin.RIFFChunk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(col.Instruments) != 2 || len(col.Waves) != 2 {
+		t.Errorf("unexpected collection: %+v", col)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	t.Parallel()
+
+	valid, err := newCollection().Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	brokenPoolTable := *valid
+	brokenPoolTable.Payload = append([]riffbin.Chunk(nil), valid.Payload...)
+	for i, p := range brokenPoolTable.Payload {
+		if string(p.ChunkID()) == "ptbl" {
+			brokenPoolTable.Payload[i] = &riffbin.OnMemorySubChunk{ID: [4]byte{'p', 't', 'b', 'l'}, Payload: []byte{8, 0, 0, 0, 1, 0, 0, 0, 1, 0, 0, 0}}
+		}
+	}
+
+	for name, c := range map[string]*riffbin.RIFFChunk{
+		"NotDLS":          {FormType: [4]byte{'W', 'A', 'V', 'E'}},
+		"BrokenPoolTable": &brokenPoolTable,
+		"NoRegionHeader": {FormType: valid.FormType, Payload: []riffbin.Chunk{
+			&riffbin.ListChunk{ListType: [4]byte{'l', 'i', 'n', 's'}, Payload: []riffbin.Chunk{
+				&riffbin.ListChunk{ListType: [4]byte{'i', 'n', 's', ' '}, Payload: []riffbin.Chunk{
+					&riffbin.OnMemorySubChunk{ID: [4]byte{'i', 'n', 's', 'h'}, Payload: make([]byte, 12)},
+					&riffbin.ListChunk{ListType: [4]byte{'l', 'r', 'g', 'n'}, Payload: []riffbin.Chunk{
+						&riffbin.ListChunk{ListType: [4]byte{'r', 'g', 'n', ' '}, Payload: []riffbin.Chunk{}},
+					}},
+				}},
+			}},
+		}},
+	} {
+		if _, err := dls.Decode(c); !errors.Is(err, riffbin.ErrInvalidFormat) {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,422 @@
+package dls
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/karupanerura/riffbin"
+)
+
+var (
+	insType  = [4]byte{'i', 'n', 's', ' '}
+	lrgnType = [4]byte{'l', 'r', 'g', 'n'}
+	rgnType  = [4]byte{'r', 'g', 'n', ' '}
+	rgn2Type = [4]byte{'r', 'g', 'n', '2'}
+	lartType = [4]byte{'l', 'a', 'r', 't'}
+	lar2Type = [4]byte{'l', 'a', 'r', '2'}
+	inshID   = [4]byte{'i', 'n', 's', 'h'}
+	rgnhID   = [4]byte{'r', 'g', 'n', 'h'}
+	wsmpID   = [4]byte{'w', 's', 'm', 'p'}
+	wlnkID   = [4]byte{'w', 'l', 'n', 'k'}
+	art1ID   = [4]byte{'a', 'r', 't', '1'}
+	art2ID   = [4]byte{'a', 'r', 't', '2'}
+)
+
+const (
+	inshBytes       = 12
+	rgnhBytes       = 12
+	rgnh2Bytes      = 14
+	wsmpBytes       = 20
+	loopBytes       = 16
+	wlnkBytes       = 12
+	artBytes        = 8
+	connectionBytes = 12
+)
+
+// DrumsFlag is the flag of Instrument.Bank for the drum instruments. (F_INSTRUMENT_DRUMS)
+const DrumsFlag uint32 = 0x80000000
+
+// Instrument is an instrument of the collection. (LIST-ins)
+type Instrument struct {
+	// Bank is the MIDI bank of the instrument. (ulBank: CC#0 in bits 8-14, CC#32 in bits 0-6, and DrumsFlag)
+	Bank uint32
+
+	// Program is the MIDI program number. (ulInstrument)
+	Program uint32
+
+	Regions []Region
+
+	// Articulators is the global articulators of the instrument. (LIST-lart, LIST-lar2)
+	Articulators []Articulator
+
+	// Info is the sub-chunks of LIST-INFO. (e.g. INAM)
+	Info []riffbin.Chunk
+}
+
+// Region is a region of the instrument. (LIST-rgn, LIST-rgn2)
+type Region struct {
+	// Level2 is true for LIST-rgn2 of DLS Level 2.
+	Level2 bool
+
+	KeyLow       uint16
+	KeyHigh      uint16
+	VelocityLow  uint16
+	VelocityHigh uint16
+	Options      uint16
+	KeyGroup     uint16
+
+	// Layer is the editing layer of DLS Level 2. It is written only for the Level 2 regions.
+	Layer uint16
+
+	// Sample overrides the wsmp of the wave, or nil.
+	Sample *WaveSample
+
+	Link         WaveLink
+	Articulators []Articulator
+}
+
+// WaveSample is the playback parameters of the wave. (wsmp)
+type WaveSample struct {
+	UnityNote uint16
+	FineTune  int16
+	Gain      int32
+	Options   uint32
+	Loops     []Loop
+}
+
+// Loop is a loop of the wave sample. (WLOOP)
+type Loop struct {
+	Type   uint32
+	Start  uint32
+	Length uint32
+}
+
+// WaveLink is the link from the region to the wave. (wlnk)
+type WaveLink struct {
+	Options    uint16
+	PhaseGroup uint16
+	Channel    uint32
+
+	// TableIndex is the index of the cue in the pool table. (see Collection.PoolTable)
+	TableIndex uint32
+}
+
+// Articulator is an articulator. (art1, art2)
+type Articulator struct {
+	// Level2 is true for art2 of DLS Level 2. It is written in LIST-lar2, or LIST-lart for the others.
+	Level2 bool
+
+	Connections []Connection
+}
+
+// Connection is a connection block of the articulator.
+type Connection struct {
+	Source      uint16
+	Control     uint16
+	Destination uint16
+	Transform   uint16
+	Scale       int32
+}
+
+func decodeInstrument(l *riffbin.ListChunk) (*Instrument, error) {
+	chunks, err := l.Chunks()
+	if err != nil {
+		return nil, err
+	}
+
+	inst := &Instrument{}
+	found := false
+	for _, c := range chunks {
+		switch cc := c.(type) {
+		case *riffbin.ListChunk:
+			switch cc.ListType {
+			case lrgnType:
+				regions, err := cc.Chunks()
+				if err != nil {
+					return nil, err
+				}
+				for _, r := range regions {
+					rl, ok := r.(*riffbin.ListChunk)
+					if !ok || (rl.ListType != rgnType && rl.ListType != rgn2Type) {
+						continue
+					}
+					region, err := decodeRegion(rl)
+					if err != nil {
+						return nil, fmt.Errorf("region[%d]: %w", len(inst.Regions), err)
+					}
+					inst.Regions = append(inst.Regions, *region)
+				}
+			case lartType, lar2Type:
+				arts, err := decodeArticulators(cc)
+				if err != nil {
+					return nil, err
+				}
+				inst.Articulators = append(inst.Articulators, arts...)
+			case infoType:
+				if inst.Info, err = cc.Chunks(); err != nil {
+					return nil, err
+				}
+			}
+		case riffbin.SubChunk:
+			if string(cc.ChunkID()) != string(inshID[:]) {
+				continue
+			}
+			b, err := riffbin.ReadPayload(cc)
+			if err != nil {
+				return nil, fmt.Errorf("insh: %w", err)
+			}
+			if len(b) < inshBytes {
+				return nil, fmt.Errorf("insh chunk is too short: %w", riffbin.ErrInvalidFormat)
+			}
+			inst.Bank = binary.LittleEndian.Uint32(b[4:])
+			inst.Program = binary.LittleEndian.Uint32(b[8:])
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("insh chunk is not found: %w", riffbin.ErrInvalidFormat)
+	}
+	return inst, nil
+}
+
+func decodeRegion(l *riffbin.ListChunk) (*Region, error) {
+	chunks, err := l.Chunks()
+	if err != nil {
+		return nil, err
+	}
+
+	region := &Region{Level2: l.ListType == rgn2Type}
+	var foundHeader, foundLink bool
+	for _, c := range chunks {
+		switch cc := c.(type) {
+		case *riffbin.ListChunk:
+			if cc.ListType != lartType && cc.ListType != lar2Type {
+				continue
+			}
+			arts, err := decodeArticulators(cc)
+			if err != nil {
+				return nil, err
+			}
+			region.Articulators = append(region.Articulators, arts...)
+		case riffbin.SubChunk:
+			var id [4]byte
+			copy(id[:], cc.ChunkID())
+			if id != rgnhID && id != wsmpID && id != wlnkID {
+				continue
+			}
+			b, err := riffbin.ReadPayload(cc)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", string(id[:]), err)
+			}
+
+			switch id {
+			case rgnhID:
+				if len(b) < rgnhBytes {
+					return nil, fmt.Errorf("rgnh chunk is too short: %w", riffbin.ErrInvalidFormat)
+				}
+				region.KeyLow = binary.LittleEndian.Uint16(b[0:])
+				region.KeyHigh = binary.LittleEndian.Uint16(b[2:])
+				region.VelocityLow = binary.LittleEndian.Uint16(b[4:])
+				region.VelocityHigh = binary.LittleEndian.Uint16(b[6:])
+				region.Options = binary.LittleEndian.Uint16(b[8:])
+				region.KeyGroup = binary.LittleEndian.Uint16(b[10:])
+				if len(b) >= rgnh2Bytes {
+					region.Layer = binary.LittleEndian.Uint16(b[12:])
+				}
+				foundHeader = true
+			case wsmpID:
+				if region.Sample, err = decodeWaveSample(b); err != nil {
+					return nil, err
+				}
+			case wlnkID:
+				if len(b) < wlnkBytes {
+					return nil, fmt.Errorf("wlnk chunk is too short: %w", riffbin.ErrInvalidFormat)
+				}
+				region.Link = WaveLink{
+					Options:    binary.LittleEndian.Uint16(b[0:]),
+					PhaseGroup: binary.LittleEndian.Uint16(b[2:]),
+					Channel:    binary.LittleEndian.Uint32(b[4:]),
+					TableIndex: binary.LittleEndian.Uint32(b[8:]),
+				}
+				foundLink = true
+			}
+		}
+	}
+	if !foundHeader {
+		return nil, fmt.Errorf("rgnh chunk is not found: %w", riffbin.ErrInvalidFormat)
+	}
+	if !foundLink {
+		return nil, fmt.Errorf("wlnk chunk is not found: %w", riffbin.ErrInvalidFormat)
+	}
+	return region, nil
+}
+
+func decodeArticulators(l *riffbin.ListChunk) ([]Articulator, error) {
+	chunks, err := l.Chunks()
+	if err != nil {
+		return nil, err
+	}
+
+	var arts []Articulator
+	for _, chunk := range chunks {
+		sc, ok := chunk.(riffbin.SubChunk)
+		if !ok {
+			continue
+		}
+		var id [4]byte
+		copy(id[:], sc.ChunkID())
+		if id != art1ID && id != art2ID {
+			continue
+		}
+		b, err := riffbin.ReadPayload(sc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", string(id[:]), err)
+		}
+		if len(b) < artBytes {
+			return nil, fmt.Errorf("%s chunk is too short: %w", string(id[:]), riffbin.ErrInvalidFormat)
+		}
+
+		size := binary.LittleEndian.Uint32(b[0:])
+		n := binary.LittleEndian.Uint32(b[4:])
+		if uint64(size)+uint64(n)*connectionBytes > uint64(len(b)) {
+			return nil, fmt.Errorf("%s connection blocks are out of the chunk: %w", string(id[:]), riffbin.ErrInvalidFormat)
+		}
+
+		art := Articulator{Level2: id == art2ID}
+		for i := uint32(0); i < n; i++ {
+			r := b[size+i*connectionBytes:]
+			art.Connections = append(art.Connections, Connection{
+				Source:      binary.LittleEndian.Uint16(r[0:]),
+				Control:     binary.LittleEndian.Uint16(r[2:]),
+				Destination: binary.LittleEndian.Uint16(r[4:]),
+				Transform:   binary.LittleEndian.Uint16(r[6:]),
+				Scale:       int32(binary.LittleEndian.Uint32(r[8:])),
+			})
+		}
+		arts = append(arts, art)
+	}
+	return arts, nil
+}
+
+func decodeWaveSample(b []byte) (*WaveSample, error) {
+	if len(b) < wsmpBytes {
+		return nil, fmt.Errorf("wsmp chunk is too short: %w", riffbin.ErrInvalidFormat)
+	}
+
+	size := binary.LittleEndian.Uint32(b[0:])
+	n := binary.LittleEndian.Uint32(b[16:])
+	if uint64(size)+uint64(n)*loopBytes > uint64(len(b)) {
+		return nil, fmt.Errorf("wsmp loops are out of the chunk: %w", riffbin.ErrInvalidFormat)
+	}
+
+	ws := &WaveSample{
+		UnityNote: binary.LittleEndian.Uint16(b[4:]),
+		FineTune:  int16(binary.LittleEndian.Uint16(b[6:])),
+		Gain:      int32(binary.LittleEndian.Uint32(b[8:])),
+		Options:   binary.LittleEndian.Uint32(b[12:]),
+	}
+	for i := uint32(0); i < n; i++ {
+		r := b[size+i*loopBytes:]
+		ws.Loops = append(ws.Loops, Loop{
+			Type:   binary.LittleEndian.Uint32(r[4:]),
+			Start:  binary.LittleEndian.Uint32(r[8:]),
+			Length: binary.LittleEndian.Uint32(r[12:]),
+		})
+	}
+	return ws, nil
+}
+
+func (inst *Instrument) encode() *riffbin.ListChunk {
+	insh := appendUint32(nil, uint32(len(inst.Regions)))
+	insh = appendUint32(insh, inst.Bank)
+	insh = appendUint32(insh, inst.Program)
+
+	regions := make([]riffbin.Chunk, 0, len(inst.Regions))
+	for i := range inst.Regions {
+		regions = append(regions, inst.Regions[i].encode())
+	}
+
+	payload := []riffbin.Chunk{
+		&riffbin.OnMemorySubChunk{ID: inshID, Payload: insh},
+		&riffbin.ListChunk{ListType: lrgnType, Payload: regions},
+	}
+	payload = append(payload, encodeArticulators(inst.Articulators)...)
+	if len(inst.Info) != 0 {
+		payload = append(payload, &riffbin.ListChunk{ListType: infoType, Payload: inst.Info})
+	}
+	return &riffbin.ListChunk{ListType: insType, Payload: payload}
+}
+
+func (r *Region) encode() *riffbin.ListChunk {
+	rgnh := appendUint16(nil, r.KeyLow)
+	rgnh = appendUint16(rgnh, r.KeyHigh)
+	rgnh = appendUint16(rgnh, r.VelocityLow)
+	rgnh = appendUint16(rgnh, r.VelocityHigh)
+	rgnh = appendUint16(rgnh, r.Options)
+	rgnh = appendUint16(rgnh, r.KeyGroup)
+	typ := rgnType
+	if r.Level2 {
+		rgnh = appendUint16(rgnh, r.Layer)
+		typ = rgn2Type
+	}
+
+	wlnk := appendUint16(nil, r.Link.Options)
+	wlnk = appendUint16(wlnk, r.Link.PhaseGroup)
+	wlnk = appendUint32(wlnk, r.Link.Channel)
+	wlnk = appendUint32(wlnk, r.Link.TableIndex)
+
+	payload := []riffbin.Chunk{&riffbin.OnMemorySubChunk{ID: rgnhID, Payload: rgnh}}
+	if r.Sample != nil {
+		payload = append(payload, r.Sample.encode())
+	}
+	payload = append(payload, &riffbin.OnMemorySubChunk{ID: wlnkID, Payload: wlnk})
+	payload = append(payload, encodeArticulators(r.Articulators)...)
+	return &riffbin.ListChunk{ListType: typ, Payload: payload}
+}
+
+func (ws *WaveSample) encode() *riffbin.OnMemorySubChunk {
+	b := appendUint32(nil, wsmpBytes)
+	b = appendUint16(b, ws.UnityNote)
+	b = appendUint16(b, uint16(ws.FineTune))
+	b = appendUint32(b, uint32(ws.Gain))
+	b = appendUint32(b, ws.Options)
+	b = appendUint32(b, uint32(len(ws.Loops)))
+	for _, l := range ws.Loops {
+		b = appendUint32(b, loopBytes)
+		b = appendUint32(b, l.Type)
+		b = appendUint32(b, l.Start)
+		b = appendUint32(b, l.Length)
+	}
+	return &riffbin.OnMemorySubChunk{ID: wsmpID, Payload: b}
+}
+
+// encodeArticulators encodes the articulators into LIST-lart for art1 and LIST-lar2 for art2.
+func encodeArticulators(arts []Articulator) []riffbin.Chunk {
+	var lart, lar2 []riffbin.Chunk
+	for _, art := range arts {
+		b := appendUint32(nil, artBytes)
+		b = appendUint32(b, uint32(len(art.Connections)))
+		for _, c := range art.Connections {
+			b = appendUint16(b, c.Source)
+			b = appendUint16(b, c.Control)
+			b = appendUint16(b, c.Destination)
+			b = appendUint16(b, c.Transform)
+			b = appendUint32(b, uint32(c.Scale))
+		}
+
+		if art.Level2 {
+			lar2 = append(lar2, &riffbin.OnMemorySubChunk{ID: art2ID, Payload: b})
+		} else {
+			lart = append(lart, &riffbin.OnMemorySubChunk{ID: art1ID, Payload: b})
+		}
+	}
+
+	var chunks []riffbin.Chunk
+	if len(lart) != 0 {
+		chunks = append(chunks, &riffbin.ListChunk{ListType: lartType, Payload: lart})
+	}
+	if len(lar2) != 0 {
+		chunks = append(chunks, &riffbin.ListChunk{ListType: lar2Type, Payload: lar2})
+	}
+	return chunks
+}
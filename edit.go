@@ -0,0 +1,132 @@
+package riffbin
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+)
+
+// LookupChunk returns the chunk at name in c. name is the path of the chunk as same as NewFS (e.g. "LIST-INFO/INAM").
+func LookupChunk(c *RIFFChunk, name string) (Chunk, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "lookup", Path: name, Err: fs.ErrInvalid}
+	}
+
+	info, err := (&chunkFS{root: c}).lookup(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "lookup", Path: name, Err: err}
+	}
+	return info.chunk, nil
+}
+
+// ReplaceChunk replaces the chunk at name in c with nc.
+func ReplaceChunk(c *RIFFChunk, name string, nc Chunk) error {
+	return editChunk(c, "replace", name, func(payload []Chunk, i int) []Chunk {
+		payload[i] = nc
+		return payload
+	})
+}
+
+// DeleteChunk deletes the chunk at name in c.
+func DeleteChunk(c *RIFFChunk, name string) error {
+	return editChunk(c, "delete", name, func(payload []Chunk, i int) []Chunk {
+		return append(payload[:i], payload[i+1:]...)
+	})
+}
+
+// InsertChunkBefore inserts nc before the chunk at name in c.
+func InsertChunkBefore(c *RIFFChunk, name string, nc Chunk) error {
+	return editChunk(c, "insert", name, func(payload []Chunk, i int) []Chunk {
+		return insertChunk(payload, i, nc)
+	})
+}
+
+// InsertChunkAfter inserts nc after the chunk at name in c.
+func InsertChunkAfter(c *RIFFChunk, name string, nc Chunk) error {
+	return editChunk(c, "insert", name, func(payload []Chunk, i int) []Chunk {
+		return insertChunk(payload, i+1, nc)
+	})
+}
+
+// AppendChunk appends nc to the payload of the grouped chunk at dir in c. dir is "." for c itself.
+func AppendChunk(c *RIFFChunk, dir string, nc Chunk) error {
+	if !fs.ValidPath(dir) {
+		return &fs.PathError{Op: "append", Path: dir, Err: fs.ErrInvalid}
+	}
+
+	info, err := (&chunkFS{root: c}).lookup(dir)
+	if err != nil {
+		return &fs.PathError{Op: "append", Path: dir, Err: err}
+	}
+	if !info.IsDir() {
+		return &fs.PathError{Op: "append", Path: dir, Err: errors.New("not a grouped chunk")}
+	}
+
+	payload, err := groupedPayload(info.chunk)
+	if err != nil {
+		return &fs.PathError{Op: "append", Path: dir, Err: err}
+	}
+	setGroupedPayload(info.chunk, append(payload, nc))
+	return nil
+}
+
+// editChunk edits the payload of the parent of the chunk at name by f with the index of the chunk.
+func editChunk(c *RIFFChunk, op, name string, f func(payload []Chunk, i int) []Chunk) error {
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	dir, base := path.Split(name)
+	if dir == "" {
+		dir = "."
+	} else {
+		dir = dir[:len(dir)-1]
+	}
+
+	parent, err := (&chunkFS{root: c}).lookup(dir)
+	if err != nil {
+		return &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	children, err := parent.children()
+	if err != nil {
+		return &fs.PathError{Op: op, Path: name, Err: err}
+	}
+
+	for i, child := range children {
+		if child.name == base {
+			payload, err := groupedPayload(parent.chunk)
+			if err != nil {
+				return &fs.PathError{Op: op, Path: name, Err: err}
+			}
+			setGroupedPayload(parent.chunk, f(payload, i))
+			return nil
+		}
+	}
+	return &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+}
+
+func insertChunk(payload []Chunk, i int, nc Chunk) []Chunk {
+	payload = append(payload, nil)
+	copy(payload[i+1:], payload[i:])
+	payload[i] = nc
+	return payload
+}
+
+func groupedPayload(c Chunk) ([]Chunk, error) {
+	switch cc := c.(type) {
+	case *RIFFChunk:
+		return cc.Payload, nil
+	case *ListChunk:
+		return cc.Chunks()
+	}
+	return nil, errors.New("not a grouped chunk")
+}
+
+func setGroupedPayload(c Chunk, payload []Chunk) {
+	switch cc := c.(type) {
+	case *RIFFChunk:
+		cc.Payload = payload
+	case *ListChunk:
+		cc.Payload = payload
+	}
+}
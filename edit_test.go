@@ -0,0 +1,192 @@
+package riffbin_test
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/karupanerura/riffbin"
+)
+
+func chunkNames(t *testing.T, c *riffbin.RIFFChunk) []string {
+	t.Helper()
+
+	var names []string
+	if err := fs.WalkDir(riffbin.NewFS(c), ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != "." {
+			names = append(names, path)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return names
+}
+
+func TestLookupChunk(t *testing.T) {
+	t.Parallel()
+
+	c := newComplexRIFFChunk()
+	for _, tc := range []struct {
+		name string
+		id   string
+	}{
+		{name: ".", id: "RIFF"},
+		{name: "LIST-LST1", id: "LIST"},
+		{name: "LIST-LST1/LIST-LST2/ENT3", id: "ENT3"},
+		{name: "ENT5", id: "ENT5"},
+	} {
+		chunk, err := riffbin.LookupChunk(c, tc.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(chunk.ChunkID()) != tc.id {
+			t.Errorf("%s: unexpected chunk: %s", tc.name, chunk.ChunkID())
+		}
+	}
+
+	if _, err := riffbin.LookupChunk(c, "LIST-LST1/ENT5"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := riffbin.LookupChunk(c, "/ENT5"); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestEditChunk(t *testing.T) {
+	t.Parallel()
+
+	newChunk := &riffbin.OnMemorySubChunk{ID: [4]byte{'N', 'E', 'W', '1'}, Payload: []byte{0xFF}}
+
+	for _, tc := range []struct {
+		name     string
+		edit     func(c *riffbin.RIFFChunk) error
+		expected []string
+	}{
+		{
+			name: "Replace",
+			edit: func(c *riffbin.RIFFChunk) error { return riffbin.ReplaceChunk(c, "LIST-LST1/LIST-LST2", newChunk) },
+			expected: []string{
+				"ENT5", "LIST-LST1", "LIST-LST1/ENT1", "LIST-LST1/ENT4", "LIST-LST1/NEW1", "LIST-LST3", "LIST-LST4", "LIST-LST4/ENT6",
+			},
+		},
+		{
+			name: "Delete",
+			edit: func(c *riffbin.RIFFChunk) error { return riffbin.DeleteChunk(c, "ENT5") },
+			expected: []string{
+				"LIST-LST1", "LIST-LST1/ENT1", "LIST-LST1/ENT4", "LIST-LST1/LIST-LST2", "LIST-LST1/LIST-LST2/ENT2", "LIST-LST1/LIST-LST2/ENT3", "LIST-LST3", "LIST-LST4", "LIST-LST4/ENT6",
+			},
+		},
+		{
+			name: "InsertBefore",
+			edit: func(c *riffbin.RIFFChunk) error { return riffbin.InsertChunkBefore(c, "LIST-LST4/ENT6", newChunk) },
+			expected: []string{
+				"ENT5", "LIST-LST1", "LIST-LST1/ENT1", "LIST-LST1/ENT4", "LIST-LST1/LIST-LST2", "LIST-LST1/LIST-LST2/ENT2", "LIST-LST1/LIST-LST2/ENT3", "LIST-LST3", "LIST-LST4", "LIST-LST4/ENT6", "LIST-LST4/NEW1",
+			},
+		},
+		{
+			name: "AppendEmpty",
+			edit: func(c *riffbin.RIFFChunk) error { return riffbin.AppendChunk(c, "LIST-LST3", newChunk) },
+			expected: []string{
+				"ENT5", "LIST-LST1", "LIST-LST1/ENT1", "LIST-LST1/ENT4", "LIST-LST1/LIST-LST2", "LIST-LST1/LIST-LST2/ENT2", "LIST-LST1/LIST-LST2/ENT3", "LIST-LST3", "LIST-LST3/NEW1", "LIST-LST4", "LIST-LST4/ENT6",
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := newComplexRIFFChunk()
+			if err := tc.edit(c); err != nil {
+				t.Fatal(err)
+			}
+			if df := cmp.Diff(chunkNames(t, c), tc.expected); df != "" {
+				t.Errorf("diff = %s", df)
+			}
+		})
+	}
+
+	t.Run("Order", func(t *testing.T) {
+		t.Parallel()
+
+		c := &riffbin.RIFFChunk{
+			FormType: [4]byte{'T', 'E', 'S', 'T'},
+			Payload: []riffbin.Chunk{
+				&riffbin.OnMemorySubChunk{ID: [4]byte{'d', 'a', 't', 'a'}, Payload: []byte{0x01}},
+				&riffbin.OnMemorySubChunk{ID: [4]byte{'d', 'a', 't', 'a'}, Payload: []byte{0x02}},
+			},
+		}
+		if err := riffbin.InsertChunkAfter(c, "data", &riffbin.OnMemorySubChunk{ID: [4]byte{'d', 'a', 't', 'a'}, Payload: []byte{0x03}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := riffbin.InsertChunkBefore(c, "data", &riffbin.OnMemorySubChunk{ID: [4]byte{'d', 'a', 't', 'a'}, Payload: []byte{0x04}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := riffbin.AppendChunk(c, ".", &riffbin.OnMemorySubChunk{ID: [4]byte{'d', 'a', 't', 'a'}, Payload: []byte{0x05}}); err != nil {
+			t.Fatal(err)
+		}
+
+		var got []byte
+		for _, chunk := range c.Payload {
+			got = append(got, chunk.(*riffbin.OnMemorySubChunk).Payload...)
+		}
+		if !bytes.Equal(got, []byte{0x04, 0x01, 0x03, 0x02, 0x05}) {
+			t.Errorf("unexpected order: %v", got)
+		}
+	})
+
+	t.Run("Lazy", func(t *testing.T) {
+		t.Parallel()
+
+		var src bytes.Buffer
+		if _, err := riffbin.NewCompletedChunkWriter(&src).Write(newComplexRIFFChunk()); err != nil {
+			t.Fatal(err)
+		}
+		c, err := riffbin.ReadSectionsLazy(bytes.NewReader(src.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := riffbin.DeleteChunk(c, "LIST-LST1/LIST-LST2/ENT2"); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := newComplexRIFFChunk()
+		if err := riffbin.DeleteChunk(expected, "LIST-LST1/LIST-LST2/ENT2"); err != nil {
+			t.Fatal(err)
+		}
+
+		var got, want bytes.Buffer
+		if _, err := riffbin.NewCompletedChunkWriter(&got).Write(c); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := riffbin.NewCompletedChunkWriter(&want).Write(expected); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got.Bytes(), want.Bytes()) {
+			t.Error("unexpected bytes are written")
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+
+		c := newComplexRIFFChunk()
+		if err := riffbin.DeleteChunk(c, "."); !errors.Is(err, fs.ErrInvalid) {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if err := riffbin.ReplaceChunk(c, "LIST-LST1/ENT9", newChunk); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if err := riffbin.InsertChunkAfter(c, "ENT5/ENT1", newChunk); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if err := riffbin.AppendChunk(c, "ENT5", newChunk); err == nil {
+			t.Error("sub-chunk should not be appended to")
+		}
+	})
+}
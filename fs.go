@@ -0,0 +1,239 @@
+package riffbin
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+	"time"
+)
+
+// NewFS returns fs.FS to browse the chunk tree of c with the standard tools (e.g. fs.WalkDir, fs.Glob, http.FileServer).
+// The grouped chunks are directories, and the sub-chunks are regular files whose content is the payload.
+// The names are same as the paths reported to ProgressFunc (e.g. "LIST-INFO/INAM", "data.1"), and c itself is the root directory ".".
+// Sys of fs.FileInfo returns the Chunk.
+// The LIST chunks read by ReadSectionsLazy are loaded on opening, and the incomplete sub-chunks cannot be opened.
+func NewFS(c *RIFFChunk) fs.FS {
+	return &chunkFS{root: c}
+}
+
+type chunkFS struct {
+	root *RIFFChunk
+}
+
+var _ fs.FS = (*chunkFS)(nil)
+
+func (fsys *chunkFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	info, err := fsys.lookup(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	f, err := info.open()
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return f, nil
+}
+
+func (fsys *chunkFS) lookup(name string) (*chunkFileInfo, error) {
+	info := &chunkFileInfo{name: ".", chunk: fsys.root}
+	if name == "." {
+		return info, nil
+	}
+
+	for _, elem := range strings.Split(name, "/") {
+		children, err := info.children()
+		if err != nil {
+			return nil, err
+		}
+
+		var found *chunkFileInfo
+		for _, child := range children {
+			if child.name == elem {
+				found = child
+				break
+			}
+		}
+		if found == nil {
+			return nil, fs.ErrNotExist
+		}
+		info = found
+	}
+	return info, nil
+}
+
+// chunkFileInfo is fs.FileInfo of the chunk.
+type chunkFileInfo struct {
+	name  string
+	chunk Chunk
+}
+
+var _ fs.FileInfo = (*chunkFileInfo)(nil)
+
+func (fi *chunkFileInfo) Name() string {
+	return fi.name
+}
+
+func (fi *chunkFileInfo) Size() int64 {
+	return int64(fi.chunk.BodySize())
+}
+
+func (fi *chunkFileInfo) Mode() fs.FileMode {
+	if fi.IsDir() {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+func (fi *chunkFileInfo) ModTime() time.Time {
+	return time.Time{}
+}
+
+func (fi *chunkFileInfo) IsDir() bool {
+	_, ok := fi.chunk.(groupedChunk)
+	return ok
+}
+
+func (fi *chunkFileInfo) Sys() interface{} {
+	return fi.chunk
+}
+
+// children returns the infos of the chunks in the payload of the grouped chunk.
+func (fi *chunkFileInfo) children() ([]*chunkFileInfo, error) {
+	if !fi.IsDir() {
+		return nil, fs.ErrNotExist
+	}
+	payload, err := groupedPayload(fi.chunk)
+	if err != nil {
+		return nil, err
+	}
+
+	names := chunkNamer{}
+	children := make([]*chunkFileInfo, len(payload))
+	for i, c := range payload {
+		children[i] = &chunkFileInfo{name: names.name(c), chunk: c}
+	}
+	return children, nil
+}
+
+func (fi *chunkFileInfo) open() (fs.File, error) {
+	if fi.IsDir() {
+		children, err := fi.children()
+		if err != nil {
+			return nil, err
+		}
+
+		entries := make([]fs.DirEntry, len(children))
+		for i, child := range children {
+			entries[i] = chunkDirEntry{child}
+		}
+		return &chunkDir{info: fi, entries: entries}, nil
+	}
+
+	sc, ok := fi.chunk.(SubChunk)
+	if !ok {
+		return nil, errors.New("unknown chunk")
+	}
+	r, err := openPayload(sc)
+	if err != nil {
+		return nil, err
+	}
+	return &chunkFile{info: fi, SectionReader: r}, nil
+}
+
+// openPayload opens the payload of the sub-chunk with the own cursor, so it does not consume c.
+func openPayload(c SubChunk) (*io.SectionReader, error) {
+	if c.Incomplete() {
+		return nil, errors.New("incomplete sub-chunk cannot be opened")
+	}
+
+	var r io.ReaderAt
+	switch cc := c.(type) {
+	case *OnMemorySubChunk:
+		r = bytes.NewReader(cc.Payload)
+	case io.ReaderAt:
+		r = cc
+	default:
+		return nil, errors.New("sub-chunk cannot be opened")
+	}
+	return io.NewSectionReader(r, 0, int64(c.BodySize())), nil
+}
+
+// chunkDirEntry is fs.DirEntry of the chunk.
+type chunkDirEntry struct {
+	info *chunkFileInfo
+}
+
+func (e chunkDirEntry) Name() string {
+	return e.info.Name()
+}
+
+func (e chunkDirEntry) IsDir() bool {
+	return e.info.IsDir()
+}
+
+func (e chunkDirEntry) Type() fs.FileMode {
+	return e.info.Mode().Type()
+}
+
+func (e chunkDirEntry) Info() (fs.FileInfo, error) {
+	return e.info, nil
+}
+
+// chunkFile is fs.File of the sub-chunk.
+type chunkFile struct {
+	info *chunkFileInfo
+	*io.SectionReader
+}
+
+func (f *chunkFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *chunkFile) Close() error {
+	return nil
+}
+
+// chunkDir is fs.ReadDirFile of the grouped chunk.
+type chunkDir struct {
+	info    *chunkFileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+var _ fs.ReadDirFile = (*chunkDir)(nil)
+
+func (d *chunkDir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *chunkDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")}
+}
+
+func (d *chunkDir) Close() error {
+	return nil
+}
+
+func (d *chunkDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+
+	if n > len(rest) {
+		n = len(rest)
+	}
+	d.offset += n
+	return rest[:n], nil
+}
@@ -0,0 +1,196 @@
+package riffbin_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/karupanerura/riffbin"
+)
+
+func TestFS(t *testing.T) {
+	t.Parallel()
+
+	var src bytes.Buffer
+	if _, err := riffbin.NewCompletedChunkWriter(&src).Write(newComplexRIFFChunk()); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"LIST-LST1",
+		"LIST-LST1/ENT1",
+		"LIST-LST1/LIST-LST2",
+		"LIST-LST1/LIST-LST2/ENT2",
+		"LIST-LST1/LIST-LST2/ENT3",
+		"LIST-LST1/ENT4",
+		"ENT5",
+		"LIST-LST3",
+		"LIST-LST4",
+		"LIST-LST4/ENT6",
+	}
+
+	for _, tc := range []struct {
+		name string
+		read func() (*riffbin.RIFFChunk, error)
+	}{
+		{name: "OnMemory", read: func() (*riffbin.RIFFChunk, error) { return newComplexRIFFChunk(), nil }},
+		{name: "ReadFull", read: func() (*riffbin.RIFFChunk, error) { return riffbin.ReadFull(bytes.NewReader(src.Bytes())) }},
+		{name: "ReadSections", read: func() (*riffbin.RIFFChunk, error) { return riffbin.ReadSections(bytes.NewReader(src.Bytes())) }},
+		{name: "ReadSectionsLazy", read: func() (*riffbin.RIFFChunk, error) { return riffbin.ReadSectionsLazy(bytes.NewReader(src.Bytes())) }},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			riffChunk, err := tc.read()
+			if err != nil {
+				t.Fatal(err)
+			}
+			fsys := riffbin.NewFS(riffChunk)
+
+			if err := fstest.TestFS(fsys, expected...); err != nil {
+				t.Fatal(err)
+			}
+
+			var walked []string
+			if err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if path != "." {
+					walked = append(walked, path)
+				}
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if df := cmp.Diff(walked, []string{
+				"ENT5",
+				"LIST-LST1",
+				"LIST-LST1/ENT1",
+				"LIST-LST1/ENT4",
+				"LIST-LST1/LIST-LST2",
+				"LIST-LST1/LIST-LST2/ENT2",
+				"LIST-LST1/LIST-LST2/ENT3",
+				"LIST-LST3",
+				"LIST-LST4",
+				"LIST-LST4/ENT6",
+			}); df != "" {
+				t.Errorf("diff = %s", df)
+			}
+
+			got, err := fs.ReadFile(fsys, "LIST-LST1/LIST-LST2/ENT3")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, []byte{0x01, 0x02, 0x03}) {
+				t.Errorf("unexpected payload: %v", got)
+			}
+
+			matches, err := fs.Glob(fsys, "LIST-*/ENT*")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if df := cmp.Diff(matches, []string{"LIST-LST1/ENT1", "LIST-LST1/ENT4", "LIST-LST4/ENT6"}); df != "" {
+				t.Errorf("diff = %s", df)
+			}
+
+			info, err := fs.Stat(fsys, "ENT5")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Size() != 5 || !info.Mode().IsRegular() {
+				t.Errorf("unexpected info: size=%d, mode=%s", info.Size(), info.Mode())
+			}
+			if c, ok := info.Sys().(riffbin.Chunk); !ok || string(c.ChunkID()) != "ENT5" {
+				t.Errorf("unexpected sys: %v", info.Sys())
+			}
+
+			if _, err := fsys.Open("ENT5/ENT1"); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if _, err := fsys.Open("/ENT5"); !errors.Is(err, fs.ErrInvalid) {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+
+	t.Run("Duplicated", func(t *testing.T) {
+		t.Parallel()
+
+		fsys := riffbin.NewFS(&riffbin.RIFFChunk{
+			FormType: [4]byte{'T', 'E', 'S', 'T'},
+			Payload: []riffbin.Chunk{
+				&riffbin.OnMemorySubChunk{ID: [4]byte{'d', 'a', 't', 'a'}, Payload: []byte{0x01}},
+				&riffbin.OnMemorySubChunk{ID: [4]byte{'d', 'a', 't', 'a'}, Payload: []byte{0x02}},
+				&riffbin.OnMemorySubChunk{ID: [4]byte{'f', 'm', 't', ' '}, Payload: []byte{0x03}},
+				&riffbin.OnMemorySubChunk{ID: [4]byte{'a', '/', 0x00, 0xFF}, Payload: []byte{0x04}},
+				&riffbin.ListChunk{ListType: [4]byte{'I', 'N', 'F', 'O'}, Payload: []riffbin.Chunk{}},
+				&riffbin.ListChunk{ListType: [4]byte{'I', 'N', 'F', 'O'}, Payload: []riffbin.Chunk{}},
+			},
+		})
+		if err := fstest.TestFS(fsys, "data", "data.1", "fmt ", "a%2F%00%FF", "LIST-INFO", "LIST-INFO.1"); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := fs.ReadFile(fsys, "data.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, []byte{0x02}) {
+			t.Errorf("unexpected payload: %v", got)
+		}
+	})
+
+	t.Run("Incomplete", func(t *testing.T) {
+		t.Parallel()
+
+		fsys := riffbin.NewFS(&riffbin.RIFFChunk{
+			FormType: [4]byte{'T', 'E', 'S', 'T'},
+			Payload: []riffbin.Chunk{
+				riffbin.NewIncompleteSubChunk([4]byte{'d', 'a', 't', 'a'}, strings.NewReader("data")),
+			},
+		})
+		if _, err := fsys.Open("data"); err == nil {
+			t.Error("incomplete sub-chunk should not be opened")
+		}
+	})
+
+	t.Run("FileServer", func(t *testing.T) {
+		t.Parallel()
+
+		riffChunk, err := riffbin.ReadSections(bytes.NewReader(src.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		server := httptest.NewServer(http.FileServer(http.FS(riffbin.NewFS(riffChunk))))
+		t.Cleanup(server.Close)
+
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/LIST-LST4/ENT6", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Range", "bytes=2-3")
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+
+		got, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != http.StatusPartialContent || !bytes.Equal(got, []byte{0x03, 0x04}) {
+			t.Errorf("unexpected response: %s %v", res.Status, got)
+		}
+	})
+}
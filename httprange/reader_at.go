@@ -0,0 +1,356 @@
+// Package httprange provides io.ReaderAt for the remote file by HTTP range requests.
+// It can be used with riffbin.ReadSectionsAt to fetch only the chunk headers and the requested sections.
+package httprange
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrRangeNotSupported is an error for the server which does not support range requests.
+	ErrRangeNotSupported = errors.New("range request is not supported")
+
+	// ErrModified is an error for the remote file which has been modified after NewReaderAt.
+	ErrModified = errors.New("remote file has been modified")
+)
+
+const (
+	defaultBlockSize  = 64 * 1024
+	defaultReadAhead  = 4
+	defaultCacheSize  = 64
+	contentRangeUnits = "bytes "
+)
+
+// Option is an option for ReaderAt.
+type Option func(*config)
+
+type config struct {
+	client    *http.Client
+	blockSize int64
+	readAhead int
+	cacheSize int
+	header    http.Header
+}
+
+// WithClient sets the HTTP client. (default: http.DefaultClient)
+func WithClient(client *http.Client) Option {
+	return func(cfg *config) {
+		cfg.client = client
+	}
+}
+
+// WithBlockSize sets the bytes of the cache block. (default: 64KiB)
+func WithBlockSize(size int64) Option {
+	return func(cfg *config) {
+		cfg.blockSize = size
+	}
+}
+
+// WithReadAhead sets the number of the blocks to fetch at once. (default: 4)
+func WithReadAhead(blocks int) Option {
+	return func(cfg *config) {
+		cfg.readAhead = blocks
+	}
+}
+
+// WithCacheSize sets the maximum number of the cached blocks. (default: 64)
+func WithCacheSize(blocks int) Option {
+	return func(cfg *config) {
+		cfg.cacheSize = blocks
+	}
+}
+
+// WithHeader sets the additional HTTP request header. (e.g. Authorization)
+func WithHeader(header http.Header) Option {
+	return func(cfg *config) {
+		cfg.header = header
+	}
+}
+
+// ReaderAt is io.ReaderAt for the remote file by HTTP range requests.
+// The fetched blocks are cached in LRU manner. It is safe for concurrent use.
+type ReaderAt struct {
+	ctx  context.Context
+	url  string
+	size int64
+	etag string
+	cfg  config
+
+	mu     sync.Mutex
+	blocks map[int64]*list.Element
+	lru    *list.List
+}
+
+var _ io.ReaderAt = (*ReaderAt)(nil)
+
+type block struct {
+	index int64
+	data  []byte
+}
+
+// NewReaderAt creates a new ReaderAt for url. It requests the first byte to get the size of the file.
+func NewReaderAt(url string, opts ...Option) (*ReaderAt, error) {
+	return NewReaderAtContext(context.Background(), url, opts...)
+}
+
+// NewReaderAtContext is same as NewReaderAt, but ctx is used for the all requests including the requests by ReadAt.
+// ReadAt returns the error of ctx after ctx is done.
+func NewReaderAtContext(ctx context.Context, url string, opts ...Option) (*ReaderAt, error) {
+	cfg := config{
+		client:    http.DefaultClient,
+		blockSize: defaultBlockSize,
+		readAhead: defaultReadAhead,
+		cacheSize: defaultCacheSize,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.blockSize < 1 || cfg.readAhead < 1 || cfg.cacheSize < 1 {
+		return nil, errors.New("block size, read ahead and cache size must be positive")
+	}
+
+	r := &ReaderAt{
+		ctx:    ctx,
+		url:    url,
+		cfg:    cfg,
+		blocks: map[int64]*list.Element{},
+		lru:    list.New(),
+	}
+
+	res, size, err := r.request(0, 0)
+	if errors.Is(err, errUnsatisfiable) {
+		if size > 0 {
+			return nil, fmt.Errorf("unexpected unsatisfiable range for %d bytes", size)
+		}
+		// the first byte cannot be satisfied for the empty file
+		return r, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if size < 0 {
+		return nil, fmt.Errorf("unknown size in Content-Range: %q", res.Header.Get("Content-Range"))
+	}
+	r.size = size
+	if etag := res.Header.Get("ETag"); !strings.HasPrefix(etag, "W/") {
+		// If-Range accepts the strong validator only
+		r.etag = etag
+	}
+	return r, nil
+}
+
+// Size returns the size of the remote file.
+func (r *ReaderAt) Size() int64 {
+	return r.size
+}
+
+// ReadAt reads len(p) bytes from the remote file starting at byte offset off.
+func (r *ReaderAt) ReadAt(p []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+
+	for n < len(p) {
+		pos := off + int64(n)
+		if pos >= r.size {
+			return n, io.EOF
+		}
+
+		index := pos / r.cfg.blockSize
+		data, err := r.block(index, int64(len(p)-n))
+		if err != nil {
+			return n, err
+		}
+		n += copy(p[n:], data[pos-index*r.cfg.blockSize:])
+	}
+	return n, nil
+}
+
+// block returns the block data of index, and fetches the blocks to cover the wanted bytes at least if it is not cached.
+func (r *ReaderAt) block(index, wanted int64) ([]byte, error) {
+	r.mu.Lock()
+	if e, ok := r.blocks[index]; ok {
+		r.lru.MoveToFront(e)
+		r.mu.Unlock()
+		return e.Value.(*block).data, nil
+	}
+	r.mu.Unlock()
+
+	count := int64(r.cfg.readAhead)
+	if c := (wanted + r.cfg.blockSize - 1) / r.cfg.blockSize; c > count {
+		count = c
+	}
+
+	start := index * r.cfg.blockSize
+	end := start + count*r.cfg.blockSize - 1
+	if end >= r.size {
+		end = r.size - 1
+	}
+
+	data, err := r.fetch(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := int64(0); i*r.cfg.blockSize < int64(len(data)); i++ {
+		b := data[i*r.cfg.blockSize:]
+		if int64(len(b)) > r.cfg.blockSize {
+			b = b[:r.cfg.blockSize]
+		}
+		r.put(&block{index: index + i, data: b})
+	}
+
+	first := data
+	if int64(len(first)) > r.cfg.blockSize {
+		first = first[:r.cfg.blockSize]
+	}
+	return first, nil
+}
+
+func (r *ReaderAt) put(b *block) {
+	if e, ok := r.blocks[b.index]; ok {
+		e.Value = b
+		r.lru.MoveToFront(e)
+		return
+	}
+
+	r.blocks[b.index] = r.lru.PushFront(b)
+	for r.lru.Len() > r.cfg.cacheSize {
+		e := r.lru.Back()
+		r.lru.Remove(e)
+		delete(r.blocks, e.Value.(*block).index)
+	}
+}
+
+func (r *ReaderAt) fetch(start, end int64) ([]byte, error) {
+	res, size, err := r.request(start, end)
+	if errors.Is(err, errUnsatisfiable) {
+		// the file is truncated
+		return nil, ErrModified
+	} else if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if size >= 0 && size != r.size {
+		return nil, ErrModified
+	}
+
+	data := make([]byte, end-start+1)
+	if _, err := io.ReadFull(res.Body, data); err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+	return data, nil
+}
+
+// errUnsatisfiable is an internal error for the range which is out of the remote file.
+var errUnsatisfiable = errors.New("range not satisfiable")
+
+// request requests the range from start to end. It returns the response of the range and the complete length of the file. (-1 if it is unknown)
+// It also returns the complete length with errUnsatisfiable for 416 Range Not Satisfiable.
+func (r *ReaderAt) request(start, end int64) (*http.Response, int64, error) {
+	req, err := http.NewRequestWithContext(r.ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	for k, v := range r.cfg.header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+	if r.etag != "" {
+		// the server responds the whole file if it has been modified
+		req.Header.Set("If-Range", r.etag)
+	}
+
+	res, err := r.cfg.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch res.StatusCode {
+	case http.StatusPartialContent:
+		first, last, size, err := parseContentRange(res.Header.Get("Content-Range"))
+		if err != nil {
+			res.Body.Close()
+			return nil, 0, err
+		}
+		if first != start || last != end {
+			res.Body.Close()
+			return nil, 0, fmt.Errorf("unexpected Content-Range: %q for bytes=%d-%d", res.Header.Get("Content-Range"), start, end)
+		}
+		return res, size, nil
+	case http.StatusRequestedRangeNotSatisfiable:
+		res.Body.Close()
+		return nil, parseUnsatisfiedContentRangeSize(res.Header.Get("Content-Range")), errUnsatisfiable
+	case http.StatusOK:
+		res.Body.Close()
+		if r.etag != "" {
+			return nil, 0, ErrModified
+		}
+		if res.ContentLength == 0 {
+			// some servers ignore the range for the empty file
+			return nil, 0, errUnsatisfiable
+		}
+		return nil, 0, ErrRangeNotSupported
+	default:
+		res.Body.Close()
+		return nil, 0, fmt.Errorf("unexpected status: %s", res.Status)
+	}
+}
+
+// parseContentRange parses Content-Range header of the partial content. (e.g. "bytes 0-0/1234")
+// The complete length is -1 if it is unknown. (e.g. "bytes 0-0/*")
+func parseContentRange(s string) (first, last, size int64, err error) {
+	if !strings.HasPrefix(s, contentRangeUnits) {
+		return 0, 0, 0, fmt.Errorf("invalid Content-Range: %q", s)
+	}
+
+	i := strings.LastIndexByte(s, '/')
+	j := strings.IndexByte(s, '-')
+	if i < 0 || j < 0 || j > i {
+		return 0, 0, 0, fmt.Errorf("invalid Content-Range: %q", s)
+	}
+
+	first, err = strconv.ParseInt(s[len(contentRangeUnits):j], 10, 64)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid Content-Range: %q", s)
+	}
+	last, err = strconv.ParseInt(s[j+1:i], 10, 64)
+	if err != nil || last < first {
+		return 0, 0, 0, fmt.Errorf("invalid Content-Range: %q", s)
+	}
+
+	size = -1
+	if s[i+1:] != "*" {
+		size, err = strconv.ParseInt(s[i+1:], 10, 64)
+		if err != nil || size <= last {
+			return 0, 0, 0, fmt.Errorf("invalid Content-Range: %q", s)
+		}
+	}
+	return first, last, size, nil
+}
+
+// parseUnsatisfiedContentRangeSize parses the complete length of Content-Range header of the unsatisfiable range. (e.g. "bytes */1234")
+// It returns -1 if it is unknown.
+func parseUnsatisfiedContentRangeSize(s string) int64 {
+	if !strings.HasPrefix(s, contentRangeUnits+"*/") {
+		return -1
+	}
+
+	size, err := strconv.ParseInt(s[len(contentRangeUnits+"*/"):], 10, 64)
+	if err != nil {
+		return -1
+	}
+	return size
+}
@@ -0,0 +1,291 @@
+package httprange_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/karupanerura/riffbin"
+	"github.com/karupanerura/riffbin/httprange"
+)
+
+func newServer(t *testing.T, content []byte) (*httptest.Server, *int64, *int64) {
+	t.Helper()
+
+	var requests, served int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		w.Header().Set("ETag", `"riffbin"`)
+		http.ServeContent(&countingResponseWriter{ResponseWriter: w, n: &served}, r, "test.wav", time.Time{}, bytes.NewReader(content))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests, &served
+}
+
+type countingResponseWriter struct {
+	http.ResponseWriter
+	n *int64
+}
+
+func (w *countingResponseWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	atomic.AddInt64(w.n, int64(n))
+	return n, err
+}
+
+func TestReaderAt(t *testing.T) {
+	t.Parallel()
+
+	data := bytes.Repeat([]byte{0xAB}, 1<<20)
+	var src bytes.Buffer
+	if _, err := riffbin.NewCompletedChunkWriter(&src).Write(&riffbin.RIFFChunk{
+		FormType: [4]byte{'W', 'A', 'V', 'E'},
+		Payload: []riffbin.Chunk{
+			&riffbin.OnMemorySubChunk{ID: [4]byte{'f', 'm', 't', ' '}, Payload: []byte{0x01, 0x02, 0x03, 0x04}},
+			&riffbin.OnMemorySubChunk{ID: [4]byte{'d', 'a', 't', 'a'}, Payload: data},
+			&riffbin.ListChunk{
+				ListType: [4]byte{'I', 'N', 'F', 'O'},
+				Payload: []riffbin.Chunk{
+					&riffbin.OnMemorySubChunk{ID: [4]byte{'I', 'N', 'A', 'M'}, Payload: []byte("name")},
+				},
+			},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("ReadSectionsAt", func(t *testing.T) {
+		t.Parallel()
+
+		server, requests, served := newServer(t, src.Bytes())
+		r, err := httprange.NewReaderAt(server.URL, httprange.WithBlockSize(1024), httprange.WithReadAhead(2))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Size() != int64(src.Len()) {
+			t.Errorf("unexpected size: %d", r.Size())
+		}
+
+		riffChunk, err := riffbin.ReadSectionsAt(r, r.Size())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// only the headers are fetched
+		if n := atomic.LoadInt64(served); n > 8*1024 {
+			t.Errorf("too many bytes are fetched: %d", n)
+		}
+
+		fetched := atomic.LoadInt64(requests)
+		got, err := io.ReadAll(riffChunk.Payload[0].(*riffbin.InStreamSubChunk))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, []byte{0x01, 0x02, 0x03, 0x04}) {
+			t.Errorf("unexpected payload: %v", got)
+		}
+		if n := atomic.LoadInt64(requests); n != fetched {
+			t.Errorf("cached block should be used: %d requests", n-fetched)
+		}
+
+		name, err := io.ReadAll(riffChunk.Payload[2].(*riffbin.ListChunk).Payload[0].(*riffbin.InStreamSubChunk))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(name) != "name" {
+			t.Errorf("unexpected payload: %q", name)
+		}
+
+		got, err = io.ReadAll(riffChunk.Payload[1].(*riffbin.InStreamSubChunk))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Error("unexpected data payload")
+		}
+	})
+
+	t.Run("ReadAt", func(t *testing.T) {
+		t.Parallel()
+
+		server, requests, _ := newServer(t, src.Bytes())
+		r, err := httprange.NewReaderAt(server.URL, httprange.WithBlockSize(16), httprange.WithReadAhead(1), httprange.WithCacheSize(2))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		buf := make([]byte, 40)
+		if n, err := r.ReadAt(buf, 10); err != nil || n != len(buf) {
+			t.Fatalf("unexpected result: n=%d, err=%v", n, err)
+		}
+		if !bytes.Equal(buf, src.Bytes()[10:50]) {
+			t.Errorf("unexpected bytes: %v", buf)
+		}
+
+		// the first block is evicted
+		before := atomic.LoadInt64(requests)
+		if _, err := r.ReadAt(buf[:1], 0); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.ReadAt(buf[:1], 0); err != nil {
+			t.Fatal(err)
+		}
+		if n := atomic.LoadInt64(requests) - before; n != 1 {
+			t.Errorf("unexpected requests: %d", n)
+		}
+
+		// read beyond the end
+		n, err := r.ReadAt(buf, r.Size()-4)
+		if err != io.EOF || n != 4 {
+			t.Errorf("unexpected result: n=%d, err=%v", n, err)
+		}
+		if !bytes.Equal(buf[:n], src.Bytes()[src.Len()-4:]) {
+			t.Errorf("unexpected bytes: %v", buf[:n])
+		}
+	})
+
+	t.Run("WeakETag", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("ETag", `W/"riffbin"`)
+			http.ServeContent(w, r, "test.wav", time.Time{}, bytes.NewReader(src.Bytes()))
+		}))
+		t.Cleanup(server.Close)
+
+		r, err := httprange.NewReaderAt(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf := make([]byte, 4)
+		if _, err := r.ReadAt(buf, 8); err != nil {
+			t.Fatal(err)
+		}
+		if string(buf) != "WAVE" {
+			t.Errorf("unexpected bytes: %q", buf)
+		}
+	})
+
+	t.Run("Modified", func(t *testing.T) {
+		t.Parallel()
+
+		var etag atomic.Value
+		etag.Store(`"v1"`)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("ETag", etag.Load().(string))
+			http.ServeContent(w, r, "test.wav", time.Time{}, bytes.NewReader(src.Bytes()))
+		}))
+		t.Cleanup(server.Close)
+
+		r, err := httprange.NewReaderAt(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		etag.Store(`"v2"`)
+		if _, err := r.ReadAt(make([]byte, 4), 8); !errors.Is(err, httprange.ErrModified) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("UnexpectedContentRange", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Range") == "bytes=0-0" {
+				w.Header().Set("Content-Range", fmt.Sprintf("bytes 0-0/%d", src.Len()))
+				w.WriteHeader(http.StatusPartialContent)
+				w.Write(src.Bytes()[:1])
+				return
+			}
+
+			// respond the head instead of the requested range
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes 0-3/%d", src.Len()))
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(src.Bytes()[:4])
+		}))
+		t.Cleanup(server.Close)
+
+		r, err := httprange.NewReaderAt(server.URL, httprange.WithBlockSize(4), httprange.WithReadAhead(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.ReadAt(make([]byte, 4), 8); err == nil || !strings.Contains(err.Error(), "Content-Range") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Context", func(t *testing.T) {
+		t.Parallel()
+
+		server, _, _ := newServer(t, src.Bytes())
+		ctx, cancel := context.WithCancel(context.Background())
+		r, err := httprange.NewReaderAtContext(ctx, server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cancel()
+		if _, err := r.ReadAt(make([]byte, 4), 8); !errors.Is(err, context.Canceled) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("RangeNotSupported", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write(src.Bytes())
+		}))
+		t.Cleanup(server.Close)
+
+		if _, err := httprange.NewReaderAt(server.URL); !errors.Is(err, httprange.ErrRangeNotSupported) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		t.Parallel()
+
+		for name, handler := range map[string]http.HandlerFunc{
+			"NotSatisfiable": func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Range", "bytes */0")
+				w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			},
+			"RangeIgnored": func(w http.ResponseWriter, r *http.Request) {
+				http.ServeContent(w, r, "test.wav", time.Time{}, bytes.NewReader(nil))
+			},
+		} {
+			server := httptest.NewServer(handler)
+			t.Cleanup(server.Close)
+
+			r, err := httprange.NewReaderAt(server.URL)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if r.Size() != 0 {
+				t.Errorf("%s: unexpected size: %d", name, r.Size())
+			}
+			if n, err := r.ReadAt(make([]byte, 1), 0); n != 0 || err != io.EOF {
+				t.Errorf("%s: unexpected result: n=%d, err=%v", name, n, err)
+			}
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(http.NotFoundHandler())
+		t.Cleanup(server.Close)
+
+		if _, err := httprange.NewReaderAt(server.URL); err == nil || !strings.Contains(err.Error(), "404") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
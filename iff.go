@@ -0,0 +1,191 @@
+package riffbin
+
+var (
+	formID = [idBytes]byte{'F', 'O', 'R', 'M'}
+	catID  = [idBytes]byte{'C', 'A', 'T', ' '}
+	propID = [idBytes]byte{'P', 'R', 'O', 'P'}
+)
+
+// IFFRootChunk is a root chunk of EA IFF 85. It is *IFFFormChunk, *IFFListChunk or *IFFCatChunk.
+// The sub-chunks in the tree are the same SubChunk as RIFF. (e.g. *OnMemorySubChunk, *InStreamSubChunk, *IncompleteSubChunk)
+type IFFRootChunk interface {
+	Chunk
+
+	iffRoot()
+}
+
+// IFFFormChunk is a FORM chunk of EA IFF 85. (e.g. "AIFF", "AIFC", "8SVX", "ILBM")
+type IFFFormChunk struct {
+	FormType [typeBytes]byte
+	Payload  []Chunk
+}
+
+var (
+	_ groupedChunk = (*IFFFormChunk)(nil)
+	_ IFFRootChunk = (*IFFFormChunk)(nil)
+)
+
+func (c *IFFFormChunk) ChunkID() []byte {
+	return formID[:]
+}
+
+func (c *IFFFormChunk) BodySize() uint32 {
+	return uint32(iffChunkFormat.bodySize(c))
+}
+
+func (c *IFFFormChunk) groupType() []byte {
+	return c.FormType[:]
+}
+
+func (c *IFFFormChunk) payload() []Chunk {
+	return c.Payload
+}
+
+func (c *IFFFormChunk) iffRoot() {}
+
+// IFFListChunk is a LIST chunk of EA IFF 85.
+// Payload starts with *IFFPropChunk for the properties shared by the following FORMs, and the rest are *IFFFormChunk, *IFFListChunk or *IFFCatChunk.
+type IFFListChunk struct {
+	ListType [typeBytes]byte
+	Payload  []Chunk
+}
+
+var (
+	_ groupedChunk = (*IFFListChunk)(nil)
+	_ IFFRootChunk = (*IFFListChunk)(nil)
+)
+
+func (c *IFFListChunk) ChunkID() []byte {
+	return listID[:]
+}
+
+func (c *IFFListChunk) BodySize() uint32 {
+	return uint32(iffChunkFormat.bodySize(c))
+}
+
+func (c *IFFListChunk) groupType() []byte {
+	return c.ListType[:]
+}
+
+func (c *IFFListChunk) payload() []Chunk {
+	return c.Payload
+}
+
+func (c *IFFListChunk) iffRoot() {}
+
+// Properties returns the property chunks shared by the FORMs of formType in the LIST.
+// The chunks of the later PROP override the earlier ones with the same ID.
+func (c *IFFListChunk) Properties(formType [typeBytes]byte) []Chunk {
+	return mergeIFFProperties(nil, c, formType)
+}
+
+// IFFCatChunk is a CAT chunk of EA IFF 85 to concatenate *IFFFormChunk, *IFFListChunk or *IFFCatChunk.
+// CatType is a hint of the contents, and it is "    " if the contents are mixed.
+type IFFCatChunk struct {
+	CatType [typeBytes]byte
+	Payload []Chunk
+}
+
+var (
+	_ groupedChunk = (*IFFCatChunk)(nil)
+	_ IFFRootChunk = (*IFFCatChunk)(nil)
+)
+
+func (c *IFFCatChunk) ChunkID() []byte {
+	return catID[:]
+}
+
+func (c *IFFCatChunk) BodySize() uint32 {
+	return uint32(iffChunkFormat.bodySize(c))
+}
+
+func (c *IFFCatChunk) groupType() []byte {
+	return c.CatType[:]
+}
+
+func (c *IFFCatChunk) payload() []Chunk {
+	return c.Payload
+}
+
+func (c *IFFCatChunk) iffRoot() {}
+
+// IFFPropChunk is a PROP chunk of EA IFF 85. It must be placed at the head of the LIST chunk.
+// Payload is the sub-chunks shared by the FORMs of PropType in the LIST.
+type IFFPropChunk struct {
+	PropType [typeBytes]byte
+	Payload  []Chunk
+}
+
+var _ groupedChunk = (*IFFPropChunk)(nil)
+
+func (c *IFFPropChunk) ChunkID() []byte {
+	return propID[:]
+}
+
+func (c *IFFPropChunk) BodySize() uint32 {
+	return uint32(iffChunkFormat.bodySize(c))
+}
+
+func (c *IFFPropChunk) groupType() []byte {
+	return c.PropType[:]
+}
+
+func (c *IFFPropChunk) payload() []Chunk {
+	return c.Payload
+}
+
+// WalkIFFForms calls fn with the all FORM chunks in c in order.
+// path is the path of the FORM in c (e.g. "LIST-AIFF/FORM-AIFF.1") and props is the property chunks inherited from the PROPs of the enclosing LISTs.
+// The properties of the inner LIST override the outer ones with the same ID.
+// It stops walking and returns the error if fn returns an error.
+func WalkIFFForms(c IFFRootChunk, fn func(path string, form *IFFFormChunk, props []Chunk) error) error {
+	return walkIFFForms(c, nil, nil, fn)
+}
+
+func walkIFFForms(c Chunk, path []string, scopes []*IFFListChunk, fn func(path string, form *IFFFormChunk, props []Chunk) error) error {
+	switch cc := c.(type) {
+	case *IFFFormChunk:
+		var props []Chunk
+		for _, l := range scopes {
+			props = mergeIFFProperties(props, l, cc.FormType)
+		}
+		if err := fn(joinChunkPath(path), cc, props); err != nil {
+			return err
+		}
+	case *IFFListChunk:
+		scopes = append(scopes[:len(scopes):len(scopes)], cc)
+	case *IFFCatChunk:
+	default:
+		return nil
+	}
+
+	names := chunkNamer{}
+	for _, p := range c.(groupedChunk).payload() {
+		if err := walkIFFForms(p, append(path[:len(path):len(path)], names.name(p)), scopes, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeIFFProperties merges the property chunks of formType in the LIST into props.
+func mergeIFFProperties(props []Chunk, l *IFFListChunk, formType [typeBytes]byte) []Chunk {
+	for _, p := range l.Payload {
+		prop, ok := p.(*IFFPropChunk)
+		if !ok || prop.PropType != formType {
+			continue
+		}
+
+	merge:
+		for _, chunk := range prop.Payload {
+			for i, q := range props {
+				if string(q.ChunkID()) == string(chunk.ChunkID()) {
+					props[i] = chunk
+					continue merge
+				}
+			}
+			props = append(props, chunk)
+		}
+	}
+	return props
+}
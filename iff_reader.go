@@ -0,0 +1,118 @@
+package riffbin
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+)
+
+// ReadIFFFull reads EA IFF 85 binary (e.g. AIFF, AIFF-C, 8SVX, ILBM) from io.Reader.
+// It creates IFFRootChunk with *OnMemorySubChunk for sub-chunks.
+func ReadIFFFull(r io.Reader, opts ...ReaderOption) (IFFRootChunk, error) {
+	return ReadIFFFullContext(context.Background(), r, nil, opts...)
+}
+
+// ReadIFFFullContext is same as ReadIFFFull, but it stops reading when ctx is done.
+// progress is called with the path of the chunk in reading and the total bytes read if it is not nil.
+func ReadIFFFullContext(ctx context.Context, r io.Reader, progress ProgressFunc, opts ...ReaderOption) (IFFRootChunk, error) {
+	return readIFF(&readState{ctx: ctx, src: r, progress: progress, cfg: newReaderConfig(opts), format: iffChunkFormat}, createOnMemorySubChunk)
+}
+
+// ReadIFFSections reads EA IFF 85 binary from io.ReadSeeker to use less memory than ReadIFFFull.
+// It creates IFFRootChunk with *InStreamSubChunk for sub-chunks.
+func ReadIFFSections(r PartialReader, opts ...ReaderOption) (IFFRootChunk, error) {
+	return ReadIFFSectionsContext(context.Background(), r, nil, opts...)
+}
+
+// ReadIFFSectionsContext is same as ReadIFFSections, but it stops reading when ctx is done.
+// progress is called with the path of the chunk in reading and the total bytes read or skipped if it is not nil.
+func ReadIFFSectionsContext(ctx context.Context, r PartialReader, progress ProgressFunc, opts ...ReaderOption) (IFFRootChunk, error) {
+	pos, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("get seek position: %w", err)
+	}
+
+	return readIFF(&readState{ctx: ctx, src: r, progress: progress, base: pos, cfg: newReaderConfig(opts), format: iffChunkFormat}, createInStreamSubChunk)
+}
+
+func readIFF(s *readState, f subChunkConstructorFn) (IFFRootChunk, error) {
+	chunk, err := readRoot(s, f)
+	if err != nil {
+		return nil, err
+	}
+	return chunk.(IFFRootChunk), nil
+}
+
+// iffTree is the chunk tree of EA IFF 85. The sub-chunks are same as RIFF.
+type iffTree struct {
+	riffTree
+}
+
+var _ chunkTree = iffTree{}
+
+func (iffTree) isRoot(id []byte) bool {
+	return bytes.Equal(formID[:], id) || bytes.Equal(listID[:], id) || bytes.Equal(catID[:], id)
+}
+
+func (iffTree) isGroup(id []byte) bool {
+	return isIFFGroupID(toFourCC(id))
+}
+
+func (iffTree) validChild(parent, id []byte, siblings []interface{}) bool {
+	return validIFFChild(toFourCC(parent), toFourCC(id), siblings)
+}
+
+func (iffTree) newGroup(id, typ []byte, payload []interface{}) interface{} {
+	var t [typeBytes]byte
+	copy(t[:], typ)
+
+	chunks := toChunks(payload)
+	switch toFourCC(id) {
+	case formID:
+		return &IFFFormChunk{FormType: t, Payload: chunks}
+	case listID:
+		return &IFFListChunk{ListType: t, Payload: chunks}
+	case catID:
+		return &IFFCatChunk{CatType: t, Payload: chunks}
+	case propID:
+		return &IFFPropChunk{PropType: t, Payload: chunks}
+	}
+	panic("should not reach here")
+}
+
+func toFourCC(b []byte) (id [idBytes]byte) {
+	copy(id[:], b)
+	return
+}
+
+func isIFFGroupID(id [idBytes]byte) bool {
+	return id == formID || id == listID || id == catID || id == propID
+}
+
+// validIFFChild returns true if the chunk of id can be placed after the siblings in the grouped chunk of parent.
+func validIFFChild(parent, id [idBytes]byte, siblings []interface{}) bool {
+	switch parent {
+	case formID:
+		// FORM contains the local chunks and the nested FORM, LIST and CAT
+		return id != propID
+	case propID:
+		// PROP contains the local chunks only
+		return !isIFFGroupID(id)
+	case catID:
+		return isIFFGroupID(id) && id != propID
+	case listID:
+		if id != propID {
+			return isIFFGroupID(id)
+		}
+
+		// PROPs must precede the other chunks
+		for _, c := range siblings {
+			if _, ok := c.(*IFFPropChunk); !ok {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
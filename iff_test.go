@@ -0,0 +1,220 @@
+package riffbin_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/karupanerura/riffbin"
+)
+
+func newAIFFChunk() *riffbin.IFFFormChunk {
+	return &riffbin.IFFFormChunk{
+		FormType: [4]byte{'A', 'I', 'F', 'F'},
+		Payload: []riffbin.Chunk{
+			&riffbin.OnMemorySubChunk{ID: [4]byte{'C', 'O', 'M', 'M'}, Payload: bytes.Repeat([]byte{0x01}, 18)},
+			// odd size to be padded
+			&riffbin.OnMemorySubChunk{ID: [4]byte{'N', 'A', 'M', 'E'}, Payload: []byte("aif")},
+			&riffbin.OnMemorySubChunk{ID: [4]byte{'S', 'S', 'N', 'D'}, Payload: []byte{0x00, 0x01, 0x02, 0x03}},
+		},
+	}
+}
+
+func TestIFFWriter(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if _, err := riffbin.NewIFFCompletedChunkWriter(&buf).Write(newAIFFChunk()); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []byte{
+		'F', 'O', 'R', 'M', 0x00, 0x00, 0x00, 0x36, 'A', 'I', 'F', 'F',
+		'C', 'O', 'M', 'M', 0x00, 0x00, 0x00, 0x12,
+		0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01,
+		'N', 'A', 'M', 'E', 0x00, 0x00, 0x00, 0x03, 'a', 'i', 'f', 0x00,
+		'S', 'S', 'N', 'D', 0x00, 0x00, 0x00, 0x04, 0x00, 0x01, 0x02, 0x03,
+	}
+	if !bytes.Equal(buf.Bytes(), expected) {
+		t.Fatalf("unexpected bytes: %s", hex.Dump(buf.Bytes()))
+	}
+
+	for name, read := range map[string]func([]byte, ...riffbin.ReaderOption) (riffbin.IFFRootChunk, error){
+		"ReadFull": func(b []byte, opts ...riffbin.ReaderOption) (riffbin.IFFRootChunk, error) {
+			return riffbin.ReadIFFFull(bytes.NewReader(b), opts...)
+		},
+		"ReadSections": func(b []byte, opts ...riffbin.ReaderOption) (riffbin.IFFRootChunk, error) {
+			return riffbin.ReadIFFSections(bytes.NewReader(b), opts...)
+		},
+	} {
+		read := read
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			locs := &riffbin.ChunkLocations{}
+			c, err := read(expected, riffbin.WithChunkLocations(locs))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			form := c.(*riffbin.IFFFormChunk)
+			if loc, ok := locs.Lookup(form.Payload[2]); !ok || loc.BodyOffset != 58 {
+				t.Errorf("unexpected location: %+v", loc)
+			}
+
+			var got bytes.Buffer
+			if _, err := riffbin.NewIFFCompletedChunkWriter(&got).Write(c); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got.Bytes(), expected) {
+				t.Errorf("unexpected round trip: %s", hex.Dump(got.Bytes()))
+			}
+		})
+	}
+}
+
+func TestIFFIncompleteChunkWriter(t *testing.T) {
+	t.Parallel()
+
+	c := newAIFFChunk()
+	c.Payload[2] = riffbin.NewIncompleteSubChunk([4]byte{'S', 'S', 'N', 'D'}, bytes.NewReader([]byte{0x00, 0x01, 0x02}))
+	c.Payload = append(c.Payload, &riffbin.OnMemorySubChunk{ID: [4]byte{'A', 'N', 'N', 'O'}, Payload: []byte("x")})
+
+	f := writeTempFile(t, nil)
+	w, err := riffbin.NewIFFIncompleteChunkWriter(&pureWriteSeeker{W: f})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := w.Write(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pos, _ := f.Seek(0, io.SeekCurrent); pos != n {
+		t.Errorf("unexpected seek position: %d (written: %d)", pos, n)
+	}
+
+	b, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := riffbin.ReadIFFFull(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	form := got.(*riffbin.IFFFormChunk)
+	if form.BodySize() != uint32(len(b)-riffbin.HeaderBytes) {
+		t.Errorf("unexpected body size: %d", form.BodySize())
+	}
+	if ssnd := form.Payload[2].(*riffbin.OnMemorySubChunk); !bytes.Equal(ssnd.Payload, []byte{0x00, 0x01, 0x02}) {
+		t.Errorf("unexpected SSND: %v", ssnd.Payload)
+	}
+	if anno := form.Payload[3].(*riffbin.OnMemorySubChunk); !bytes.Equal(anno.Payload, []byte("x")) {
+		t.Errorf("unexpected ANNO: %v", anno.Payload)
+	}
+}
+
+func TestWalkIFFForms(t *testing.T) {
+	t.Parallel()
+
+	svx := [4]byte{'8', 'S', 'V', 'X'}
+	vhdr1 := &riffbin.OnMemorySubChunk{ID: [4]byte{'V', 'H', 'D', 'R'}, Payload: []byte{0x01}}
+	vhdr2 := &riffbin.OnMemorySubChunk{ID: [4]byte{'V', 'H', 'D', 'R'}, Payload: []byte{0x02}}
+	name := &riffbin.OnMemorySubChunk{ID: [4]byte{'N', 'A', 'M', 'E'}, Payload: []byte("ab")}
+	body := func() riffbin.Chunk {
+		return &riffbin.OnMemorySubChunk{ID: [4]byte{'B', 'O', 'D', 'Y'}, Payload: []byte{0x00}}
+	}
+	c := &riffbin.IFFCatChunk{
+		CatType: [4]byte{' ', ' ', ' ', ' '},
+		Payload: []riffbin.Chunk{
+			&riffbin.IFFListChunk{
+				ListType: svx,
+				Payload: []riffbin.Chunk{
+					&riffbin.IFFPropChunk{PropType: svx, Payload: []riffbin.Chunk{vhdr1, name}},
+					&riffbin.IFFFormChunk{FormType: svx, Payload: []riffbin.Chunk{body()}},
+					&riffbin.IFFListChunk{
+						ListType: svx,
+						Payload: []riffbin.Chunk{
+							&riffbin.IFFPropChunk{PropType: svx, Payload: []riffbin.Chunk{vhdr2}},
+							&riffbin.IFFFormChunk{FormType: svx, Payload: []riffbin.Chunk{body()}},
+						},
+					},
+				},
+			},
+			&riffbin.IFFFormChunk{FormType: [4]byte{'I', 'L', 'B', 'M'}, Payload: []riffbin.Chunk{body()}},
+		},
+	}
+
+	// write and read back to parse the chunks as same as files
+	var buf bytes.Buffer
+	if _, err := riffbin.NewIFFCompletedChunkWriter(&buf).Write(c); err != nil {
+		t.Fatal(err)
+	}
+	root, err := riffbin.ReadIFFFull(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type form struct {
+		Path  string
+		Type  string
+		Props map[string][]byte
+	}
+	var got []form
+	err = riffbin.WalkIFFForms(root, func(path string, f *riffbin.IFFFormChunk, props []riffbin.Chunk) error {
+		m := map[string][]byte{}
+		for _, p := range props {
+			m[string(p.ChunkID())] = p.(*riffbin.OnMemorySubChunk).Payload
+		}
+		got = append(got, form{Path: path, Type: string(f.FormType[:]), Props: m})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []form{
+		{Path: "LIST-8SVX/FORM-8SVX", Type: "8SVX", Props: map[string][]byte{"VHDR": {0x01}, "NAME": []byte("ab")}},
+		{Path: "LIST-8SVX/LIST-8SVX/FORM-8SVX", Type: "8SVX", Props: map[string][]byte{"VHDR": {0x02}, "NAME": []byte("ab")}},
+		{Path: "FORM-ILBM", Type: "ILBM", Props: map[string][]byte{}},
+	}
+	if df := cmp.Diff(got, expected); df != "" {
+		t.Errorf("diff = %s", df)
+	}
+
+	if props := root.(*riffbin.IFFCatChunk).Payload[0].(*riffbin.IFFListChunk).Properties(svx); len(props) != 2 {
+		t.Errorf("unexpected properties: %d", len(props))
+	}
+}
+
+func TestReadIFFInvalid(t *testing.T) {
+	t.Parallel()
+
+	header := func(id string, size uint32) []byte {
+		return append([]byte(id), byte(size>>24), byte(size>>16), byte(size>>8), byte(size))
+	}
+	concat := func(bs ...[]byte) []byte {
+		return bytes.Join(bs, nil)
+	}
+
+	for name, input := range map[string][]byte{
+		"Empty":           {},
+		"RIFF":            concat([]byte("RIFF"), []byte{0x04, 0x00, 0x00, 0x00}, []byte("WAVE")),
+		"Truncated":       concat(header("FORM", 16), []byte("AIFF"), header("COMM", 4)),
+		"TooLong":         concat(header("FORM", 4), []byte("AIFF"), []byte{0x00}),
+		"PROPInFORM":      concat(header("FORM", 16), []byte("AIFF"), header("PROP", 4), []byte("AIFF")),
+		"PROPAfterFORM":   concat(header("LIST", 28), []byte("AIFF"), header("FORM", 4), []byte("AIFF"), header("PROP", 4), []byte("AIFF")),
+		"SubChunkInCAT":   concat(header("CAT ", 12), []byte("AIFF"), header("COMM", 0)),
+		"GroupInPROP":     concat(header("LIST", 28), []byte("AIFF"), header("PROP", 16), []byte("AIFF"), header("FORM", 4), []byte("AIFF")),
+		"TooShortGrouped": concat(header("CAT ", 12), []byte("AIFF"), header("FORM", 0)),
+	} {
+		if _, err := riffbin.ReadIFFFull(bytes.NewReader(input)); err != riffbin.ErrInvalidFormat {
+			t.Errorf("%s: unexpected error on ReadIFFFull: %v", name, err)
+		}
+		if _, err := riffbin.ReadIFFSections(bytes.NewReader(input)); err != riffbin.ErrInvalidFormat {
+			t.Errorf("%s: unexpected error on ReadIFFSections: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package riffbin
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// IFFCompletedChunkWriter is an EA IFF 85 chunk writer for the completed chunk.
+type IFFCompletedChunkWriter struct {
+	w io.Writer
+}
+
+// NewIFFCompletedChunkWriter creates a new IFFCompletedChunkWriter.
+func NewIFFCompletedChunkWriter(w io.Writer) *IFFCompletedChunkWriter {
+	return &IFFCompletedChunkWriter{w: w}
+}
+
+// Write writes the IFF message to the underlying data stream.
+// It returns the number of bytes written and any error encountered that caused the write to stop early. (same as Write of io.Writer)
+func (w *IFFCompletedChunkWriter) Write(c IFFRootChunk) (int64, error) {
+	return w.WriteContext(context.Background(), c, nil)
+}
+
+// WriteContext is same as Write, but it stops writing when ctx is done.
+// progress is called with the path of the chunk in writing and the total bytes written if it is not nil.
+func (w *IFFCompletedChunkWriter) WriteContext(ctx context.Context, c IFFRootChunk, progress ProgressFunc) (int64, error) {
+	return writeChunk(&writeState{ctx: ctx, w: w.w, progress: progress, format: iffChunkFormat}, c)
+}
+
+// IFFIncompleteChunkWriter is an EA IFF 85 chunk writer for the incomplete chunk.
+type IFFIncompleteChunkWriter struct {
+	w    io.WriteSeeker
+	head int64
+}
+
+// NewIFFIncompleteChunkWriter creates a new IFFIncompleteChunkWriter.
+func NewIFFIncompleteChunkWriter(w io.WriteSeeker) (*IFFIncompleteChunkWriter, error) {
+	pos, err := w.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("seek: %w", err)
+	}
+
+	return &IFFIncompleteChunkWriter{w: w, head: pos}, nil
+}
+
+// Write writes the IFF message to the underlying data stream, and re-write the bytes of the all chunk headers size to fix incomplete body bytes by random write.
+// It returns the number of bytes written and any error encountered that caused the write to stop early. (same as Write of io.Writer)
+func (w *IFFIncompleteChunkWriter) Write(c IFFRootChunk) (int64, error) {
+	return w.WriteContext(context.Background(), c, nil)
+}
+
+// WriteContext is same as Write, but it stops writing when ctx is done.
+// progress is called with the path of the chunk in writing and the total bytes written if it is not nil.
+// The chunk headers are not re-written if it is stopped by ctx.
+func (w *IFFIncompleteChunkWriter) WriteContext(ctx context.Context, c IFFRootChunk, progress ProgressFunc) (n int64, err error) {
+	n, err = writeChunk(&writeState{ctx: ctx, w: w.w, progress: progress, format: iffChunkFormat, allowIncomplete: true}, c)
+	if err != nil {
+		err = fmt.Errorf("writeChunk at first: %w", err)
+		return
+	}
+
+	err = patchBodySizes(w.w, iffChunkFormat, c, w.head, n)
+	return
+}
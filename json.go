@@ -0,0 +1,312 @@
+package riffbin
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// PayloadEncoding is an encoding of the sub-chunk payloads in JSON.
+type PayloadEncoding int
+
+const (
+	// PayloadEncodingHex encodes the payloads as hex strings.
+	PayloadEncodingHex PayloadEncoding = iota
+	// PayloadEncodingBase64 encodes the payloads as base64 strings.
+	PayloadEncodingBase64
+)
+
+const externalPayloadExt = ".bin"
+
+// JSONOption is an option for JSONEncoder and JSONDecoder.
+type JSONOption func(*jsonConfig)
+
+type jsonConfig struct {
+	encoding  PayloadEncoding
+	dir       string
+	threshold int64
+}
+
+func newJSONConfig(opts []JSONOption) jsonConfig {
+	var cfg jsonConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithPayloadEncoding sets the encoding of the payloads. (default: PayloadEncodingHex)
+func WithPayloadEncoding(enc PayloadEncoding) JSONOption {
+	return func(cfg *jsonConfig) {
+		cfg.encoding = enc
+	}
+}
+
+// WithExternalPayloads stores the payloads larger than threshold bytes as the external files in dir.
+// The files are named by the path of the chunk with ".bin" extension (e.g. "LIST-INFO/INAM.bin"), and referenced from JSON by the slash-separated path relative to dir.
+// JSONDecoder reads the referenced files from dir.
+func WithExternalPayloads(dir string, threshold int64) JSONOption {
+	return func(cfg *jsonConfig) {
+		cfg.dir = dir
+		cfg.threshold = threshold
+	}
+}
+
+// jsonChunk is a chunk in JSON.
+// ID and Type are escaped as same as the chunk paths (e.g. "%00" for NUL byte).
+type jsonChunk struct {
+	ID     string       `json:"id"`
+	Type   string       `json:"type,omitempty"`
+	Chunks []*jsonChunk `json:"chunks,omitempty"`
+	Hex    *string      `json:"hex,omitempty"`
+	Base64 *string      `json:"base64,omitempty"`
+	File   string       `json:"file,omitempty"`
+}
+
+// JSONEncoder encodes RIFF chunk to the human-reviewable JSON document.
+type JSONEncoder struct {
+	w   io.Writer
+	cfg jsonConfig
+}
+
+// NewJSONEncoder creates a new JSONEncoder.
+func NewJSONEncoder(w io.Writer, opts ...JSONOption) *JSONEncoder {
+	return &JSONEncoder{w: w, cfg: newJSONConfig(opts)}
+}
+
+// Encode encodes c as JSON. The sub-chunk payloads are read with the own cursor, so c can be still written after encoding.
+func (e *JSONEncoder) Encode(c *RIFFChunk) error {
+	root, err := e.encodeChunk(c, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(e.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(root)
+}
+
+// encodeChunk encodes c at chunkPath. filePath is the escaped path to write the payload as the external file.
+func (e *JSONEncoder) encodeChunk(c Chunk, chunkPath, filePath []string) (*jsonChunk, error) {
+	jc := &jsonChunk{ID: escapeFourCC(c.ChunkID())}
+	switch cc := c.(type) {
+	case *RIFFChunk:
+		jc.Type = escapeFourCC(cc.FormType[:])
+		return jc, e.encodePayload(jc, cc.Payload, chunkPath, filePath)
+	case *ListChunk:
+		jc.Type = escapeFourCC(cc.ListType[:])
+		payload, err := cc.Chunks()
+		if err != nil {
+			return nil, err
+		}
+		return jc, e.encodePayload(jc, payload, chunkPath, filePath)
+	case SubChunk:
+		r, err := openPayload(cc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", joinChunkPath(chunkPath), err)
+		}
+
+		if e.cfg.dir != "" && r.Size() > e.cfg.threshold {
+			jc.File = path.Join(filePath...) + externalPayloadExt
+			return jc, e.writeFile(jc.File, r)
+		}
+
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		var s string
+		switch e.cfg.encoding {
+		case PayloadEncodingBase64:
+			s = base64.StdEncoding.EncodeToString(b)
+			jc.Base64 = &s
+		default:
+			s = hex.EncodeToString(b)
+			jc.Hex = &s
+		}
+		return jc, nil
+	}
+	return nil, fmt.Errorf("unknown chunk: %T", c)
+}
+
+func (e *JSONEncoder) encodePayload(jc *jsonChunk, payload []Chunk, chunkPath, filePath []string) error {
+	names := chunkNamer{}
+	files := fileNamer{}
+	for _, c := range payload {
+		name := names.name(c)
+		child, err := e.encodeChunk(c, append(chunkPath[:len(chunkPath):len(chunkPath)], name), append(filePath[:len(filePath):len(filePath)], files.next(name)))
+		if err != nil {
+			return err
+		}
+		jc.Chunks = append(jc.Chunks, child)
+	}
+	return nil
+}
+
+// escapeFileName escapes the chunk name to be a portable file name. (e.g. "fmt%20" for "fmt ")
+// The bytes except [A-Za-z0-9._-] are escaped, and '%' is kept as it is because it is already used only for the escapes in the chunk names.
+// The first byte of the device names reserved on Windows (e.g. "CON", "COM1") is also escaped.
+func escapeFileName(name string) string {
+	var sb strings.Builder
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '.' || c == '_' || c == '-' || c == '%' {
+			sb.WriteByte(c)
+		} else {
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+
+	escaped := sb.String()
+	base := escaped
+	if i := strings.IndexByte(base, '.'); i >= 0 {
+		base = base[:i]
+	}
+	switch strings.ToUpper(base) {
+	case "CON", "PRN", "AUX", "NUL",
+		"COM1", "COM2", "COM3", "COM4", "COM5", "COM6", "COM7", "COM8", "COM9",
+		"LPT1", "LPT2", "LPT3", "LPT4", "LPT5", "LPT6", "LPT7", "LPT8", "LPT9":
+		escaped = fmt.Sprintf("%%%02X", escaped[0]) + escaped[1:]
+	}
+	return escaped
+}
+
+// fileNamer names the files of the sibling chunks uniquely on the case-insensitive file systems. (e.g. macOS, Windows)
+// The escaped name is suffixed with "~1", "~2", ... if it is used by the preceding siblings ignoring case, and '~' is never used by escapeFileName.
+type fileNamer map[string]bool
+
+func (n fileNamer) next(name string) string {
+	escaped := escapeFileName(name)
+	fileName := escaped
+	for i := 1; n[strings.ToLower(fileName)]; i++ {
+		fileName = fmt.Sprintf("%s~%d", escaped, i)
+	}
+	n[strings.ToLower(fileName)] = true
+	return fileName
+}
+
+func (e *JSONEncoder) writeFile(name string, r io.Reader) error {
+	p := filepath.Join(e.cfg.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// JSONDecoder decodes the JSON document encoded by JSONEncoder to RIFF chunk.
+type JSONDecoder struct {
+	r   io.Reader
+	cfg jsonConfig
+}
+
+// NewJSONDecoder creates a new JSONDecoder.
+func NewJSONDecoder(r io.Reader, opts ...JSONOption) *JSONDecoder {
+	return &JSONDecoder{r: r, cfg: newJSONConfig(opts)}
+}
+
+// Decode decodes JSON to *RIFFChunk with *ListChunk and *OnMemorySubChunk.
+// It is written by CompletedChunkWriter as the same bytes as the encoded chunk.
+func (d *JSONDecoder) Decode() (*RIFFChunk, error) {
+	var root jsonChunk
+	if err := json.NewDecoder(d.r).Decode(&root); err != nil {
+		return nil, err
+	}
+
+	c, err := d.decodeChunk(&root)
+	if err != nil {
+		return nil, err
+	}
+	riffChunk, ok := c.(*RIFFChunk)
+	if !ok {
+		return nil, fmt.Errorf("%w: root chunk is not RIFF", ErrInvalidFormat)
+	}
+	return riffChunk, nil
+}
+
+func (d *JSONDecoder) decodeChunk(jc *jsonChunk) (Chunk, error) {
+	id, err := decodeFourCC(jc.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.Equal(id[:], riffID[:]) || bytes.Equal(id[:], listID[:]) {
+		if jc.Hex != nil || jc.Base64 != nil || jc.File != "" {
+			return nil, fmt.Errorf("%w: grouped chunk %q has a payload", ErrInvalidFormat, jc.ID)
+		}
+		typ, err := decodeFourCC(jc.Type)
+		if err != nil {
+			return nil, err
+		}
+
+		payload := make([]Chunk, len(jc.Chunks))
+		for i, child := range jc.Chunks {
+			if child == nil {
+				return nil, fmt.Errorf("%w: null chunk in %q", ErrInvalidFormat, jc.ID)
+			}
+			if payload[i], err = d.decodeChunk(child); err != nil {
+				return nil, err
+			}
+		}
+
+		if bytes.Equal(id[:], riffID[:]) {
+			return &RIFFChunk{FormType: typ, Payload: payload}, nil
+		}
+		return &ListChunk{ListType: typ, Payload: payload}, nil
+	}
+
+	if jc.Type != "" || len(jc.Chunks) != 0 {
+		return nil, fmt.Errorf("%w: sub-chunk %q has a type or chunks", ErrInvalidFormat, jc.ID)
+	}
+
+	payload, err := d.decodePayload(jc)
+	if err != nil {
+		return nil, err
+	}
+	return &OnMemorySubChunk{ID: id, Payload: payload}, nil
+}
+
+func (d *JSONDecoder) decodePayload(jc *jsonChunk) ([]byte, error) {
+	switch {
+	case jc.Hex != nil && jc.Base64 == nil && jc.File == "":
+		return hex.DecodeString(*jc.Hex)
+	case jc.Hex == nil && jc.Base64 != nil && jc.File == "":
+		return base64.StdEncoding.DecodeString(*jc.Base64)
+	case jc.Hex == nil && jc.Base64 == nil && jc.File != "":
+		if d.cfg.dir == "" {
+			return nil, errors.New("external payload directory is not specified")
+		}
+		return fs.ReadFile(os.DirFS(d.cfg.dir), jc.File)
+	}
+	return nil, fmt.Errorf("%w: sub-chunk %q must have exactly one of hex, base64 or file", ErrInvalidFormat, jc.ID)
+}
+
+func decodeFourCC(s string) ([idBytes]byte, error) {
+	var id [idBytes]byte
+	b, err := unescapeFourCC(s)
+	if err != nil {
+		return id, fmt.Errorf("%w: %v", ErrInvalidFormat, err)
+	}
+	if len(b) != idBytes {
+		return id, fmt.Errorf("%w: %q is not 4 bytes", ErrInvalidFormat, s)
+	}
+	copy(id[:], b)
+	return id, nil
+}
@@ -0,0 +1,272 @@
+package riffbin_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/karupanerura/riffbin"
+)
+
+func TestJSON(t *testing.T) {
+	t.Parallel()
+
+	newChunk := func() *riffbin.RIFFChunk {
+		c := newComplexRIFFChunk()
+		c.Payload = append(c.Payload,
+			&riffbin.OnMemorySubChunk{ID: [4]byte{'E', 'N', 'T', '5'}, Payload: []byte{}},
+			&riffbin.OnMemorySubChunk{ID: [4]byte{'a', '/', 0x00, 0xFF}, Payload: bytes.Repeat([]byte{0xAB}, 100)},
+		)
+		return c
+	}
+
+	var src bytes.Buffer
+	if _, err := riffbin.NewCompletedChunkWriter(&src).Write(newChunk()); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range []struct {
+			name string
+			opts func(dir string) []riffbin.JSONOption
+		}{
+			{name: "Hex", opts: func(string) []riffbin.JSONOption { return nil }},
+			{name: "Base64", opts: func(string) []riffbin.JSONOption {
+				return []riffbin.JSONOption{riffbin.WithPayloadEncoding(riffbin.PayloadEncodingBase64)}
+			}},
+			{name: "External", opts: func(dir string) []riffbin.JSONOption {
+				return []riffbin.JSONOption{riffbin.WithExternalPayloads(dir, 4)}
+			}},
+		} {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				riffChunk, err := riffbin.ReadSections(bytes.NewReader(src.Bytes()))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				opts := tc.opts(t.TempDir())
+				var doc bytes.Buffer
+				if err := riffbin.NewJSONEncoder(&doc, opts...).Encode(riffChunk); err != nil {
+					t.Fatal(err)
+				}
+
+				decoded, err := riffbin.NewJSONDecoder(bytes.NewReader(doc.Bytes()), opts...).Decode()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				var buf bytes.Buffer
+				if _, err := riffbin.NewCompletedChunkWriter(&buf).Write(decoded); err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(buf.Bytes(), src.Bytes()) {
+					t.Error("unexpected bytes are written")
+					t.Log(doc.String())
+					t.Log(hex.Dump(buf.Bytes()))
+				}
+
+				// the source chunk is not consumed
+				buf.Reset()
+				if _, err := riffbin.NewCompletedChunkWriter(&buf).Write(riffChunk); err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(buf.Bytes(), src.Bytes()) {
+					t.Error("unexpected bytes are written")
+				}
+			})
+		}
+	})
+
+	t.Run("Document", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		var doc bytes.Buffer
+		if err := riffbin.NewJSONEncoder(&doc, riffbin.WithExternalPayloads(dir, 8)).Encode(&riffbin.RIFFChunk{
+			FormType: [4]byte{'W', 'A', 'V', 'E'},
+			Payload: []riffbin.Chunk{
+				&riffbin.OnMemorySubChunk{ID: [4]byte{'f', 'm', 't', ' '}, Payload: []byte{0x01, 0x00}},
+				&riffbin.OnMemorySubChunk{ID: [4]byte{'d', 'a', 't', 'a'}, Payload: []byte("0123456789")},
+				&riffbin.ListChunk{ListType: [4]byte{'I', 'N', 'F', 'O'}, Payload: []riffbin.Chunk{}},
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		const expected = `{
+  "id": "RIFF",
+  "type": "WAVE",
+  "chunks": [
+    {
+      "id": "fmt ",
+      "hex": "0100"
+    },
+    {
+      "id": "data",
+      "file": "data.bin"
+    },
+    {
+      "id": "LIST",
+      "type": "INFO"
+    }
+  ]
+}
+`
+		if doc.String() != expected {
+			t.Errorf("unexpected document: %s", doc.String())
+		}
+
+		got, err := os.ReadFile(filepath.Join(dir, "data.bin"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != "0123456789" {
+			t.Errorf("unexpected file content: %q", got)
+		}
+	})
+
+	t.Run("PortableFileNames", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		riffChunk := &riffbin.RIFFChunk{
+			FormType: [4]byte{'T', 'E', 'S', 'T'},
+			Payload: []riffbin.Chunk{
+				&riffbin.OnMemorySubChunk{ID: [4]byte{'a', '\\', ':', '*'}, Payload: []byte{0x01}},
+				&riffbin.ListChunk{
+					ListType: [4]byte{'?', '<', '>', '|'},
+					Payload: []riffbin.Chunk{
+						&riffbin.OnMemorySubChunk{ID: [4]byte{'f', 'm', 't', ' '}, Payload: []byte{0x02}},
+						&riffbin.OnMemorySubChunk{ID: [4]byte{'C', 'O', 'M', '1'}, Payload: []byte{0x03}},
+					},
+				},
+			},
+		}
+		var doc bytes.Buffer
+		if err := riffbin.NewJSONEncoder(&doc, riffbin.WithExternalPayloads(dir, 0)).Encode(riffChunk); err != nil {
+			t.Fatal(err)
+		}
+
+		for name, expected := range map[string]byte{
+			"a%5C%3A%2A.bin":               0x01,
+			"LIST-%3F%3C%3E%7C/fmt%20.bin": 0x02,
+			"LIST-%3F%3C%3E%7C/%43OM1.bin": 0x03,
+		} {
+			if got, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+				t.Error(err)
+			} else if !bytes.Equal(got, []byte{expected}) {
+				t.Errorf("unexpected file content of %s: %v", name, got)
+			}
+		}
+
+		got, err := riffbin.NewJSONDecoder(&doc, riffbin.WithExternalPayloads(dir, 0)).Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var expected, buf bytes.Buffer
+		if _, err := riffbin.NewCompletedChunkWriter(&buf).Write(got); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := riffbin.NewCompletedChunkWriter(&expected).Write(riffChunk); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf.Bytes(), expected.Bytes()) {
+			t.Error("unexpected bytes are decoded")
+		}
+	})
+
+	t.Run("CaseInsensitiveFileNames", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		riffChunk := &riffbin.RIFFChunk{
+			FormType: [4]byte{'T', 'E', 'S', 'T'},
+			Payload: []riffbin.Chunk{
+				&riffbin.OnMemorySubChunk{ID: [4]byte{'d', 'a', 't', 'a'}, Payload: []byte{0x01}},
+				&riffbin.OnMemorySubChunk{ID: [4]byte{'D', 'A', 'T', 'A'}, Payload: []byte{0x02}},
+				&riffbin.OnMemorySubChunk{ID: [4]byte{'D', 'a', 't', 'a'}, Payload: []byte{0x03}},
+				&riffbin.ListChunk{
+					ListType: [4]byte{'I', 'N', 'F', 'O'},
+					Payload:  []riffbin.Chunk{&riffbin.OnMemorySubChunk{ID: [4]byte{'I', 'N', 'A', 'M'}, Payload: []byte{0x04}}},
+				},
+				&riffbin.ListChunk{
+					ListType: [4]byte{'i', 'n', 'f', 'o'},
+					Payload:  []riffbin.Chunk{&riffbin.OnMemorySubChunk{ID: [4]byte{'I', 'N', 'A', 'M'}, Payload: []byte{0x05}}},
+				},
+			},
+		}
+		var doc bytes.Buffer
+		if err := riffbin.NewJSONEncoder(&doc, riffbin.WithExternalPayloads(dir, 0)).Encode(riffChunk); err != nil {
+			t.Fatal(err)
+		}
+
+		// the file names are unique ignoring case
+		for name, expected := range map[string]byte{
+			"data.bin":             0x01,
+			"DATA~1.bin":           0x02,
+			"Data~2.bin":           0x03,
+			"LIST-INFO/INAM.bin":   0x04,
+			"LIST-info~1/INAM.bin": 0x05,
+		} {
+			if got, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+				t.Error(err)
+			} else if !bytes.Equal(got, []byte{expected}) {
+				t.Errorf("unexpected file content of %s: %v", name, got)
+			}
+		}
+
+		got, err := riffbin.NewJSONDecoder(&doc, riffbin.WithExternalPayloads(dir, 0)).Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var expected, buf bytes.Buffer
+		if _, err := riffbin.NewCompletedChunkWriter(&buf).Write(got); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := riffbin.NewCompletedChunkWriter(&expected).Write(riffChunk); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf.Bytes(), expected.Bytes()) {
+			t.Error("unexpected bytes are decoded")
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range []struct {
+			name string
+			doc  string
+		}{
+			{name: "NotRIFF", doc: `{"id": "LIST", "type": "INFO"}`},
+			{name: "ShortID", doc: `{"id": "RIF", "type": "WAVE"}`},
+			{name: "InvalidEscape", doc: `{"id": "RIF%G0", "type": "WAVE"}`},
+			{name: "NoPayload", doc: `{"id": "RIFF", "type": "WAVE", "chunks": [{"id": "data"}]}`},
+			{name: "TwoPayloads", doc: `{"id": "RIFF", "type": "WAVE", "chunks": [{"id": "data", "hex": "", "base64": ""}]}`},
+			{name: "GroupedPayload", doc: `{"id": "RIFF", "type": "WAVE", "hex": "00"}`},
+			{name: "SubChunkChildren", doc: `{"id": "RIFF", "type": "WAVE", "chunks": [{"id": "data", "hex": "", "chunks": [{"id": "data", "hex": ""}]}]}`},
+		} {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				if _, err := riffbin.NewJSONDecoder(strings.NewReader(tc.doc)).Decode(); !errors.Is(err, riffbin.ErrInvalidFormat) {
+					t.Errorf("unexpected error: %v", err)
+				}
+			})
+		}
+
+		if _, err := riffbin.NewJSONDecoder(strings.NewReader(`{"id": "RIFF", "type": "WAVE", "chunks": [{"id": "data", "file": "../data.bin"}]}`), riffbin.WithExternalPayloads(t.TempDir(), 0)).Decode(); err == nil {
+			t.Error("file outside the directory should not be read")
+		}
+	})
+}
@@ -0,0 +1,178 @@
+package riffbin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// ErrClosed is an error for the access to the closed MappedFile.
+var ErrClosed = errors.New("mapped file already closed")
+
+// MappedFile is a RIFF file mapped on memory. (it is read into memory on the platforms without mmap support)
+// The payloads of the sub-chunks are the slices of the mapping, so they must not be used after Close.
+// Read, ReadAt and WriteTo of the sub-chunks hold the mapping while they access it, so Close waits them and they return ErrClosed after Close.
+type MappedFile struct {
+	data   []byte
+	unmap  func([]byte) error
+	mu     sync.RWMutex
+	closed bool
+
+	// RIFF is the parsed RIFF chunk with *MappedSubChunk for sub-chunks.
+	RIFF *RIFFChunk
+}
+
+// OpenMapped maps the RIFF file named by name on memory, and parses it.
+func OpenMapped(name string, opts ...ReaderOption) (*MappedFile, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat: %w", err)
+	}
+
+	data, unmap, err := mmapFile(f, stat.Size())
+	if err != nil {
+		return nil, fmt.Errorf("mmap: %w", err)
+	}
+
+	mf := &MappedFile{data: data, unmap: unmap}
+	cfg := newReaderConfig(opts)
+	mf.RIFF, err = read(&readState{ctx: context.Background(), src: bytes.NewReader(data), cfg: cfg, format: cfg.riffFormat()}, mf.createSubChunk)
+	if err != nil {
+		_ = unmap(data)
+		return nil, err
+	}
+
+	return mf, nil
+}
+
+// Bytes returns the whole mapped file. It returns nil after Close.
+// The returned slice is invalid after Close, and accessing it may crash the process, so the caller must not call Close until it finishes to use the slice.
+func (f *MappedFile) Bytes() []byte {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.closed {
+		return nil
+	}
+	return f.data
+}
+
+// Close unmaps the file. It waits the running accesses of the sub-chunks.
+// The sub-chunk payloads must not be used after Close.
+func (f *MappedFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return ErrClosed
+	}
+	f.closed = true
+	return f.unmap(f.data)
+}
+
+// access calls fn with holding the mapping. It returns ErrClosed without calling fn after Close.
+func (f *MappedFile) access(fn func() error) error {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.closed {
+		return ErrClosed
+	}
+	return fn()
+}
+
+func (f *MappedFile) createSubChunk(s *readState, r *io.LimitedReader, id []byte, bodyLen int64) (SubChunk, error) {
+	loc := s.location(bodyLen)
+	if bodyLen > r.N || loc.BodyOffset+bodyLen > int64(len(f.data)) {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	// skip sub-chunk body
+	_, err := s.src.(io.Seeker).Seek(bodyLen, io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("seek: %w", err)
+	}
+	consume(r, bodyLen)
+	s.advance(bodyLen)
+
+	chunk := &MappedSubChunk{file: f, payload: f.data[loc.BodyOffset : loc.BodyOffset+bodyLen]}
+	copy(chunk.ID[:], id)
+	return chunk, nil
+}
+
+// MappedSubChunk is a sub-chunk with the payload on the mapping of MappedFile.
+type MappedSubChunk struct {
+	ID [idBytes]byte
+
+	file    *MappedFile
+	payload []byte
+	once    sync.Once
+	r       *bytes.Reader
+}
+
+var _ SubChunk = (*MappedSubChunk)(nil)
+
+func (c *MappedSubChunk) ChunkID() []byte {
+	return c.ID[:]
+}
+
+func (c *MappedSubChunk) BodySize() uint32 {
+	return uint32(len(c.payload))
+}
+
+func (c *MappedSubChunk) Incomplete() bool {
+	return false
+}
+
+// Bytes returns the payload without copy. It returns nil after the MappedFile is closed.
+// The returned slice is invalid after the MappedFile is closed, same as (*MappedFile).Bytes.
+func (c *MappedSubChunk) Bytes() []byte {
+	if c.file.Bytes() == nil {
+		return nil
+	}
+	return c.payload
+}
+
+func (c *MappedSubChunk) Read(p []byte) (n int, err error) {
+	if accessErr := c.file.access(func() error {
+		n, err = c.reader().Read(p)
+		return nil
+	}); accessErr != nil {
+		return 0, accessErr
+	}
+	return
+}
+
+func (c *MappedSubChunk) ReadAt(p []byte, off int64) (n int, err error) {
+	if accessErr := c.file.access(func() error {
+		n, err = bytes.NewReader(c.payload).ReadAt(p, off)
+		return nil
+	}); accessErr != nil {
+		return 0, accessErr
+	}
+	return
+}
+
+func (c *MappedSubChunk) WriteTo(w io.Writer) (n int64, err error) {
+	if accessErr := c.file.access(func() error {
+		n, err = c.reader().WriteTo(w)
+		return nil
+	}); accessErr != nil {
+		return 0, accessErr
+	}
+	return
+}
+
+func (c *MappedSubChunk) reader() *bytes.Reader {
+	c.once.Do(func() {
+		c.r = bytes.NewReader(c.payload)
+	})
+	return c.r
+}
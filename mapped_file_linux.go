@@ -0,0 +1,22 @@
+//go:build linux
+// +build linux
+
+package riffbin
+
+import (
+	"os"
+	"syscall"
+)
+
+func mmapFile(f *os.File, size int64) ([]byte, func([]byte) error, error) {
+	if size == 0 {
+		// mmap(2) does not accept zero length
+		return []byte{}, func([]byte) error { return nil }, nil
+	}
+
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, syscall.Munmap, nil
+}
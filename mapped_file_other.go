@@ -0,0 +1,18 @@
+//go:build !linux
+// +build !linux
+
+package riffbin
+
+import (
+	"io"
+	"os"
+)
+
+func mmapFile(f *os.File, size int64) ([]byte, func([]byte) error, error) {
+	// fallback to read into memory
+	data := make([]byte, size)
+	if _, err := io.ReadFull(f, data); err != nil {
+		return nil, nil, err
+	}
+	return data, func([]byte) error { return nil }, nil
+}
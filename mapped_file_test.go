@@ -0,0 +1,146 @@
+package riffbin_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/karupanerura/riffbin"
+)
+
+func TestOpenMapped(t *testing.T) {
+	t.Parallel()
+
+	var src bytes.Buffer
+	if _, err := riffbin.NewCompletedChunkWriter(&src).Write(newComplexRIFFChunk()); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.CreateTemp("", "riffbin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Remove(f.Name()) })
+	if _, err := f.Write(src.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		t.Parallel()
+
+		locs := &riffbin.ChunkLocations{}
+		mf, err := riffbin.OpenMapped(f.Name(), riffbin.WithChunkLocations(locs))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(mf.Bytes(), src.Bytes()) {
+			t.Error("unexpected mapped bytes")
+		}
+
+		chunk := mf.RIFF.Payload[1].(*riffbin.MappedSubChunk)
+		if id := string(chunk.ChunkID()); id != "ENT5" {
+			t.Errorf("unexpected id: %q", id)
+		}
+		if got := chunk.Bytes(); !bytes.Equal(got, []byte{0x01, 0x02, 0x03, 0x04, 0x05}) {
+			t.Errorf("unexpected payload: %v", got)
+		}
+		if loc, _ := locs.Lookup(chunk); loc.BodyOffset != 86 {
+			t.Errorf("unexpected location: %+v", loc)
+		}
+
+		// round trip
+		var buf bytes.Buffer
+		if _, err := riffbin.NewCompletedChunkWriter(&buf).Write(mf.RIFF); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf.Bytes(), src.Bytes()) {
+			t.Error("unexpected bytes are written")
+		}
+
+		if err := mf.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := mf.Close(); !errors.Is(err, riffbin.ErrClosed) {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if chunk.Bytes() != nil {
+			t.Error("payload should be nil after close")
+		}
+		if _, err := io.ReadAll(chunk); !errors.Is(err, riffbin.ErrClosed) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("ConcurrentClose", func(t *testing.T) {
+		t.Parallel()
+
+		mf, err := riffbin.OpenMapped(f.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		chunk := mf.RIFF.Payload[1].(*riffbin.MappedSubChunk)
+
+		// the accesses racing with Close must not touch the unmapped memory
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				buf := make([]byte, 5)
+				for j := 0; j < 1000; j++ {
+					n, err := chunk.ReadAt(buf, 0)
+					if errors.Is(err, riffbin.ErrClosed) {
+						return
+					}
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					if !bytes.Equal(buf[:n], []byte{0x01, 0x02, 0x03, 0x04, 0x05}) {
+						t.Errorf("unexpected payload: %v", buf[:n])
+						return
+					}
+				}
+			}()
+		}
+		if err := mf.Close(); err != nil {
+			t.Fatal(err)
+		}
+		wg.Wait()
+	})
+
+	t.Run("InvalidFormat", func(t *testing.T) {
+		t.Parallel()
+
+		f, err := os.CreateTemp("", "riffbin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(f.Name())
+		if _, err := f.Write(src.Bytes()[:src.Len()-1]); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := riffbin.OpenMapped(f.Name()); err != riffbin.ErrInvalidFormat {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := riffbin.OpenMapped(f.Name() + ".notfound"); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
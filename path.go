@@ -0,0 +1,90 @@
+package riffbin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ProgressFunc is a callback to report the progress of reading or writing.
+// path is the slash-separated name of the chunk in processing (e.g. "LIST-INFO/ISFT", "." is the root RIFF chunk) and n is the total number of bytes processed so far.
+// The header bytes of a chunk are reported under the chunk itself by both of the readers and the writers.
+type ProgressFunc func(path string, n int64)
+
+// chunkNamer names the sibling chunks to be unique in the parent chunk.
+// The name of a sub-chunk is its ID, and the name of a grouped chunk is "ID-TYPE" (e.g. "LIST-INFO").
+// The second and later chunks with the same name are disambiguated by the index of the occurrence (e.g. "data.1").
+type chunkNamer map[string]int
+
+func (n chunkNamer) name(c Chunk) string {
+	var typ []byte
+	if cc, ok := c.(groupedChunk); ok {
+		typ = cc.groupType()
+	}
+	return n.next(c.ChunkID(), typ)
+}
+
+func (n chunkNamer) next(id, typ []byte) string {
+	return n.nextName(chunkBaseName(id, typ))
+}
+
+// nextName disambiguates the base name of the chunk.
+func (n chunkNamer) nextName(name string) string {
+	i := n[name]
+	n[name] = i + 1
+	if i == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s.%d", name, i)
+}
+
+// chunkBaseName returns the name of the chunk without the index of the occurrence.
+func chunkBaseName(id, typ []byte) string {
+	name := escapeFourCC(id)
+	if typ != nil {
+		name += "-" + escapeFourCC(typ)
+	}
+	return name
+}
+
+// escapeFourCC escapes the bytes to be a valid path element.
+func escapeFourCC(b []byte) string {
+	var sb strings.Builder
+	for _, c := range b {
+		if c < 0x20 || c >= 0x7f || c == '/' || c == '%' {
+			fmt.Fprintf(&sb, "%%%02X", c)
+		} else {
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// unescapeFourCC unescapes the string escaped by escapeFourCC.
+func unescapeFourCC(s string) ([]byte, error) {
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' {
+			b = append(b, s[i])
+			continue
+		}
+		if i+2 >= len(s) {
+			return nil, fmt.Errorf("invalid escape: %q", s)
+		}
+
+		c, err := strconv.ParseUint(s[i+1:i+3], 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid escape: %q", s)
+		}
+		b = append(b, byte(c))
+		i += 2
+	}
+	return b, nil
+}
+
+func joinChunkPath(path []string) string {
+	if len(path) == 0 {
+		return "."
+	}
+	return strings.Join(path, "/")
+}
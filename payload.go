@@ -0,0 +1,537 @@
+package riffbin
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Marshal encodes v into the payload of the sub-chunk of id.
+// If v or the pointer to v implements encoding.BinaryMarshaler, its MarshalBinary is used.
+// Otherwise v must be a struct or a pointer to a struct, and its exported fields are encoded in order by the `riff` struct tags.
+//
+// The tag is "-" to skip the field, or the comma separated options:
+//
+//	be       encode the field in big-endian (the nested fields inherit it)
+//	le       encode the field in little-endian (default)
+//	size=N   the string is N bytes padded with NUL bytes, or the slice has N elements
+//	nul      the string is terminated by a NUL byte
+//	count=F  the slice has the elements as many as the preceding integer field F
+//	rest     the string or the slice is the rest of the payload (the last field only)
+//
+// The integers, the floats, the arrays and the nested structs are encoded in the fixed size without the options.
+// The nested fields implementing encoding.BinaryMarshaler and encoding.BinaryUnmarshaler are encoded by them in size=N bytes or the rest.
+func Marshal(id [idBytes]byte, v interface{}) (*OnMemorySubChunk, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if !rv.IsValid() {
+		return nil, fmt.Errorf("cannot marshal %T into the payload", v)
+	}
+	if !rv.CanAddr() {
+		// copy the value to use the marshalers of the pointer receivers as same as the pointer to it
+		p := reflect.New(rv.Type())
+		p.Elem().Set(rv)
+		rv = p.Elem()
+	}
+
+	if m, ok := payloadMarshaler(rv); ok {
+		b, err := m.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		return &OnMemorySubChunk{ID: id, Payload: b}, nil
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot marshal %T into the payload", v)
+	}
+	b, err := appendPayloadStruct([]byte{}, rv, binary.LittleEndian)
+	if err != nil {
+		return nil, err
+	}
+	return &OnMemorySubChunk{ID: id, Payload: b}, nil
+}
+
+// Unmarshal decodes the payload of c into v.
+// If v implements encoding.BinaryUnmarshaler, its UnmarshalBinary is used.
+// Otherwise v must be a pointer to a struct, and it is decoded by the `riff` struct tags as Marshal.
+// The payload is read with the own cursor by ReadPayload, so c must be *OnMemorySubChunk or io.ReaderAt (e.g. *InStreamSubChunk).
+// The bytes after the last field are ignored.
+func Unmarshal(c SubChunk, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("cannot unmarshal the payload into %T", v)
+	}
+
+	b, err := ReadPayload(c)
+	if err != nil {
+		return err
+	}
+	if u, ok := v.(encoding.BinaryUnmarshaler); ok {
+		return u.UnmarshalBinary(b)
+	}
+	if rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot unmarshal the payload into %T", v)
+	}
+
+	d := &payloadDecoder{data: b}
+	return d.decodeStruct(rv.Elem(), binary.LittleEndian)
+}
+
+// ReadPayload reads the whole payload of c with the own cursor, so c can be still read or written after reading.
+// It returns an error if c is incomplete, or its payload is not on memory nor io.ReaderAt.
+func ReadPayload(c SubChunk) ([]byte, error) {
+	r, err := openPayload(c)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+// payloadTag is the parsed `riff` struct tag.
+type payloadTag struct {
+	skip  bool
+	order binary.ByteOrder
+	size  int // -1 if absent
+	nul   bool
+	count string
+	rest  bool
+}
+
+func parsePayloadTag(sf reflect.StructField, order binary.ByteOrder) (payloadTag, error) {
+	tag := payloadTag{order: order, size: -1}
+	s, ok := sf.Tag.Lookup("riff")
+	if !ok || s == "" {
+		return tag, nil
+	}
+	if s == "-" {
+		tag.skip = true
+		return tag, nil
+	}
+
+	for _, opt := range strings.Split(s, ",") {
+		switch {
+		case opt == "be":
+			tag.order = binary.BigEndian
+		case opt == "le":
+			tag.order = binary.LittleEndian
+		case opt == "nul":
+			tag.nul = true
+		case opt == "rest":
+			tag.rest = true
+		case strings.HasPrefix(opt, "size="):
+			n, err := strconv.Atoi(strings.TrimPrefix(opt, "size="))
+			if err != nil || n < 0 {
+				return tag, fmt.Errorf("%s: invalid size option %q", sf.Name, opt)
+			}
+			tag.size = n
+		case strings.HasPrefix(opt, "count="):
+			tag.count = strings.TrimPrefix(opt, "count=")
+		default:
+			return tag, fmt.Errorf("%s: unknown option %q", sf.Name, opt)
+		}
+	}
+	return tag, nil
+}
+
+// payloadFields returns the tags of the exported fields of t, or nil for the skipped or unexported fields.
+func payloadFields(t reflect.Type, order binary.ByteOrder) ([]*payloadTag, error) {
+	tags := make([]*payloadTag, t.NumField())
+	last := -1
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		tag, err := parsePayloadTag(sf, order)
+		if err != nil {
+			return nil, err
+		}
+		if tag.skip {
+			continue
+		}
+		if last >= 0 && tags[last].rest {
+			return nil, fmt.Errorf("%s: rest option is not the last field", t.Field(last).Name)
+		}
+		if tag.count != "" {
+			cf, ok := t.FieldByName(tag.count)
+			if !ok || cf.Index[0] >= i || tags[cf.Index[0]] == nil {
+				return nil, fmt.Errorf("%s: count field %q is not a preceding field", sf.Name, tag.count)
+			}
+			switch cf.Type.Kind() {
+			case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			default:
+				return nil, fmt.Errorf("%s: count field %q is not an integer", sf.Name, tag.count)
+			}
+		}
+		tags[i] = &tag
+		last = i
+	}
+	return tags, nil
+}
+
+func appendPayloadStruct(b []byte, v reflect.Value, order binary.ByteOrder) ([]byte, error) {
+	tags, err := payloadFields(v.Type(), order)
+	if err != nil {
+		return nil, err
+	}
+	for i, tag := range tags {
+		if tag == nil {
+			continue
+		}
+
+		name := v.Type().Field(i).Name
+		fv := v.Field(i)
+		if tag.count != "" {
+			if n := countValue(v.FieldByName(tag.count)); n != fv.Len() {
+				return nil, fmt.Errorf("%s: %d elements mismatch %s %d", name, fv.Len(), tag.count, n)
+			}
+		}
+		if b, err = appendPayloadValue(b, fv, *tag); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return b, nil
+}
+
+var (
+	binaryMarshalerType   = reflect.TypeOf((*encoding.BinaryMarshaler)(nil)).Elem()
+	binaryUnmarshalerType = reflect.TypeOf((*encoding.BinaryUnmarshaler)(nil)).Elem()
+)
+
+// payloadMarshaler returns v or the pointer to v if it implements encoding.BinaryMarshaler.
+func payloadMarshaler(v reflect.Value) (encoding.BinaryMarshaler, bool) {
+	if v.Type().Implements(binaryMarshalerType) {
+		return v.Interface().(encoding.BinaryMarshaler), true
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(binaryMarshalerType) {
+		return v.Addr().Interface().(encoding.BinaryMarshaler), true
+	}
+	return nil, false
+}
+
+// payloadUnmarshaler returns the pointer to v if it implements encoding.BinaryUnmarshaler.
+func payloadUnmarshaler(v reflect.Value) (encoding.BinaryUnmarshaler, bool) {
+	if v.CanAddr() && v.Addr().Type().Implements(binaryUnmarshalerType) {
+		return v.Addr().Interface().(encoding.BinaryUnmarshaler), true
+	}
+	return nil, false
+}
+
+func appendPayloadValue(b []byte, v reflect.Value, tag payloadTag) ([]byte, error) {
+	if m, ok := payloadMarshaler(v); ok {
+		if tag.size < 0 && !tag.rest {
+			return nil, errors.New("encoding.BinaryMarshaler requires size or rest option")
+		}
+		p, err := m.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		if tag.size >= 0 && len(p) != tag.size {
+			return nil, fmt.Errorf("%d bytes mismatch size %d", len(p), tag.size)
+		}
+		return append(b, p...), nil
+	}
+
+	var buf [8]byte
+	switch v.Kind() {
+	case reflect.Uint8, reflect.Int8:
+		return append(b, byte(intBits(v))), nil
+	case reflect.Uint16, reflect.Int16:
+		tag.order.PutUint16(buf[:], uint16(intBits(v)))
+		return append(b, buf[:2]...), nil
+	case reflect.Uint32, reflect.Int32:
+		tag.order.PutUint32(buf[:], uint32(intBits(v)))
+		return append(b, buf[:4]...), nil
+	case reflect.Uint64, reflect.Int64:
+		tag.order.PutUint64(buf[:], intBits(v))
+		return append(b, buf[:8]...), nil
+	case reflect.Float32:
+		tag.order.PutUint32(buf[:], math.Float32bits(float32(v.Float())))
+		return append(b, buf[:4]...), nil
+	case reflect.Float64:
+		tag.order.PutUint64(buf[:], math.Float64bits(v.Float()))
+		return append(b, buf[:8]...), nil
+	case reflect.Struct:
+		return appendPayloadStruct(b, v, tag.order)
+	case reflect.Array:
+		return appendPayloadElements(b, v, tag)
+	case reflect.String:
+		s := v.String()
+		switch {
+		case tag.size >= 0:
+			if len(s) > tag.size {
+				return nil, fmt.Errorf("%q exceeds %d bytes", s, tag.size)
+			}
+			b = append(b, s...)
+			return append(b, make([]byte, tag.size-len(s))...), nil
+		case tag.nul:
+			return append(append(b, s...), 0), nil
+		case tag.rest:
+			return append(b, s...), nil
+		}
+		return nil, errors.New("string requires size, nul or rest option")
+	case reflect.Slice:
+		if tag.size >= 0 && v.Len() != tag.size {
+			return nil, fmt.Errorf("%d elements mismatch size %d", v.Len(), tag.size)
+		}
+		if tag.size < 0 && tag.count == "" && !tag.rest {
+			return nil, errors.New("slice requires size, count or rest option")
+		}
+		return appendPayloadElements(b, v, tag)
+	}
+	return nil, fmt.Errorf("unsupported type %s", v.Type())
+}
+
+func appendPayloadElements(b []byte, v reflect.Value, tag payloadTag) ([]byte, error) {
+	elemTag := payloadTag{order: tag.order, size: -1}
+	for i := 0; i < v.Len(); i++ {
+		var err error
+		if b, err = appendPayloadValue(b, v.Index(i), elemTag); err != nil {
+			return nil, fmt.Errorf("[%d]: %w", i, err)
+		}
+	}
+	return b, nil
+}
+
+// intBits returns the bits of the integer value in two's complement.
+func intBits(v reflect.Value) uint64 {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return uint64(v.Int())
+	}
+	return v.Uint()
+}
+
+func countValue(v reflect.Value) int {
+	return int(intBits(v))
+}
+
+// payloadDecoder decodes the payload from the head.
+type payloadDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *payloadDecoder) next(n int) ([]byte, error) {
+	if n < 0 || len(d.data)-d.pos < n {
+		return nil, fmt.Errorf("payload is too short at offset %d: %w", d.pos, ErrInvalidFormat)
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *payloadDecoder) decodeStruct(v reflect.Value, order binary.ByteOrder) error {
+	tags, err := payloadFields(v.Type(), order)
+	if err != nil {
+		return err
+	}
+	for i, tag := range tags {
+		if tag == nil {
+			continue
+		}
+
+		count := -1
+		if tag.count != "" {
+			cv := v.FieldByName(tag.count)
+			if count = countValue(cv); count < 0 {
+				return fmt.Errorf("%s: negative count %s %v: %w", v.Type().Field(i).Name, tag.count, cv, ErrInvalidFormat)
+			}
+		}
+		if err := d.decodeValue(v.Field(i), *tag, count); err != nil {
+			return fmt.Errorf("%s: %w", v.Type().Field(i).Name, err)
+		}
+	}
+	return nil
+}
+
+func (d *payloadDecoder) decodeValue(v reflect.Value, tag payloadTag, count int) error {
+	if u, ok := payloadUnmarshaler(v); ok {
+		var b []byte
+		switch {
+		case tag.size >= 0:
+			var err error
+			if b, err = d.next(tag.size); err != nil {
+				return err
+			}
+		case tag.rest:
+			b, _ = d.next(len(d.data) - d.pos)
+		default:
+			return errors.New("encoding.BinaryUnmarshaler requires size or rest option")
+		}
+		return u.UnmarshalBinary(b)
+	}
+
+	switch v.Kind() {
+	case reflect.Uint8, reflect.Int8, reflect.Uint16, reflect.Int16, reflect.Uint32, reflect.Int32, reflect.Uint64, reflect.Int64:
+		b, err := d.next(int(v.Type().Size()))
+		if err != nil {
+			return err
+		}
+
+		var bits uint64
+		switch len(b) {
+		case 1:
+			bits = uint64(b[0])
+		case 2:
+			bits = uint64(tag.order.Uint16(b))
+		case 4:
+			bits = uint64(tag.order.Uint32(b))
+		case 8:
+			bits = tag.order.Uint64(b)
+		}
+		switch v.Kind() {
+		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			v.SetInt(signExtend(bits, len(b)))
+		default:
+			v.SetUint(bits)
+		}
+		return nil
+	case reflect.Float32:
+		b, err := d.next(4)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(float64(math.Float32frombits(tag.order.Uint32(b))))
+		return nil
+	case reflect.Float64:
+		b, err := d.next(8)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(math.Float64frombits(tag.order.Uint64(b)))
+		return nil
+	case reflect.Struct:
+		return d.decodeStruct(v, tag.order)
+	case reflect.Array:
+		return d.decodeElements(v, tag)
+	case reflect.String:
+		switch {
+		case tag.size >= 0:
+			b, err := d.next(tag.size)
+			if err != nil {
+				return err
+			}
+			v.SetString(trimNUL(b))
+			return nil
+		case tag.nul:
+			i := 0
+			for d.pos+i < len(d.data) && d.data[d.pos+i] != 0 {
+				i++
+			}
+			b, err := d.next(i + 1)
+			if err != nil {
+				return fmt.Errorf("string is not terminated by NUL: %w", ErrInvalidFormat)
+			}
+			v.SetString(string(b[:i]))
+			return nil
+		case tag.rest:
+			b, _ := d.next(len(d.data) - d.pos)
+			v.SetString(trimNUL(b))
+			return nil
+		}
+		return errors.New("string requires size, nul or rest option")
+	case reflect.Slice:
+		switch {
+		case tag.size >= 0:
+			count = tag.size
+		case count >= 0:
+		case tag.rest:
+			s := reflect.MakeSlice(v.Type(), 0, 0)
+			for i := 0; d.pos < len(d.data); i++ {
+				pos := d.pos
+				s = reflect.Append(s, reflect.Zero(v.Type().Elem()))
+				if err := d.decodeValue(s.Index(i), payloadTag{order: tag.order, size: -1}, -1); err != nil {
+					return fmt.Errorf("[%d]: %w", i, err)
+				}
+				if d.pos == pos {
+					return fmt.Errorf("zero size element %s cannot be the rest", v.Type().Elem())
+				}
+			}
+			v.Set(s)
+			return nil
+		default:
+			return errors.New("slice requires size, count or rest option")
+		}
+		elem := v.Type().Elem()
+		if elem.Size() == 0 && !reflect.PtrTo(elem).Implements(binaryUnmarshalerType) {
+			// the zero size elements are decoded from no bytes
+			v.Set(reflect.MakeSlice(v.Type(), count, count))
+			return nil
+		}
+		least := minPayloadBytes(elem, payloadTag{order: tag.order, size: -1})
+		if least == 0 {
+			// limit the allocation by the payload for the elements which may consume no bytes
+			least = 1
+		}
+		if count > (len(d.data)-d.pos)/least {
+			return fmt.Errorf("%d elements exceed the payload: %w", count, ErrInvalidFormat)
+		}
+		v.Set(reflect.MakeSlice(v.Type(), count, count))
+		return d.decodeElements(v, tag)
+	}
+	return fmt.Errorf("unsupported type %s", v.Type())
+}
+
+func (d *payloadDecoder) decodeElements(v reflect.Value, tag payloadTag) error {
+	elemTag := payloadTag{order: tag.order, size: -1}
+	for i := 0; i < v.Len(); i++ {
+		if err := d.decodeValue(v.Index(i), elemTag, -1); err != nil {
+			return fmt.Errorf("[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// minPayloadBytes returns the least bytes to decode the value of t with tag.
+func minPayloadBytes(t reflect.Type, tag payloadTag) int {
+	if reflect.PtrTo(t).Implements(binaryUnmarshalerType) {
+		if tag.size >= 0 {
+			return tag.size
+		}
+		return 0
+	}
+
+	switch t.Kind() {
+	case reflect.Uint8, reflect.Int8, reflect.Uint16, reflect.Int16, reflect.Uint32, reflect.Int32, reflect.Uint64, reflect.Int64, reflect.Float32, reflect.Float64:
+		return int(t.Size())
+	case reflect.Array:
+		return t.Len() * minPayloadBytes(t.Elem(), payloadTag{order: tag.order, size: -1})
+	case reflect.Struct:
+		tags, err := payloadFields(t, tag.order)
+		if err != nil {
+			return 0
+		}
+		n := 0
+		for i, ft := range tags {
+			if ft != nil {
+				n += minPayloadBytes(t.Field(i).Type, *ft)
+			}
+		}
+		return n
+	case reflect.String:
+		if tag.size >= 0 {
+			return tag.size
+		}
+		if tag.nul {
+			return 1
+		}
+	case reflect.Slice:
+		if tag.size >= 0 {
+			return tag.size * minPayloadBytes(t.Elem(), payloadTag{order: tag.order, size: -1})
+		}
+	}
+	return 0
+}
+
+// signExtend returns the signed integer of the n bytes bits.
+func signExtend(bits uint64, n int) int64 {
+	shift := uint(64 - n*8)
+	return int64(bits<<shift) >> shift
+}
@@ -0,0 +1,361 @@
+package riffbin_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/karupanerura/riffbin"
+)
+
+type testWaveFormat struct {
+	FormatTag      uint16
+	Channels       uint16
+	SamplesPerSec  uint32
+	AvgBytesPerSec uint32
+	BlockAlign     uint16
+	BitsPerSample  uint16
+}
+
+type testTrack struct {
+	Index   uint16
+	TrackID string `riff:"size=12"`
+}
+
+type testPayload struct {
+	Format   testWaveFormat
+	Gain     int16
+	Peak     float32 `riff:"be"`
+	Tag      [4]byte
+	Name     string                `riff:"nul"`
+	Big      struct{ A, B uint16 } `riff:"be"`
+	Tracks   uint8
+	Reserved [2]uint8
+	Track    []testTrack `riff:"count=Tracks"`
+	Pair     []int8      `riff:"size=2"`
+	internal int
+	Ignored  string `riff:"-"`
+	Extra    []byte `riff:"rest"`
+}
+
+// readerSubChunk is a sub-chunk which implements only io.Reader.
+type readerSubChunk struct {
+	io.Reader
+	size uint32
+}
+
+func (c readerSubChunk) ChunkID() []byte {
+	return []byte("data")
+}
+
+func (c readerSubChunk) BodySize() uint32 {
+	return c.size
+}
+
+func (c readerSubChunk) Incomplete() bool {
+	return false
+}
+
+func TestMarshal(t *testing.T) {
+	t.Parallel()
+
+	v := testPayload{
+		Format:  testWaveFormat{FormatTag: 1, Channels: 1, SamplesPerSec: 44100, AvgBytesPerSec: 88200, BlockAlign: 2, BitsPerSample: 16},
+		Gain:    -2,
+		Peak:    1.0,
+		Tag:     [4]byte{'a', 'b', 'c', 'd'},
+		Name:    "hi",
+		Big:     struct{ A, B uint16 }{A: 1, B: 2},
+		Tracks:  1,
+		Track:   []testTrack{{Index: 3, TrackID: "ATU_00000001"}},
+		Pair:    []int8{-1, 1},
+		Ignored: "ignored",
+		Extra:   []byte{0xFF},
+	}
+	c, err := riffbin.Marshal([4]byte{'t', 'e', 's', 't'}, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []byte{
+		0x01, 0x00, 0x01, 0x00, 0x44, 0xAC, 0x00, 0x00, 0x88, 0x58, 0x01, 0x00, 0x02, 0x00, 0x10, 0x00, // Format
+		0xFE, 0xFF, // Gain
+		0x3F, 0x80, 0x00, 0x00, // Peak
+		'a', 'b', 'c', 'd', // Tag
+		'h', 'i', 0x00, // Name
+		0x00, 0x01, 0x00, 0x02, // Big
+		0x01,       // Tracks
+		0x00, 0x00, // Reserved
+		0x03, 0x00, 'A', 'T', 'U', '_', '0', '0', '0', '0', '0', '0', '0', '1', // Track
+		0xFF, 0x01, // Pair
+		0xFF, // Extra
+	}
+	if c.ID != [4]byte{'t', 'e', 's', 't'} {
+		t.Errorf("unexpected ID: %q", c.ID)
+	}
+	if df := cmp.Diff(c.Payload, expected); df != "" {
+		t.Errorf("payload diff = %s", df)
+	}
+
+	// the sub-chunk read from the stream can be unmarshaled
+	var got testPayload
+	sc := &riffbin.InStreamSubChunk{ID: c.ID, SectionReader: io.NewSectionReader(bytes.NewReader(c.Payload), 0, int64(len(c.Payload)))}
+	if err := riffbin.Unmarshal(sc, &got); err != nil {
+		t.Fatal(err)
+	}
+	v.Ignored = ""
+	if df := cmp.Diff(got, v, cmp.AllowUnexported(testPayload{})); df != "" {
+		t.Errorf("diff = %s", df)
+	}
+}
+
+func TestMarshalBinaryMarshaler(t *testing.T) {
+	t.Parallel()
+
+	ext := &riffbin.BroadcastExtension{Description: "desc", Version: 2, CodingHistory: "A=PCM\r\n"}
+	c, err := riffbin.Marshal([4]byte{'b', 'e', 'x', 't'}, ext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := ext.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(c.Payload, expected) {
+		t.Errorf("unexpected payload: %v", c.Payload)
+	}
+
+	var got riffbin.BroadcastExtension
+	if err := riffbin.Unmarshal(c, &got); err != nil {
+		t.Fatal(err)
+	}
+	if df := cmp.Diff(&got, ext); df != "" {
+		t.Errorf("diff = %s", df)
+	}
+}
+
+// testFourCC is encoded by its own marshaler in reversed order.
+type testFourCC string
+
+func (c testFourCC) MarshalBinary() ([]byte, error) {
+	b := []byte(c)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return b, nil
+}
+
+func (c *testFourCC) UnmarshalBinary(b []byte) error {
+	r, _ := testFourCC(b).MarshalBinary()
+	*c = testFourCC(r)
+	return nil
+}
+
+type testNestedMarshaler struct {
+	Code testFourCC `riff:"size=4"`
+	N    uint8
+	Zero []struct{} `riff:"count=N"`
+	Rest testFourCC `riff:"rest"`
+}
+
+func TestMarshalNestedBinaryMarshaler(t *testing.T) {
+	t.Parallel()
+
+	v := testNestedMarshaler{Code: "abcd", N: 3, Zero: make([]struct{}, 3), Rest: "xyz"}
+	c, err := riffbin.Marshal([4]byte{'t', 'e', 's', 't'}, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []byte{'d', 'c', 'b', 'a', 0x03, 'z', 'y', 'x'}; !bytes.Equal(c.Payload, expected) {
+		t.Errorf("unexpected payload: %q", c.Payload)
+	}
+
+	var got testNestedMarshaler
+	if err := riffbin.Unmarshal(c, &got); err != nil {
+		t.Fatal(err)
+	}
+	if df := cmp.Diff(got, v); df != "" {
+		t.Errorf("diff = %s", df)
+	}
+}
+
+// testPtrCode is encoded by its own marshaler of the pointer receiver in reversed order.
+type testPtrCode [2]byte
+
+func (c *testPtrCode) MarshalBinary() ([]byte, error) {
+	return []byte{c[1], c[0]}, nil
+}
+
+func (c *testPtrCode) UnmarshalBinary(b []byte) error {
+	c[0], c[1] = b[1], b[0]
+	return nil
+}
+
+func TestMarshalByValue(t *testing.T) {
+	t.Parallel()
+
+	// the marshalers of the pointer receivers are used for the values as same as the pointers
+	v := struct {
+		Code testPtrCode `riff:"size=2"`
+		N    uint8
+	}{Code: testPtrCode{'a', 'b'}, N: 1}
+	ext := riffbin.BroadcastExtension{Description: "desc", Version: 2}
+	for name, values := range map[string][2]interface{}{
+		"Nested": {v, &v},
+		"Root":   {ext, &ext},
+	} {
+		byValue, err := riffbin.Marshal([4]byte{'t', 'e', 's', 't'}, values[0])
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		byPointer, err := riffbin.Marshal([4]byte{'t', 'e', 's', 't'}, values[1])
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !bytes.Equal(byValue.Payload, byPointer.Payload) {
+			t.Errorf("%s: unexpected payload: %q (expected: %q)", name, byValue.Payload, byPointer.Payload)
+		}
+	}
+
+	c, err := riffbin.Marshal([4]byte{'t', 'e', 's', 't'}, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []byte{'b', 'a', 0x01}; !bytes.Equal(c.Payload, expected) {
+		t.Errorf("unexpected payload: %q", c.Payload)
+	}
+}
+
+func TestMarshalError(t *testing.T) {
+	t.Parallel()
+
+	id := [4]byte{'t', 'e', 's', 't'}
+	for name, v := range map[string]interface{}{
+		"NotStruct":  1,
+		"NilPointer": (*testWaveFormat)(nil),
+		"LongString": struct {
+			S string `riff:"size=2"`
+		}{S: "abc"},
+		"NoStringSize": struct{ S string }{},
+		"NoSliceSize":  struct{ S []byte }{},
+		"SizeMismatch": struct {
+			S []byte `riff:"size=2"`
+		}{S: []byte{1}},
+		"Count": struct {
+			N uint8
+			S []byte `riff:"count=N"`
+		}{N: 2},
+		"CountNotPreceding": struct {
+			S []byte `riff:"count=N"`
+			N uint8
+		}{},
+		"RestNotLast": struct {
+			S []byte `riff:"rest"`
+			N uint8
+		}{},
+		"UnknownOption": struct {
+			N uint8 `riff:"unknown"`
+		}{},
+		"Unsupported": struct{ N int }{},
+		"MarshalerWithoutSize": struct {
+			C testFourCC
+		}{C: "abcd"},
+		"MarshalerSizeMismatch": struct {
+			C testFourCC `riff:"size=4"`
+		}{C: "abc"},
+	} {
+		if _, err := riffbin.Marshal(id, v); err == nil {
+			t.Errorf("%s: error is expected", name)
+		}
+	}
+}
+
+func TestUnmarshalError(t *testing.T) {
+	t.Parallel()
+
+	// the payload of the sub-chunk which is only io.Reader cannot be read with the own cursor
+	var v testWaveFormat
+	if err := riffbin.Unmarshal(readerSubChunk{Reader: bytes.NewReader(make([]byte, 16)), size: 16}, &v); err == nil {
+		t.Error("error is expected for the sub-chunk which is only io.Reader")
+	}
+
+	var format testWaveFormat
+	if err := riffbin.Unmarshal(&riffbin.OnMemorySubChunk{Payload: make([]byte, 15)}, &format); !errors.Is(err, riffbin.ErrInvalidFormat) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	var str struct {
+		S string `riff:"nul"`
+	}
+	if err := riffbin.Unmarshal(&riffbin.OnMemorySubChunk{Payload: []byte("abc")}, &str); !errors.Is(err, riffbin.ErrInvalidFormat) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	var count struct {
+		N uint32
+		S []uint16 `riff:"count=N"`
+	}
+	if err := riffbin.Unmarshal(&riffbin.OnMemorySubChunk{Payload: []byte{0xFF, 0xFF, 0xFF, 0xFF, 0x00}}, &count); !errors.Is(err, riffbin.ErrInvalidFormat) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	var rest struct {
+		S []uint16 `riff:"rest"`
+	}
+	if err := riffbin.Unmarshal(&riffbin.OnMemorySubChunk{Payload: []byte{0x00, 0x00, 0x00}}, &rest); !errors.Is(err, riffbin.ErrInvalidFormat) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	var negative struct {
+		N int8
+		S []byte `riff:"count=N"`
+	}
+	if err := riffbin.Unmarshal(&riffbin.OnMemorySubChunk{Payload: []byte{0xFF, 0x00}}, &negative); !errors.Is(err, riffbin.ErrInvalidFormat) || !strings.Contains(err.Error(), "negative count N -1") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	var nested struct {
+		N uint8
+		S []struct {
+			A uint16
+			B string `riff:"size=4"`
+		} `riff:"count=N"`
+	}
+	// the elements have 6 bytes at least
+	if err := riffbin.Unmarshal(&riffbin.OnMemorySubChunk{Payload: append([]byte{0x02}, make([]byte, 11)...)}, &nested); !errors.Is(err, riffbin.ErrInvalidFormat) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	var zero struct {
+		N uint32
+		S [][0]byte `riff:"count=N"`
+	}
+	if err := riffbin.Unmarshal(&riffbin.OnMemorySubChunk{Payload: []byte{0x00, 0x01, 0x00, 0x00}}, &zero); err != nil || len(zero.S) != 256 {
+		t.Errorf("unexpected result: %d elements, %v", len(zero.S), err)
+	}
+	var marshaler struct {
+		C testFourCC
+	}
+	if err := riffbin.Unmarshal(&riffbin.OnMemorySubChunk{Payload: []byte("abcd")}, &marshaler); err == nil {
+		t.Error("error is expected for the marshaler without size")
+	}
+	if err := riffbin.Unmarshal(&riffbin.OnMemorySubChunk{Payload: make([]byte, 16)}, format); err == nil {
+		t.Error("error is expected for the non-pointer")
+	}
+}
+
+func TestReadPayload(t *testing.T) {
+	t.Parallel()
+
+	c := &riffbin.InStreamSubChunk{ID: [4]byte{'d', 'a', 't', 'a'}, SectionReader: io.NewSectionReader(strings.NewReader("xpayloadx"), 1, 7)}
+	for i := 0; i < 2; i++ {
+		// the payload is read with the own cursor, so it is read again
+		if b, err := riffbin.ReadPayload(c); err != nil || string(b) != "payload" {
+			t.Errorf("unexpected payload: %q, %v", b, err)
+		}
+	}
+	if b, err := io.ReadAll(c); err != nil || string(b) != "payload" {
+		t.Errorf("the cursor of the sub-chunk is moved: %q, %v", b, err)
+	}
+
+	if _, err := riffbin.ReadPayload(riffbin.NewIncompleteSubChunk([4]byte{'d', 'a', 't', 'a'}, strings.NewReader("payload"))); err == nil {
+		t.Error("error is expected for the incomplete sub-chunk")
+	}
+}
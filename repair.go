@@ -0,0 +1,375 @@
+package riffbin
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"path"
+)
+
+// RepairOption is an option for PlanRepair.
+type RepairOption func(*repairConfig)
+
+type repairConfig struct {
+	pad bool
+}
+
+// WithPartialChunkPadding pads the partial trailing sub-chunk with zero bytes up to the declared size instead of shrinking the size.
+func WithPartialChunkPadding() RepairOption {
+	return func(cfg *repairConfig) {
+		cfg.pad = true
+	}
+}
+
+// SizeFix is a fix of the size field of the chunk header.
+type SizeFix struct {
+	// Path is the path of the chunk as same as NewFS. ("." is the root RIFF chunk)
+	Path string
+
+	// HeaderOffset is the offset of the chunk header.
+	HeaderOffset int64
+
+	// OldSize is the body size in the header now.
+	OldSize uint32
+
+	// NewSize is the body size to be written.
+	NewSize uint32
+}
+
+func (f SizeFix) String() string {
+	return fmt.Sprintf("%s@%d: size %d -> %d", f.Path, f.HeaderOffset, f.OldSize, f.NewSize)
+}
+
+// RepairPlan is a plan to repair the RIFF binary made by PlanRepair.
+type RepairPlan struct {
+	// Fixes are the fixes of the chunk headers.
+	Fixes []SizeFix
+
+	// Size is the current size of the binary.
+	Size int64
+
+	// NewSize is the size of the binary after repairing. It is less than Size to truncate the trailing garbage, or greater than Size to pad the partial chunk.
+	NewSize int64
+}
+
+// NeedsRepair returns true if the binary is broken.
+func (p *RepairPlan) NeedsRepair() bool {
+	return len(p.Fixes) != 0 || p.Size != p.NewSize
+}
+
+// changes returns the number of the changes in the plan.
+func (p *RepairPlan) changes() int {
+	n := len(p.Fixes)
+	if p.Size != p.NewSize {
+		n++
+	}
+	return n
+}
+
+// RepairTarget is a destination to apply RepairPlan in place. (e.g. *os.File)
+type RepairTarget interface {
+	io.WriterAt
+	Truncate(size int64) error
+}
+
+// Apply rewrites only the size fields of the chunk headers, and truncates or extends the binary.
+func (p *RepairPlan) Apply(w RepairTarget) error {
+	for _, fix := range p.Fixes {
+		if _, err := writeChunkBodySizeAt(w, fix.NewSize, fix.HeaderOffset+idBytes); err != nil {
+			return fmt.Errorf("writeChunkBodySizeAt: %w", err)
+		}
+	}
+	if p.Size != p.NewSize {
+		if err := w.Truncate(p.NewSize); err != nil {
+			return fmt.Errorf("truncate: %w", err)
+		}
+	}
+	return nil
+}
+
+// PlanRepair scans the RIFF binary of size bytes which may be left by the interrupted writing (e.g. by IncompleteChunkWriter),
+// and plans to fix the sizes of the chunks heuristically.
+// It trusts the chunk headers which are followed by the valid chunk headers, and recomputes the sizes of the other chunks from the actual length.
+// The zero size of the sub-chunk is trusted only if the valid chunks fill the rest, otherwise the sub-chunk is extended not to drop the payload.
+// The trailing garbage and the partial chunk header are truncated, and the partial sub-chunk is shrunk (or padded by WithPartialChunkPadding).
+// The pad byte after the odd-sized chunk is expected as the spec, unless the binary fits the unpadded layout better.
+// The binary which is read cleanly as it is does not need to be repaired.
+// It returns ErrInvalidFormat if the binary does not start with RIFF chunk header.
+func PlanRepair(r io.ReaderAt, size int64, opts ...RepairOption) (*RepairPlan, error) {
+	cfg := repairConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if size < HeaderBytes+typeBytes {
+		return nil, ErrInvalidFormat
+	}
+	padded := &repairer{r: r, size: size, cfg: cfg, padded: true}
+	h, err := padded.header(0)
+	if err != nil {
+		return nil, err
+	}
+	if h.id != riffID {
+		return nil, ErrInvalidFormat
+	}
+	if readCleanly(r, size) {
+		return &RepairPlan{Size: size, NewSize: size}, nil
+	}
+
+	if err := padded.run(h); err != nil {
+		return nil, err
+	}
+	unpadded := &repairer{r: r, size: size, cfg: cfg}
+	if err := unpadded.run(h); err != nil {
+		return nil, err
+	}
+	if unpadded.plan.changes() < padded.plan.changes() {
+		return &unpadded.plan, nil
+	}
+	return &padded.plan, nil
+}
+
+// readCleanly returns true if the binary of size bytes is read as it is with or without the pad bytes.
+func readCleanly(r io.ReaderAt, size int64) bool {
+	for _, opts := range [][]ReaderOption{{WithReadPadding()}, nil} {
+		riffChunk, err := ReadSections(io.NewSectionReader(r, 0, size), opts...)
+		if err != nil {
+			continue
+		}
+
+		end := int64(HeaderBytes + riffChunk.BodySize())
+		if len(opts) != 0 {
+			end += int64(riffChunk.BodySize() % 2)
+		}
+		if end == size {
+			return true
+		}
+	}
+	return false
+}
+
+type repairer struct {
+	r      io.ReaderAt
+	size   int64
+	cfg    repairConfig
+	padded bool
+	plan   RepairPlan
+}
+
+// run plans to repair the binary which starts with the RIFF chunk header h.
+func (rp *repairer) run(h *repairHeader) error {
+	end, err := rp.container(".", 0, h, rp.size, true)
+	if err != nil {
+		return err
+	}
+
+	rp.plan.Size = rp.size
+	rp.plan.NewSize = rp.pad(end, end-HeaderBytes, rp.size)
+	return nil
+}
+
+// pad returns the position after the pad byte following the chunk body of size bytes which ends at end.
+// The pad byte is skipped only in the padded layout, and it may be omitted at limit.
+func (rp *repairer) pad(end, size, limit int64) int64 {
+	if rp.padded && size%2 != 0 && end < limit {
+		return end + 1
+	}
+	return end
+}
+
+type repairHeader struct {
+	id   [idBytes]byte
+	size uint32
+	typ  [typeBytes]byte
+}
+
+func (h *repairHeader) grouped() bool {
+	return h.id == riffID || h.id == listID
+}
+
+// header reads the chunk header at pos. The type is read only if it is available.
+func (rp *repairer) header(pos int64) (*repairHeader, error) {
+	var buf [HeaderBytes + typeBytes]byte
+	n, err := rp.r.ReadAt(buf[:], pos)
+	if n < HeaderBytes {
+		if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	h := &repairHeader{size: binary.LittleEndian.Uint32(buf[idBytes:])}
+	copy(h.id[:], buf[:idBytes])
+	copy(h.typ[:], buf[HeaderBytes:n])
+	return h, nil
+}
+
+// plausible returns true if there is a valid chunk header at pos before limit.
+func (rp *repairer) plausible(pos, limit int64) (*repairHeader, bool) {
+	if pos+HeaderBytes > limit {
+		return nil, false
+	}
+	h, err := rp.header(pos)
+	if err != nil || !plausibleFourCC(h.id[:]) {
+		return nil, false
+	}
+	if h.grouped() && (pos+HeaderBytes+typeBytes > limit || h.size < typeBytes || !plausibleFourCC(h.typ[:])) {
+		return nil, false
+	}
+	return h, true
+}
+
+// plausibleEnd returns true if the chunk can end at pos.
+func (rp *repairer) plausibleEnd(pos, limit int64) bool {
+	if pos == limit {
+		return true
+	}
+	_, ok := rp.plausible(pos, limit)
+	return ok
+}
+
+// fits returns true if the valid chunks fill [start, end) exactly.
+func (rp *repairer) fits(start, end int64) bool {
+	pos := start
+	for pos < end {
+		h, ok := rp.plausible(pos, end)
+		if !ok {
+			return false
+		}
+
+		next := pos + HeaderBytes + int64(h.size)
+		if next > end || h.grouped() && !rp.fits(pos+HeaderBytes+typeBytes, next) {
+			return false
+		}
+		pos = rp.pad(next, int64(h.size), end)
+	}
+	return pos == end
+}
+
+func (rp *repairer) fix(chunkPath string, pos int64, h *repairHeader, size int64) error {
+	if size > math.MaxUint32 {
+		return fmt.Errorf("%s: size %d exceeds the limit", chunkPath, size)
+	}
+	if uint32(size) != h.size {
+		rp.plan.Fixes = append(rp.plan.Fixes, SizeFix{Path: chunkPath, HeaderOffset: pos, OldSize: h.size, NewSize: uint32(size)})
+	}
+	return nil
+}
+
+// container scans the grouped chunk at pos, and returns the end of it.
+// The declared size is trusted if the chunks fill it and the valid chunk follows it, otherwise it extends to limit.
+func (rp *repairer) container(chunkPath string, pos int64, h *repairHeader, limit int64, root bool) (int64, error) {
+	bodyStart := pos + HeaderBytes
+	declaredEnd := bodyStart + int64(h.size)
+
+	regionEnd := limit
+	if !root && declaredEnd <= limit && rp.fits(bodyStart+typeBytes, declaredEnd) && rp.plausibleEnd(rp.pad(declaredEnd, int64(h.size), limit), limit) {
+		regionEnd = declaredEnd
+	}
+
+	end, err := rp.children(chunkPath, bodyStart+typeBytes, regionEnd)
+	if err != nil {
+		return 0, err
+	}
+	return end, rp.fix(chunkPath, pos, h, end-bodyStart)
+}
+
+// children scans the chunks in [start, limit), and returns the end of the last valid chunk.
+func (rp *repairer) children(parentPath string, start, limit int64) (int64, error) {
+	names := chunkNamer{}
+	pos := start
+	for pos < limit {
+		h, ok := rp.plausible(pos, limit)
+		if !ok {
+			// trailing garbage or partial header
+			break
+		}
+
+		if h.grouped() {
+			end, err := rp.container(path.Join(parentPath, names.next(h.id[:], h.typ[:])), pos, h, limit, false)
+			if err != nil {
+				return 0, err
+			}
+			pos = rp.pad(end, end-pos-HeaderBytes, limit)
+			continue
+		}
+
+		chunkPath := path.Join(parentPath, names.next(h.id[:], nil))
+		bodyStart := pos + HeaderBytes
+		declaredEnd := bodyStart + int64(h.size)
+		if declaredEnd <= limit && (h.size != 0 || rp.fits(declaredEnd, limit)) {
+			// the zero size may be not fixed yet, so it is trusted only if the whole rest is parsed
+			pos = rp.pad(declaredEnd, int64(h.size), limit)
+			continue
+		}
+
+		// the sub-chunk is partial, or its size has not been fixed yet
+		end := limit
+		if declaredEnd > limit {
+			if rp.cfg.pad && limit == rp.size {
+				end = declaredEnd
+			}
+		} else if q, ok, err := rp.findListChunk(bodyStart, limit); err != nil {
+			return 0, err
+		} else if ok {
+			end = q
+		}
+		if err := rp.fix(chunkPath, pos, h, end-bodyStart); err != nil {
+			return 0, err
+		}
+		pos = end
+	}
+	return pos, nil
+}
+
+// findListChunk finds the valid LIST chunk in [start, limit) which is followed by the valid chunk.
+func (rp *repairer) findListChunk(start, limit int64) (int64, bool, error) {
+	const blockSize = 1 << 20
+
+	buf := make([]byte, blockSize+idBytes-1)
+	for off := start; off < limit; off += blockSize {
+		n, err := rp.r.ReadAt(buf[:min64(int64(len(buf)), limit-off)], off)
+		if err != nil && err != io.EOF {
+			return 0, false, err
+		}
+
+		for b := buf[:n]; ; {
+			i := bytes.Index(b, listID[:])
+			if i < 0 {
+				break
+			}
+
+			q := off + int64(n-len(b)+i)
+			if h, ok := rp.plausible(q, limit); ok {
+				end := q + HeaderBytes + int64(h.size)
+				if end <= limit && rp.fits(q+HeaderBytes+typeBytes, end) && rp.plausibleEnd(rp.pad(end, int64(h.size), limit), limit) {
+					return q, true, nil
+				}
+			}
+			b = b[i+1:]
+		}
+	}
+	return 0, false, nil
+}
+
+// plausibleFourCC returns true if b consists of the printable ASCII characters and does not start with a space.
+func plausibleFourCC(b []byte) bool {
+	if b[0] == ' ' {
+		return false
+	}
+	for _, c := range b {
+		if c < 0x20 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,337 @@
+package riffbin_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/karupanerura/riffbin"
+)
+
+func writeTempFile(t *testing.T, b []byte) *os.File {
+	t.Helper()
+
+	f, err := os.CreateTemp("", "riffbin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		f.Close()
+		os.Remove(f.Name())
+	})
+	if _, err := f.Write(b); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func repairFile(t *testing.T, f *os.File, opts ...riffbin.RepairOption) (*riffbin.RepairPlan, []byte) {
+	t.Helper()
+
+	info, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := riffbin.PlanRepair(f, info.Size(), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plan.Apply(f); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return plan, b
+}
+
+func TestRepair(t *testing.T) {
+	t.Parallel()
+
+	wave := &riffbin.RIFFChunk{
+		FormType: [4]byte{'W', 'A', 'V', 'E'},
+		Payload: []riffbin.Chunk{
+			&riffbin.OnMemorySubChunk{ID: [4]byte{'f', 'm', 't', ' '}, Payload: make([]byte, 16)},
+			&riffbin.OnMemorySubChunk{ID: [4]byte{'d', 'a', 't', 'a'}, Payload: bytes.Repeat([]byte{0x00, 0x80}, 50)},
+			&riffbin.ListChunk{
+				ListType: [4]byte{'I', 'N', 'F', 'O'},
+				Payload: []riffbin.Chunk{
+					&riffbin.OnMemorySubChunk{ID: [4]byte{'I', 'N', 'A', 'M'}, Payload: []byte("name")},
+				},
+			},
+		},
+	}
+	var src bytes.Buffer
+	if _, err := riffbin.NewCompletedChunkWriter(&src).Write(wave); err != nil {
+		t.Fatal(err)
+	}
+
+	// offsets in src
+	const (
+		dataHeader = 36
+		listHeader = 144
+	)
+	patch := func(b []byte, off int64, size uint32) []byte {
+		b = append([]byte{}, b...)
+		binary.LittleEndian.PutUint32(b[off+4:], size)
+		return b
+	}
+
+	for _, tc := range []struct {
+		name     string
+		input    []byte
+		opts     []riffbin.RepairOption
+		expected []byte
+		fixes    []riffbin.SizeFix
+	}{
+		{
+			name:     "Valid",
+			input:    src.Bytes(),
+			expected: src.Bytes(),
+		},
+		{
+			name:     "UnfixedSizes",
+			input:    patch(patch(src.Bytes(), 0, 4+24+8+20), dataHeader, 0),
+			expected: src.Bytes(),
+			fixes: []riffbin.SizeFix{
+				{Path: "data", HeaderOffset: dataHeader, OldSize: 0, NewSize: 100},
+				{Path: ".", HeaderOffset: 0, OldSize: 56, NewSize: 160},
+			},
+		},
+		{
+			name:     "TrailingGarbage",
+			input:    append(append([]byte{}, src.Bytes()...), 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00),
+			expected: src.Bytes(),
+		},
+		{
+			name:     "PartialHeader",
+			input:    src.Bytes()[:src.Len()-10],
+			expected: patch(patch(src.Bytes()[:listHeader+12], listHeader, 4), 0, listHeader+4),
+			fixes: []riffbin.SizeFix{
+				{Path: "LIST-INFO", HeaderOffset: listHeader, OldSize: 16, NewSize: 4},
+				{Path: ".", HeaderOffset: 0, OldSize: 160, NewSize: listHeader + 4},
+			},
+		},
+		{
+			name:     "PartialSubChunk",
+			input:    src.Bytes()[:dataHeader+8+11],
+			expected: patch(patch(src.Bytes()[:dataHeader+8+11], dataHeader, 11), 0, dataHeader+8+11-8),
+			fixes: []riffbin.SizeFix{
+				{Path: "data", HeaderOffset: dataHeader, OldSize: 100, NewSize: 11},
+				{Path: ".", HeaderOffset: 0, OldSize: 160, NewSize: dataHeader + 11},
+			},
+		},
+		{
+			name:     "PaddedSubChunk",
+			input:    src.Bytes()[:dataHeader+8+11],
+			opts:     []riffbin.RepairOption{riffbin.WithPartialChunkPadding()},
+			expected: patch(append(append([]byte{}, src.Bytes()[:dataHeader+8+11]...), make([]byte, 89)...), 0, dataHeader+100),
+			fixes: []riffbin.SizeFix{
+				{Path: ".", HeaderOffset: 0, OldSize: 160, NewSize: dataHeader + 100},
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			f := writeTempFile(t, tc.input)
+			plan, got := repairFile(t, f, tc.opts...)
+			if !bytes.Equal(got, tc.expected) {
+				t.Error("unexpected bytes are repaired")
+				t.Log(hex.Dump(got))
+			}
+			if df := cmp.Diff(plan.Fixes, tc.fixes); df != "" {
+				t.Errorf("diff = %s", df)
+			}
+			if plan.NeedsRepair() != !bytes.Equal(tc.input, tc.expected) {
+				t.Errorf("unexpected NeedsRepair: %v", plan.NeedsRepair())
+			}
+			if _, err := riffbin.ReadSections(bytes.NewReader(got)); err != nil {
+				t.Errorf("repaired binary cannot be read: %v", err)
+			}
+		})
+	}
+
+	t.Run("Interrupted", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		written := 0
+		f := writeTempFile(t, nil)
+		w, err := riffbin.NewIncompleteChunkWriter(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = w.WriteContext(ctx, &riffbin.RIFFChunk{
+			FormType: [4]byte{'W', 'A', 'V', 'E'},
+			Payload: []riffbin.Chunk{
+				&riffbin.OnMemorySubChunk{ID: [4]byte{'f', 'm', 't', ' '}, Payload: make([]byte, 16)},
+				&riffbin.ListChunk{
+					ListType: [4]byte{'I', 'N', 'F', 'O'},
+					Payload: []riffbin.Chunk{
+						riffbin.NewIncompleteSubChunk([4]byte{'d', 'a', 't', 'a'}, callbackReader(func(p []byte) (int, error) {
+							if written >= 1000 {
+								// the process dies
+								cancel()
+							}
+							n := copy(p, bytes.Repeat([]byte{0x01, 0x80}, 50))
+							written += n
+							return n, nil
+						})),
+					},
+				},
+			},
+		}, nil)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		info, err := f.Stat()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// RIFF-WAVE, fmt, LIST-INFO and data headers precede the written data
+		writtenData := info.Size() - (12 + 8 + 16 + 12 + 8)
+
+		_, got := repairFile(t, f)
+		riffChunk, err := riffbin.ReadSections(bytes.NewReader(got))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := riffbin.LookupChunk(riffChunk, "LIST-INFO/data")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if int64(data.BodySize()) != writtenData || writtenData < 1000 {
+			t.Errorf("unexpected data size: %d (written: %d)", data.BodySize(), writtenData)
+		}
+	})
+
+	t.Run("UnfixedBeforeList", func(t *testing.T) {
+		t.Parallel()
+
+		// the data chunk is complete, but the size is not fixed yet
+		input := patch(patch(src.Bytes(), 0, 4+24+8+20), dataHeader, 0)
+		input = append(input[:listHeader:listHeader], src.Bytes()[listHeader:]...)
+		f := writeTempFile(t, input)
+		_, got := repairFile(t, f)
+		if !bytes.Equal(got, src.Bytes()) {
+			t.Error("unexpected bytes are repaired")
+			t.Log(hex.Dump(got))
+		}
+	})
+
+	t.Run("UnfixedLikeChunkHeader", func(t *testing.T) {
+		t.Parallel()
+
+		// the audio starts with the bytes like the chunk header
+		audio := append([]byte("abcd\x04\x00\x00\x00zzzz"), bytes.Repeat([]byte{0x00, 0x80}, 50)...)
+		audio = append(audio, 0x01)
+		input := patch(patch(src.Bytes()[:dataHeader+8], 0, 0), dataHeader, 0)
+		input = append(input, audio...)
+		if len(input) != 157 {
+			t.Fatalf("unexpected input size: %d", len(input))
+		}
+
+		f := writeTempFile(t, input)
+		plan, got := repairFile(t, f)
+		expectedFixes := []riffbin.SizeFix{
+			{Path: "data", HeaderOffset: dataHeader, OldSize: 0, NewSize: 113},
+			{Path: ".", HeaderOffset: 0, OldSize: 0, NewSize: 149},
+		}
+		if df := cmp.Diff(plan.Fixes, expectedFixes); df != "" {
+			t.Errorf("diff = %s", df)
+		}
+		if plan.NewSize != 157 || len(got) != 157 {
+			t.Errorf("payload bytes are dropped: %d", plan.NewSize)
+		}
+
+		riffChunk, err := riffbin.ReadFull(bytes.NewReader(got))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data := riffChunk.Payload[1].(*riffbin.OnMemorySubChunk); !bytes.Equal(data.Payload, audio) {
+			t.Errorf("unexpected data: %s", hex.Dump(data.Payload))
+		}
+	})
+
+	t.Run("Padded", func(t *testing.T) {
+		t.Parallel()
+
+		padded := &riffbin.RIFFChunk{
+			FormType: [4]byte{'W', 'A', 'V', 'E'},
+			Payload: []riffbin.Chunk{
+				&riffbin.OnMemorySubChunk{ID: [4]byte{'f', 'm', 't', ' '}, Payload: make([]byte, 16)},
+				&riffbin.ListChunk{
+					ListType: [4]byte{'I', 'N', 'F', 'O'},
+					Payload: []riffbin.Chunk{
+						&riffbin.OnMemorySubChunk{ID: [4]byte{'I', 'N', 'A', 'M'}, Payload: []byte("name\x00")},
+					},
+				},
+				&riffbin.OnMemorySubChunk{ID: [4]byte{'d', 'a', 't', 'a'}, Payload: []byte{0x01, 0x00, 0x02, 0x00}},
+			},
+		}
+		var buf bytes.Buffer
+		if _, err := riffbin.NewCompletedChunkWriter(&buf, riffbin.WithWritePadding()).Write(padded); err != nil {
+			t.Fatal(err)
+		}
+		src := buf.Bytes()
+		if len(src) != 74 {
+			t.Fatalf("unexpected size: %d", len(src))
+		}
+
+		t.Run("Valid", func(t *testing.T) {
+			t.Parallel()
+
+			f := writeTempFile(t, src)
+			plan, got := repairFile(t, f)
+			if plan.NeedsRepair() || !bytes.Equal(got, src) {
+				t.Errorf("valid binary is repaired: %v", plan.Fixes)
+			}
+		})
+
+		t.Run("UnfixedSizes", func(t *testing.T) {
+			t.Parallel()
+
+			// the padded LIST-INFO is followed by the data chunk whose size is not fixed yet
+			f := writeTempFile(t, patch(patch(src, 0, 4), 62, 0))
+			plan, got := repairFile(t, f)
+			expectedFixes := []riffbin.SizeFix{
+				{Path: "data", HeaderOffset: 62, OldSize: 0, NewSize: 4},
+				{Path: ".", HeaderOffset: 0, OldSize: 4, NewSize: 66},
+			}
+			if df := cmp.Diff(plan.Fixes, expectedFixes); df != "" {
+				t.Errorf("diff = %s", df)
+			}
+			if !bytes.Equal(got, src) {
+				t.Error("unexpected bytes are repaired")
+				t.Log(hex.Dump(got))
+			}
+		})
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+
+		for _, b := range [][]byte{
+			{},
+			[]byte("RIFF\x04\x00\x00"),
+			[]byte("LIST\x04\x00\x00\x00INFO"),
+		} {
+			if _, err := riffbin.PlanRepair(bytes.NewReader(b), int64(len(b))); err != riffbin.ErrInvalidFormat {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}
+	})
+}
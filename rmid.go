@@ -0,0 +1,50 @@
+package riffbin
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+var (
+	rmidType = [typeBytes]byte{'R', 'M', 'I', 'D'}
+	smfMagic = []byte("MThd")
+)
+
+// WrapRMID wraps the standard MIDI file into the RMID.
+// LIST-INFO or the DLS collection (RIFF DLS) can be appended to Payload of the returned chunk to bundle them.
+func WrapRMID(smf []byte) (*RIFFChunk, error) {
+	if !bytes.HasPrefix(smf, smfMagic) {
+		return nil, fmt.Errorf("not a standard MIDI file: %w", ErrInvalidFormat)
+	}
+	return &RIFFChunk{
+		FormType: rmidType,
+		Payload:  []Chunk{&OnMemorySubChunk{ID: dataID, Payload: smf}},
+	}, nil
+}
+
+// UnwrapRMID returns the reader of the standard MIDI file in the data chunk of the RMID.
+// It reads the payload with the own cursor, so c can be still written after reading.
+func UnwrapRMID(c *RIFFChunk) (*io.SectionReader, error) {
+	if c.FormType != rmidType {
+		return nil, fmt.Errorf("form type %q is not RMID: %w", string(c.FormType[:]), ErrInvalidFormat)
+	}
+
+	for _, chunk := range c.Payload {
+		sc, ok := chunk.(SubChunk)
+		if !ok || !bytes.Equal(sc.ChunkID(), dataID[:]) {
+			continue
+		}
+
+		r, err := openPayload(sc)
+		if err != nil {
+			return nil, err
+		}
+		magic := make([]byte, len(smfMagic))
+		if _, err := r.ReadAt(magic, 0); err != nil || !bytes.Equal(magic, smfMagic) {
+			return nil, fmt.Errorf("data chunk is not a standard MIDI file: %w", ErrInvalidFormat)
+		}
+		return r, nil
+	}
+	return nil, fmt.Errorf("data chunk is not found: %w", ErrInvalidFormat)
+}
@@ -0,0 +1,54 @@
+package riffbin_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/karupanerura/riffbin"
+)
+
+func TestRMID(t *testing.T) {
+	t.Parallel()
+
+	smf := append([]byte("MThd\x00\x00\x00\x06\x00\x00\x00\x01\x01\xe0"), []byte("MTrk\x00\x00\x00\x04\x00\xff\x2f\x00")...)
+	c, err := riffbin.WrapRMID(smf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := riffbin.NewCompletedChunkWriter(&buf).Write(c); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes()[8:12], []byte("RMID")) {
+		t.Errorf("unexpected form type: %q", buf.Bytes()[8:12])
+	}
+
+	got, err := riffbin.ReadSections(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := riffbin.UnwrapRMID(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, smf) {
+		t.Errorf("unexpected SMF: %q", b)
+	}
+
+	if _, err := riffbin.WrapRMID([]byte("RIFF")); !errors.Is(err, riffbin.ErrInvalidFormat) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := riffbin.UnwrapRMID(&riffbin.RIFFChunk{FormType: [4]byte{'W', 'A', 'V', 'E'}}); !errors.Is(err, riffbin.ErrInvalidFormat) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := riffbin.UnwrapRMID(&riffbin.RIFFChunk{FormType: [4]byte{'R', 'M', 'I', 'D'}}); !errors.Is(err, riffbin.ErrInvalidFormat) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,468 @@
+package soundfont
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/karupanerura/riffbin"
+)
+
+// the bytes of the hydra records
+const (
+	nameBytes      = 20
+	phdrBytes      = 38
+	bagBytes       = 4
+	modBytes       = 10
+	genBytes       = 4
+	instBytes      = 22
+	shdrBytes      = 46
+	sampleBytes    = 2
+	terminalPreset = "EOP"
+	terminalInst   = "EOI"
+	terminalSample = "EOS"
+)
+
+// GeneratorOper is the type of the generator. (SFGenerator)
+type GeneratorOper uint16
+
+// The generators defined in SoundFont 2.04.
+const (
+	GenStartAddrsOffset GeneratorOper = iota
+	GenEndAddrsOffset
+	GenStartloopAddrsOffset
+	GenEndloopAddrsOffset
+	GenStartAddrsCoarseOffset
+	GenModLfoToPitch
+	GenVibLfoToPitch
+	GenModEnvToPitch
+	GenInitialFilterFc
+	GenInitialFilterQ
+	GenModLfoToFilterFc
+	GenModEnvToFilterFc
+	GenEndAddrsCoarseOffset
+	GenModLfoToVolume
+	_
+	GenChorusEffectsSend
+	GenReverbEffectsSend
+	GenPan
+	_
+	_
+	_
+	GenDelayModLFO
+	GenFreqModLFO
+	GenDelayVibLFO
+	GenFreqVibLFO
+	GenDelayModEnv
+	GenAttackModEnv
+	GenHoldModEnv
+	GenDecayModEnv
+	GenSustainModEnv
+	GenReleaseModEnv
+	GenKeynumToModEnvHold
+	GenKeynumToModEnvDecay
+	GenDelayVolEnv
+	GenAttackVolEnv
+	GenHoldVolEnv
+	GenDecayVolEnv
+	GenSustainVolEnv
+	GenReleaseVolEnv
+	GenKeynumToVolEnvHold
+	GenKeynumToVolEnvDecay
+	GenInstrument
+	_
+	GenKeyRange
+	GenVelRange
+	GenStartloopAddrsCoarseOffset
+	GenKeynum
+	GenVelocity
+	GenInitialAttenuation
+	_
+	GenEndloopAddrsCoarseOffset
+	GenCoarseTune
+	GenFineTune
+	GenSampleID
+	GenSampleModes
+	_
+	GenScaleTuning
+	GenExclusiveClass
+	GenOverridingRootKey
+)
+
+// Generator is a generator of the zone. (pgen, igen)
+type Generator struct {
+	Oper GeneratorOper
+
+	// Amount is the raw amount. Use Signed or Range to interpret it by Oper.
+	Amount uint16
+}
+
+// Signed returns the amount as the signed value. (shAmount)
+func (g Generator) Signed() int16 {
+	return int16(g.Amount)
+}
+
+// Range returns the amount as the range. (ranges of GenKeyRange and GenVelRange)
+func (g Generator) Range() (lo, hi uint8) {
+	return uint8(g.Amount), uint8(g.Amount >> 8)
+}
+
+// Modulator is a modulator of the zone. (pmod, imod)
+type Modulator struct {
+	SrcOper    uint16
+	DestOper   GeneratorOper
+	Amount     int16
+	AmtSrcOper uint16
+	TransOper  uint16
+}
+
+// Zone is a zone of the preset or the instrument. (pbag, ibag)
+// The first zone without the terminal generator (GenInstrument for presets, GenSampleID for instruments) is the global zone.
+type Zone struct {
+	Generators []Generator
+	Modulators []Modulator
+}
+
+// Lookup returns the first generator of oper in the zone.
+func (z *Zone) Lookup(oper GeneratorOper) (Generator, bool) {
+	for _, g := range z.Generators {
+		if g.Oper == oper {
+			return g, true
+		}
+	}
+	return Generator{}, false
+}
+
+// Preset is a preset of the bank. (phdr)
+type Preset struct {
+	Name       string
+	Preset     uint16
+	Bank       uint16
+	Library    uint32
+	Genre      uint32
+	Morphology uint32
+	Zones      []Zone
+}
+
+// Instrument is an instrument of the bank. (inst)
+type Instrument struct {
+	Name  string
+	Zones []Zone
+}
+
+// SampleType is the type of the sample. (SFSampleLink)
+type SampleType uint16
+
+const (
+	MonoSample      SampleType = 1
+	RightSample     SampleType = 2
+	LeftSample      SampleType = 4
+	LinkedSample    SampleType = 8
+	RomMonoSample   SampleType = 0x8001
+	RomRightSample  SampleType = 0x8002
+	RomLeftSample   SampleType = 0x8004
+	RomLinkedSample SampleType = 0x8008
+)
+
+// Sample is a sample header of the bank. (shdr)
+// The positions are the indices of the sample points in the sample data.
+type Sample struct {
+	Name            string
+	Start           uint32
+	End             uint32
+	StartLoop       uint32
+	EndLoop         uint32
+	SampleRate      uint32
+	OriginalPitch   uint8
+	PitchCorrection int8
+	SampleLink      uint16
+	SampleType      SampleType
+}
+
+type bag struct {
+	genIndex uint16
+	modIndex uint16
+}
+
+// records splits the payload of the hydra chunk into the records of size bytes.
+// It returns an error if the payload does not contain the terminal record.
+func records(id string, b []byte, size int) ([][]byte, error) {
+	if len(b)%size != 0 || len(b) == 0 {
+		return nil, fmt.Errorf("%s chunk size %d is not a multiple of %d: %w", id, len(b), size, riffbin.ErrInvalidFormat)
+	}
+
+	recs := make([][]byte, 0, len(b)/size)
+	for i := 0; i < len(b); i += size {
+		recs = append(recs, b[i:i+size])
+	}
+	return recs, nil
+}
+
+func decodePresets(b []byte) ([]Preset, []uint16, error) {
+	recs, err := records("phdr", b, phdrBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	presets := make([]Preset, 0, len(recs)-1)
+	bags := make([]uint16, 0, len(recs))
+	for i, r := range recs {
+		bags = append(bags, binary.LittleEndian.Uint16(r[24:]))
+		if i == len(recs)-1 {
+			break
+		}
+		presets = append(presets, Preset{
+			Name:       decodeName(r),
+			Preset:     binary.LittleEndian.Uint16(r[20:]),
+			Bank:       binary.LittleEndian.Uint16(r[22:]),
+			Library:    binary.LittleEndian.Uint32(r[26:]),
+			Genre:      binary.LittleEndian.Uint32(r[30:]),
+			Morphology: binary.LittleEndian.Uint32(r[34:]),
+		})
+	}
+	return presets, bags, nil
+}
+
+func decodeInstruments(b []byte) ([]Instrument, []uint16, error) {
+	recs, err := records("inst", b, instBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	instruments := make([]Instrument, 0, len(recs)-1)
+	bags := make([]uint16, 0, len(recs))
+	for i, r := range recs {
+		bags = append(bags, binary.LittleEndian.Uint16(r[20:]))
+		if i == len(recs)-1 {
+			break
+		}
+		instruments = append(instruments, Instrument{Name: decodeName(r)})
+	}
+	return instruments, bags, nil
+}
+
+func decodeBags(id string, b []byte) ([]bag, error) {
+	recs, err := records(id, b, bagBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	bags := make([]bag, 0, len(recs))
+	for _, r := range recs {
+		bags = append(bags, bag{
+			genIndex: binary.LittleEndian.Uint16(r[0:]),
+			modIndex: binary.LittleEndian.Uint16(r[2:]),
+		})
+	}
+	return bags, nil
+}
+
+func decodeModulators(id string, b []byte) ([]Modulator, error) {
+	recs, err := records(id, b, modBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	mods := make([]Modulator, 0, len(recs))
+	for _, r := range recs {
+		mods = append(mods, Modulator{
+			SrcOper:    binary.LittleEndian.Uint16(r[0:]),
+			DestOper:   GeneratorOper(binary.LittleEndian.Uint16(r[2:])),
+			Amount:     int16(binary.LittleEndian.Uint16(r[4:])),
+			AmtSrcOper: binary.LittleEndian.Uint16(r[6:]),
+			TransOper:  binary.LittleEndian.Uint16(r[8:]),
+		})
+	}
+	return mods, nil
+}
+
+func decodeGenerators(id string, b []byte) ([]Generator, error) {
+	recs, err := records(id, b, genBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	gens := make([]Generator, 0, len(recs))
+	for _, r := range recs {
+		gens = append(gens, Generator{
+			Oper:   GeneratorOper(binary.LittleEndian.Uint16(r[0:])),
+			Amount: binary.LittleEndian.Uint16(r[2:]),
+		})
+	}
+	return gens, nil
+}
+
+func decodeSamples(b []byte) ([]Sample, error) {
+	recs, err := records("shdr", b, shdrBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make([]Sample, 0, len(recs)-1)
+	for _, r := range recs[:len(recs)-1] {
+		samples = append(samples, Sample{
+			Name:            decodeName(r),
+			Start:           binary.LittleEndian.Uint32(r[20:]),
+			End:             binary.LittleEndian.Uint32(r[24:]),
+			StartLoop:       binary.LittleEndian.Uint32(r[28:]),
+			EndLoop:         binary.LittleEndian.Uint32(r[32:]),
+			SampleRate:      binary.LittleEndian.Uint32(r[36:]),
+			OriginalPitch:   r[40],
+			PitchCorrection: int8(r[41]),
+			SampleLink:      binary.LittleEndian.Uint16(r[42:]),
+			SampleType:      SampleType(binary.LittleEndian.Uint16(r[44:])),
+		})
+	}
+	return samples, nil
+}
+
+// decodeZones returns the zones of each owner (preset or instrument) whose first bag indices are owners.
+// owners and bags include the terminal records.
+func decodeZones(id string, owners []uint16, bags []bag, gens []Generator, mods []Modulator) ([][]Zone, error) {
+	zones := make([][]Zone, 0, len(owners)-1)
+	for i := 0; i < len(owners)-1; i++ {
+		from, to := int(owners[i]), int(owners[i+1])
+		if from > to || to >= len(bags) {
+			return nil, fmt.Errorf("%s[%d] bag index [%d, %d) is out of range: %w", id, i, from, to, riffbin.ErrInvalidFormat)
+		}
+
+		var zs []Zone
+		for j := from; j < to; j++ {
+			b, next := bags[j], bags[j+1]
+			if b.genIndex > next.genIndex || int(next.genIndex) > len(gens) || b.modIndex > next.modIndex || int(next.modIndex) > len(mods) {
+				return nil, fmt.Errorf("%s[%d] bag[%d] index is out of range: %w", id, i, j, riffbin.ErrInvalidFormat)
+			}
+			zs = append(zs, Zone{
+				Generators: append([]Generator(nil), gens[b.genIndex:next.genIndex]...),
+				Modulators: append([]Modulator(nil), mods[b.modIndex:next.modIndex]...),
+			})
+		}
+		zones = append(zones, zs)
+	}
+	return zones, nil
+}
+
+// encodeZones encodes the zones into bag, mod and gen chunk payloads with the terminal records, and returns the first bag indices of each owner.
+func encodeZones(id string, zones [][]Zone) (owners []uint16, bagBuf, modBuf, genBuf []byte, err error) {
+	var nBags, nMods, nGens int
+	for _, zs := range zones {
+		owners = append(owners, uint16(nBags))
+		for _, z := range zs {
+			bagBuf = appendUint16(bagBuf, uint16(nGens))
+			bagBuf = appendUint16(bagBuf, uint16(nMods))
+			for _, m := range z.Modulators {
+				modBuf = appendModulator(modBuf, m)
+			}
+			for _, g := range z.Generators {
+				genBuf = appendGenerator(genBuf, g)
+			}
+
+			nBags++
+			nMods += len(z.Modulators)
+			nGens += len(z.Generators)
+		}
+	}
+	if nBags > 0xFFFF || nMods > 0xFFFF || nGens > 0xFFFF {
+		err = fmt.Errorf("too many %s zones: bags=%d mods=%d gens=%d", id, nBags, nMods, nGens)
+		return
+	}
+
+	// terminal records
+	owners = append(owners, uint16(nBags))
+	bagBuf = appendUint16(bagBuf, uint16(nGens))
+	bagBuf = appendUint16(bagBuf, uint16(nMods))
+	modBuf = appendModulator(modBuf, Modulator{})
+	genBuf = appendGenerator(genBuf, Generator{})
+	return
+}
+
+func appendModulator(b []byte, m Modulator) []byte {
+	b = appendUint16(b, m.SrcOper)
+	b = appendUint16(b, uint16(m.DestOper))
+	b = appendUint16(b, uint16(m.Amount))
+	b = appendUint16(b, m.AmtSrcOper)
+	return appendUint16(b, m.TransOper)
+}
+
+func appendGenerator(b []byte, g Generator) []byte {
+	b = appendUint16(b, uint16(g.Oper))
+	return appendUint16(b, g.Amount)
+}
+
+func encodePresets(presets []Preset, owners []uint16) []byte {
+	b := make([]byte, 0, (len(presets)+1)*phdrBytes)
+	for i, p := range presets {
+		b = appendName(b, p.Name)
+		b = appendUint16(b, p.Preset)
+		b = appendUint16(b, p.Bank)
+		b = appendUint16(b, owners[i])
+		b = appendUint32(b, p.Library)
+		b = appendUint32(b, p.Genre)
+		b = appendUint32(b, p.Morphology)
+	}
+
+	// terminal record
+	b = appendName(b, terminalPreset)
+	b = append(b, make([]byte, 4)...)
+	b = appendUint16(b, owners[len(presets)])
+	return append(b, make([]byte, 12)...)
+}
+
+func encodeInstruments(instruments []Instrument, owners []uint16) []byte {
+	b := make([]byte, 0, (len(instruments)+1)*instBytes)
+	for i, inst := range instruments {
+		b = appendName(b, inst.Name)
+		b = appendUint16(b, owners[i])
+	}
+
+	// terminal record
+	b = appendName(b, terminalInst)
+	return appendUint16(b, owners[len(instruments)])
+}
+
+func encodeSamples(samples []Sample) []byte {
+	b := make([]byte, 0, (len(samples)+1)*shdrBytes)
+	for _, s := range samples {
+		b = appendName(b, s.Name)
+		b = appendUint32(b, s.Start)
+		b = appendUint32(b, s.End)
+		b = appendUint32(b, s.StartLoop)
+		b = appendUint32(b, s.EndLoop)
+		b = appendUint32(b, s.SampleRate)
+		b = append(b, s.OriginalPitch, byte(s.PitchCorrection))
+		b = appendUint16(b, s.SampleLink)
+		b = appendUint16(b, uint16(s.SampleType))
+	}
+
+	// terminal record
+	b = appendName(b, terminalSample)
+	return append(b, make([]byte, shdrBytes-nameBytes)...)
+}
+
+func decodeName(r []byte) string {
+	name := r[:nameBytes]
+	for i, c := range name {
+		if c == 0 {
+			return string(name[:i])
+		}
+	}
+	return string(name)
+}
+
+// appendName appends the name as the fixed length field, and it is truncated to keep the terminating NUL.
+func appendName(b []byte, name string) []byte {
+	var buf [nameBytes]byte
+	copy(buf[:nameBytes-1], name)
+	return append(b, buf[:]...)
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	var buf [2]byte
+	binary.LittleEndian.PutUint16(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	return append(b, buf[:]...)
+}
@@ -0,0 +1,137 @@
+package soundfont
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/karupanerura/riffbin"
+)
+
+var (
+	ifilID = [4]byte{'i', 'f', 'i', 'l'}
+	isngID = [4]byte{'i', 's', 'n', 'g'}
+	inamID = [4]byte{'I', 'N', 'A', 'M'}
+	iromID = [4]byte{'i', 'r', 'o', 'm'}
+	iverID = [4]byte{'i', 'v', 'e', 'r'}
+	icrdID = [4]byte{'I', 'C', 'R', 'D'}
+	iengID = [4]byte{'I', 'E', 'N', 'G'}
+	iprdID = [4]byte{'I', 'P', 'R', 'D'}
+	icopID = [4]byte{'I', 'C', 'O', 'P'}
+	icmtID = [4]byte{'I', 'C', 'M', 'T'}
+	isftID = [4]byte{'I', 'S', 'F', 'T'}
+)
+
+// Version is the version of the bank or the ROM. (sfVersionTag)
+type Version struct {
+	Major uint16
+	Minor uint16
+}
+
+// Info is the INFO chunk of the bank.
+// The empty strings and the nil ROMVersion are the absent chunks, but ifil, isng and INAM are always written as required.
+type Info struct {
+	Version      Version  // ifil
+	SoundEngine  string   // isng (e.g. "EMU8000")
+	Name         string   // INAM
+	ROMName      string   // irom
+	ROMVersion   *Version // iver
+	CreationDate string   // ICRD
+	Engineers    string   // IENG
+	Product      string   // IPRD
+	Copyright    string   // ICOP
+	Comments     string   // ICMT
+	Software     string   // ISFT
+}
+
+func (info *Info) strings() map[[4]byte]*string {
+	return map[[4]byte]*string{
+		isngID: &info.SoundEngine,
+		inamID: &info.Name,
+		iromID: &info.ROMName,
+		icrdID: &info.CreationDate,
+		iengID: &info.Engineers,
+		iprdID: &info.Product,
+		icopID: &info.Copyright,
+		icmtID: &info.Comments,
+		isftID: &info.Software,
+	}
+}
+
+func (info *Info) decode(chunks []riffbin.Chunk) error {
+	strs := info.strings()
+	for _, chunk := range chunks {
+		sc, ok := chunk.(riffbin.SubChunk)
+		if !ok {
+			continue
+		}
+		var id [4]byte
+		copy(id[:], sc.ChunkID())
+		b, err := riffbin.ReadPayload(sc)
+		if err != nil {
+			return fmt.Errorf("%s: %w", string(id[:]), err)
+		}
+
+		switch id {
+		case ifilID, iverID:
+			if len(b) != 4 {
+				return fmt.Errorf("%s chunk size %d is not 4: %w", string(id[:]), len(b), riffbin.ErrInvalidFormat)
+			}
+			v := Version{Major: binary.LittleEndian.Uint16(b[0:]), Minor: binary.LittleEndian.Uint16(b[2:])}
+			if id == ifilID {
+				info.Version = v
+			} else {
+				info.ROMVersion = &v
+			}
+		default:
+			if s, ok := strs[id]; ok {
+				*s = decodeString(b)
+			}
+		}
+	}
+	return nil
+}
+
+func (info *Info) encode() []riffbin.Chunk {
+	chunks := []riffbin.Chunk{
+		&riffbin.OnMemorySubChunk{ID: ifilID, Payload: encodeVersion(info.Version)},
+		&riffbin.OnMemorySubChunk{ID: isngID, Payload: encodeString(info.SoundEngine)},
+		&riffbin.OnMemorySubChunk{ID: inamID, Payload: encodeString(info.Name)},
+	}
+	if info.ROMName != "" {
+		chunks = append(chunks, &riffbin.OnMemorySubChunk{ID: iromID, Payload: encodeString(info.ROMName)})
+	}
+	if info.ROMVersion != nil {
+		chunks = append(chunks, &riffbin.OnMemorySubChunk{ID: iverID, Payload: encodeVersion(*info.ROMVersion)})
+	}
+
+	strs := info.strings()
+	for _, id := range [][4]byte{icrdID, iengID, iprdID, icopID, icmtID, isftID} {
+		if s := *strs[id]; s != "" {
+			chunks = append(chunks, &riffbin.OnMemorySubChunk{ID: id, Payload: encodeString(s)})
+		}
+	}
+	return chunks
+}
+
+func encodeVersion(v Version) []byte {
+	b := appendUint16(nil, v.Major)
+	return appendUint16(b, v.Minor)
+}
+
+func decodeString(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
+
+// encodeString encodes s as the NUL terminated string padded to the even length.
+func encodeString(s string) []byte {
+	b := append([]byte(s), 0)
+	if len(b)%2 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
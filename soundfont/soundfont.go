@@ -0,0 +1,286 @@
+// Package soundfont decodes and encodes SoundFont 2 banks (RIFF sfbk) on the riffbin chunk tree.
+// The hydra records in the pdta chunk are decoded into the typed presets, instruments and samples,
+// and the sample data in the sdta chunk is kept as the sub-chunk to stream it without loading on memory.
+package soundfont
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/karupanerura/riffbin"
+)
+
+var (
+	sfbkType = [4]byte{'s', 'f', 'b', 'k'}
+	infoType = [4]byte{'I', 'N', 'F', 'O'}
+	sdtaType = [4]byte{'s', 'd', 't', 'a'}
+	pdtaType = [4]byte{'p', 'd', 't', 'a'}
+	smplID   = [4]byte{'s', 'm', 'p', 'l'}
+	sm24ID   = [4]byte{'s', 'm', '2', '4'}
+)
+
+// hydraIDs is the IDs of the sub-chunks of pdta in the order of the spec.
+var hydraIDs = [][4]byte{
+	{'p', 'h', 'd', 'r'},
+	{'p', 'b', 'a', 'g'},
+	{'p', 'm', 'o', 'd'},
+	{'p', 'g', 'e', 'n'},
+	{'i', 'n', 's', 't'},
+	{'i', 'b', 'a', 'g'},
+	{'i', 'm', 'o', 'd'},
+	{'i', 'g', 'e', 'n'},
+	{'s', 'h', 'd', 'r'},
+}
+
+// Bank is a SoundFont 2 bank.
+type Bank struct {
+	Info        Info
+	Presets     []Preset
+	Instruments []Instrument
+	Samples     []Sample
+
+	// SampleData is the smpl chunk of the 16 bits little-endian sample points.
+	// It is *riffbin.InStreamSubChunk if the bank is read by Read, and it is streamed as it is by WriteTo.
+	SampleData riffbin.SubChunk
+
+	// SampleData24 is the sm24 chunk of the least significant bytes of the 24 bits sample points, or nil.
+	SampleData24 riffbin.SubChunk
+}
+
+// Read reads the SoundFont 2 bank from r by riffbin.ReadSections with the pad bytes.
+func Read(r riffbin.PartialReader) (*Bank, error) {
+	c, err := riffbin.ReadSections(r, riffbin.WithReadPadding())
+	if err != nil {
+		return nil, err
+	}
+	return Decode(c)
+}
+
+// Decode decodes the SoundFont 2 bank from the chunk tree.
+// The sub-chunk payloads are read with the own cursor, so c can be still written after decoding.
+func Decode(c *riffbin.RIFFChunk) (*Bank, error) {
+	if c.FormType != sfbkType {
+		return nil, fmt.Errorf("form type %q is not sfbk: %w", string(c.FormType[:]), riffbin.ErrInvalidFormat)
+	}
+
+	b := &Bank{}
+	hydra := map[[4]byte][]byte{}
+	for _, chunk := range c.Payload {
+		l, ok := chunk.(*riffbin.ListChunk)
+		if !ok {
+			continue
+		}
+		chunks, err := l.Chunks()
+		if err != nil {
+			return nil, err
+		}
+
+		switch l.ListType {
+		case infoType:
+			if err := b.Info.decode(chunks); err != nil {
+				return nil, err
+			}
+		case sdtaType:
+			for _, chunk := range chunks {
+				sc, ok := chunk.(riffbin.SubChunk)
+				if !ok {
+					continue
+				}
+				var id [4]byte
+				copy(id[:], sc.ChunkID())
+				switch id {
+				case smplID:
+					b.SampleData = sc
+				case sm24ID:
+					b.SampleData24 = sc
+				}
+			}
+		case pdtaType:
+			for _, chunk := range chunks {
+				sc, ok := chunk.(riffbin.SubChunk)
+				if !ok {
+					continue
+				}
+				var id [4]byte
+				copy(id[:], sc.ChunkID())
+				p, err := riffbin.ReadPayload(sc)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", string(id[:]), err)
+				}
+				hydra[id] = p
+			}
+		}
+	}
+	for _, id := range hydraIDs {
+		if _, ok := hydra[id]; !ok {
+			return nil, fmt.Errorf("%s chunk is not found: %w", string(id[:]), riffbin.ErrInvalidFormat)
+		}
+	}
+
+	if err := b.decodeHydra(hydra); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *Bank) decodeHydra(hydra map[[4]byte][]byte) error {
+	presets, presetBags, err := decodePresets(hydra[hydraIDs[0]])
+	if err != nil {
+		return err
+	}
+	pbag, err := decodeBags("pbag", hydra[hydraIDs[1]])
+	if err != nil {
+		return err
+	}
+	pmod, err := decodeModulators("pmod", hydra[hydraIDs[2]])
+	if err != nil {
+		return err
+	}
+	pgen, err := decodeGenerators("pgen", hydra[hydraIDs[3]])
+	if err != nil {
+		return err
+	}
+	instruments, instBags, err := decodeInstruments(hydra[hydraIDs[4]])
+	if err != nil {
+		return err
+	}
+	ibag, err := decodeBags("ibag", hydra[hydraIDs[5]])
+	if err != nil {
+		return err
+	}
+	imod, err := decodeModulators("imod", hydra[hydraIDs[6]])
+	if err != nil {
+		return err
+	}
+	igen, err := decodeGenerators("igen", hydra[hydraIDs[7]])
+	if err != nil {
+		return err
+	}
+	samples, err := decodeSamples(hydra[hydraIDs[8]])
+	if err != nil {
+		return err
+	}
+
+	presetZones, err := decodeZones("phdr", presetBags, pbag, pgen, pmod)
+	if err != nil {
+		return err
+	}
+	for i := range presets {
+		presets[i].Zones = presetZones[i]
+	}
+	instZones, err := decodeZones("inst", instBags, ibag, igen, imod)
+	if err != nil {
+		return err
+	}
+	for i := range instruments {
+		instruments[i].Zones = instZones[i]
+	}
+
+	b.Presets = presets
+	b.Instruments = instruments
+	b.Samples = samples
+	return nil
+}
+
+// Encode encodes the bank into the chunk tree.
+// The sample data sub-chunks are shared with b by the own cursor if they are *riffbin.InStreamSubChunk or *riffbin.OnMemorySubChunk.
+func (b *Bank) Encode() (*riffbin.RIFFChunk, error) {
+	presetZones := make([][]Zone, 0, len(b.Presets))
+	for _, p := range b.Presets {
+		presetZones = append(presetZones, p.Zones)
+	}
+	presetBags, pbag, pmod, pgen, err := encodeZones("preset", presetZones)
+	if err != nil {
+		return nil, err
+	}
+
+	instZones := make([][]Zone, 0, len(b.Instruments))
+	for _, inst := range b.Instruments {
+		instZones = append(instZones, inst.Zones)
+	}
+	instBags, ibag, imod, igen, err := encodeZones("instrument", instZones)
+	if err != nil {
+		return nil, err
+	}
+
+	hydra := [][]byte{
+		encodePresets(b.Presets, presetBags), pbag, pmod, pgen,
+		encodeInstruments(b.Instruments, instBags), ibag, imod, igen,
+		encodeSamples(b.Samples),
+	}
+	pdta := make([]riffbin.Chunk, 0, len(hydraIDs))
+	for i, id := range hydraIDs {
+		pdta = append(pdta, &riffbin.OnMemorySubChunk{ID: id, Payload: hydra[i]})
+	}
+
+	sdta := []riffbin.Chunk{sampleDataChunk(smplID, b.SampleData)}
+	if b.SampleData24 != nil {
+		sdta = append(sdta, sampleDataChunk(sm24ID, b.SampleData24))
+	}
+
+	return &riffbin.RIFFChunk{
+		FormType: sfbkType,
+		Payload: []riffbin.Chunk{
+			&riffbin.ListChunk{ListType: infoType, Payload: b.Info.encode()},
+			&riffbin.ListChunk{ListType: sdtaType, Payload: sdta},
+			&riffbin.ListChunk{ListType: pdtaType, Payload: pdta},
+		},
+	}, nil
+}
+
+// WriteTo writes the bank to w by riffbin.CompletedChunkWriter with the pad bytes.
+func (b *Bank) WriteTo(w io.Writer) (int64, error) {
+	c, err := b.Encode()
+	if err != nil {
+		return 0, err
+	}
+	return riffbin.NewCompletedChunkWriter(w, riffbin.WithWritePadding()).Write(c)
+}
+
+// SampleReader returns the reader of the 16 bits sample points of the i-th sample in the sample data.
+// It reads the sample data with the own cursor, so the readers of the samples can be used concurrently.
+func (b *Bank) SampleReader(i int) (*io.SectionReader, error) {
+	if i < 0 || i >= len(b.Samples) {
+		return nil, fmt.Errorf("sample index %d is out of range", i)
+	}
+	s := b.Samples[i]
+	if s.Start > s.End {
+		return nil, fmt.Errorf("sample[%d] start %d is after end %d: %w", i, s.Start, s.End, riffbin.ErrInvalidFormat)
+	}
+
+	r, size, err := sampleDataReaderAt(b.SampleData)
+	if err != nil {
+		return nil, err
+	}
+	off, n := int64(s.Start)*sampleBytes, int64(s.End-s.Start)*sampleBytes
+	if off+n > size {
+		return nil, fmt.Errorf("sample[%d] end %d is out of the sample data: %w", i, s.End, riffbin.ErrInvalidFormat)
+	}
+	return io.NewSectionReader(r, off, n), nil
+}
+
+func sampleDataReaderAt(c riffbin.SubChunk) (io.ReaderAt, int64, error) {
+	switch cc := c.(type) {
+	case *riffbin.InStreamSubChunk:
+		return cc.SectionReader, cc.Size(), nil
+	case *riffbin.OnMemorySubChunk:
+		return bytes.NewReader(cc.Payload), int64(len(cc.Payload)), nil
+	case nil:
+		return nil, 0, fmt.Errorf("smpl chunk is not found: %w", riffbin.ErrInvalidFormat)
+	}
+	return nil, 0, fmt.Errorf("sample data %T is not random accessible", c)
+}
+
+// sampleDataChunk returns the sub-chunk of id to write the sample data.
+func sampleDataChunk(id [4]byte, c riffbin.SubChunk) riffbin.SubChunk {
+	switch cc := c.(type) {
+	case *riffbin.InStreamSubChunk:
+		return &riffbin.InStreamSubChunk{ID: id, SectionReader: io.NewSectionReader(cc.SectionReader, 0, cc.Size())}
+	case *riffbin.OnMemorySubChunk:
+		return &riffbin.OnMemorySubChunk{ID: id, Payload: cc.Payload}
+	case nil:
+		return &riffbin.OnMemorySubChunk{ID: id, Payload: []byte{}}
+	}
+	return c
+}
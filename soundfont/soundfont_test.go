@@ -0,0 +1,191 @@
+package soundfont_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/karupanerura/riffbin"
+	"github.com/karupanerura/riffbin/soundfont"
+)
+
+func newBank() *soundfont.Bank {
+	samples := make([]byte, 2*(8+46+4+46))
+	for i := range samples {
+		samples[i] = byte(i)
+	}
+
+	return &soundfont.Bank{
+		Info: soundfont.Info{
+			Version:     soundfont.Version{Major: 2, Minor: 4},
+			SoundEngine: "EMU8000",
+			Name:        "test bank",
+			ROMVersion:  &soundfont.Version{Major: 1, Minor: 0},
+			Software:    "riffbin",
+		},
+		Presets: []soundfont.Preset{
+			{
+				Name:   "Piano",
+				Preset: 1,
+				Zones: []soundfont.Zone{
+					{Generators: []soundfont.Generator{{Oper: soundfont.GenInstrument, Amount: 0}}},
+				},
+			},
+		},
+		Instruments: []soundfont.Instrument{
+			{
+				Name: "Piano",
+				Zones: []soundfont.Zone{
+					// global zone
+					{
+						Generators: []soundfont.Generator{{Oper: soundfont.GenPan, Amount: uint16(0xFFCE)}},
+						Modulators: []soundfont.Modulator{{SrcOper: 0x0502, DestOper: soundfont.GenInitialAttenuation, Amount: 960}},
+					},
+					{Generators: []soundfont.Generator{{Oper: soundfont.GenKeyRange, Amount: 0x3C00}, {Oper: soundfont.GenSampleID, Amount: 0}}},
+					{Generators: []soundfont.Generator{{Oper: soundfont.GenKeyRange, Amount: 0x7F3D}, {Oper: soundfont.GenSampleID, Amount: 1}}},
+				},
+			},
+		},
+		Samples: []soundfont.Sample{
+			{Name: "low", Start: 0, End: 8, StartLoop: 2, EndLoop: 6, SampleRate: 44100, OriginalPitch: 48, SampleType: soundfont.MonoSample},
+			{Name: "high", Start: 54, End: 58, StartLoop: 55, EndLoop: 57, SampleRate: 22050, OriginalPitch: 72, PitchCorrection: -3, SampleType: soundfont.MonoSample},
+		},
+		SampleData: &riffbin.OnMemorySubChunk{ID: [4]byte{'s', 'm', 'p', 'l'}, Payload: samples},
+	}
+}
+
+func TestBank(t *testing.T) {
+	t.Parallel()
+
+	bank := newBank()
+	var buf bytes.Buffer
+	if _, err := bank.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := soundfont.Read(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if df := cmp.Diff(got.Info, bank.Info); df != "" {
+		t.Errorf("diff = %s", df)
+	}
+	if df := cmp.Diff(got.Presets, bank.Presets); df != "" {
+		t.Errorf("diff = %s", df)
+	}
+	if df := cmp.Diff(got.Instruments, bank.Instruments); df != "" {
+		t.Errorf("diff = %s", df)
+	}
+	if df := cmp.Diff(got.Samples, bank.Samples); df != "" {
+		t.Errorf("diff = %s", df)
+	}
+	if _, ok := got.SampleData.(*riffbin.InStreamSubChunk); !ok {
+		t.Errorf("unexpected sample data: %T", got.SampleData)
+	}
+	if g, _ := got.Instruments[0].Zones[0].Lookup(soundfont.GenPan); g.Signed() != -50 {
+		t.Errorf("unexpected pan: %d", g.Signed())
+	}
+	g, _ := got.Instruments[0].Zones[2].Lookup(soundfont.GenKeyRange)
+	if lo, hi := g.Range(); lo != 61 || hi != 127 {
+		t.Errorf("unexpected key range: %d-%d", lo, hi)
+	}
+
+	r, err := got.SampleReader(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	points, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(points, []byte{108, 109, 110, 111, 112, 113, 114, 115}) {
+		t.Errorf("unexpected sample points: %v", points)
+	}
+
+	// modify and write back with the streamed sample data
+	got.Presets[0].Name = "Grand Piano"
+	got.Samples = append(got.Samples, soundfont.Sample{Name: "all", Start: 0, End: 104, SampleType: soundfont.MonoSample})
+	var rewritten bytes.Buffer
+	if _, err := got.WriteTo(&rewritten); err != nil {
+		t.Fatal(err)
+	}
+
+	modified, err := soundfont.Read(bytes.NewReader(rewritten.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if modified.Presets[0].Name != "Grand Piano" || len(modified.Samples) != 3 {
+		t.Errorf("unexpected modified bank: %+v", modified)
+	}
+	r, err = modified.SampleReader(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	points, err = io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(points, bank.SampleData.(*riffbin.OnMemorySubChunk).Payload) {
+		t.Errorf("unexpected sample data: %v", points)
+	}
+	if _, err := modified.SampleReader(3); err == nil {
+		t.Error("error is expected for the out of range sample")
+	}
+}
+
+func TestBankPadded(t *testing.T) {
+	t.Parallel()
+
+	// the odd-sized sm24 chunk is followed by the pad byte before LIST-pdta
+	bank := newBank()
+	bank.SampleData24 = &riffbin.OnMemorySubChunk{ID: [4]byte{'s', 'm', '2', '4'}, Payload: []byte{1, 2, 3}}
+	var buf bytes.Buffer
+	if _, err := bank.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := riffbin.ReadSections(bytes.NewReader(buf.Bytes()), riffbin.WithReadPadding()); err != nil {
+		t.Fatalf("written bank is not padded: %v", err)
+	}
+
+	got, err := soundfont.Read(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if df := cmp.Diff(got.Samples, bank.Samples); df != "" {
+		t.Errorf("diff = %s", df)
+	}
+	if sm24, err := riffbin.ReadPayload(got.SampleData24); err != nil || !bytes.Equal(sm24, []byte{1, 2, 3}) {
+		t.Errorf("unexpected sm24: %v, %v", sm24, err)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	t.Parallel()
+
+	valid, err := newBank().Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdta := valid.Payload[2].(*riffbin.ListChunk)
+
+	for name, c := range map[string]*riffbin.RIFFChunk{
+		"NotSoundFont": {FormType: [4]byte{'W', 'A', 'V', 'E'}},
+		"NoHydra":      {FormType: valid.FormType, Payload: valid.Payload[:2]},
+		"BrokenRecord": {FormType: valid.FormType, Payload: []riffbin.Chunk{
+			&riffbin.ListChunk{ListType: pdta.ListType, Payload: append([]riffbin.Chunk{
+				&riffbin.OnMemorySubChunk{ID: [4]byte{'p', 'h', 'd', 'r'}, Payload: make([]byte, 37)},
+			}, pdta.Payload[1:]...)},
+		}},
+		"BagOutOfRange": {FormType: valid.FormType, Payload: []riffbin.Chunk{
+			&riffbin.ListChunk{ListType: pdta.ListType, Payload: append([]riffbin.Chunk{
+				&riffbin.OnMemorySubChunk{ID: [4]byte{'p', 'h', 'd', 'r'}, Payload: append(make([]byte, 38+24), 0xFF, 0xFF, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0)},
+			}, pdta.Payload[1:]...)},
+		}},
+	} {
+		if _, err := soundfont.Decode(c); !errors.Is(err, riffbin.ErrInvalidFormat) {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+	}
+}
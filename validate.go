@@ -0,0 +1,378 @@
+package riffbin
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// Severity is a severity of the validation finding.
+type Severity int
+
+const (
+	// SeverityWarning is a severity for the finding which is not compliant but widely accepted.
+	SeverityWarning Severity = iota
+	// SeverityError is a severity for the finding which violates the specification.
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// Finding is a problem found by Validate.
+type Finding struct {
+	// Path is the path of the chunk as same as NewFS. ("." is the root RIFF chunk)
+	Path     string
+	Severity Severity
+	Message  string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s: %s", f.Severity, f.Path, f.Message)
+}
+
+// Profile is a set of the rules for the specific RIFF format.
+type Profile struct {
+	// Name is the name of the format.
+	Name string
+
+	// FormType is the form type of the root RIFF chunk.
+	FormType [typeBytes]byte
+
+	// Rules are the rules for the chunks.
+	Rules []ChunkRule
+
+	// Check is called to check the rules which cannot be expressed by Rules if it is not nil.
+	Check func(c *RIFFChunk) []Finding
+}
+
+// ChunkRule is a rule for the chunks named Name in the grouped chunk at Parent.
+// Parent and Name are the paths without the index of the occurrence (e.g. "LIST-hdrl/LIST-strl" matches "LIST-hdrl/LIST-strl.1").
+type ChunkRule struct {
+	// Parent is the path of the grouped chunk. ("." is the root RIFF chunk)
+	Parent string
+
+	// Name is the name of the chunk. (e.g. "fmt ", "LIST-INFO")
+	Name string
+
+	// Required requires the chunk in the parent.
+	Required bool
+
+	// Unique prohibits the duplicated chunks in the parent.
+	Unique bool
+
+	// First requires the chunk to be the first chunk in the parent.
+	First bool
+
+	// Before requires the chunk to precede the chunks with these names in the parent.
+	Before []string
+
+	// MinSize is the minimum body size of the chunk.
+	MinSize uint32
+}
+
+// Validate checks c with the structural rules of RIFF and the rules of profile, and returns all findings.
+// The structural rules are the pad bytes, the declared sizes, the character set of the FourCCs and the position of RIFF chunks.
+// profile can be nil to check the structural rules only.
+func Validate(c *RIFFChunk, profile *Profile) []Finding {
+	return ValidateWithLocations(c, profile, nil)
+}
+
+// ValidateWithLocations is same as Validate, but it also checks the declared sizes and the pad bytes with locs recorded by WithChunkLocations on reading c.
+// The pad bytes are checked only with the locations, because they are decided by the writer for the chunks built on memory.
+// Read c with WithReadPadding to check the files with the pad bytes.
+func ValidateWithLocations(c *RIFFChunk, profile *Profile, locs *ChunkLocations) []Finding {
+	v := &validator{profile: profile, locations: locs}
+	v.checkChunk(c, ".", ".")
+
+	if profile != nil {
+		if c.FormType != profile.FormType {
+			v.report(".", SeverityError, "form type %q is not %q of %s", c.FormType[:], profile.FormType[:], profile.Name)
+		} else if profile.Check != nil {
+			v.findings = append(v.findings, profile.Check(c)...)
+		}
+	}
+	return v.findings
+}
+
+type validator struct {
+	profile   *Profile
+	locations *ChunkLocations
+	findings  []Finding
+}
+
+func (v *validator) report(chunkPath string, severity Severity, format string, args ...interface{}) {
+	v.findings = append(v.findings, Finding{Path: chunkPath, Severity: severity, Message: fmt.Sprintf(format, args...)})
+}
+
+// namedChunk is a chunk with the path in the parent.
+type namedChunk struct {
+	base  string
+	path  string
+	chunk Chunk
+}
+
+// checkChunk checks the chunk at chunkPath. basePath is the path without the index of the occurrence.
+func (v *validator) checkChunk(c Chunk, chunkPath, basePath string) {
+	v.checkFourCC(chunkPath, "ID", c.ChunkID())
+	if loc, ok := v.locations.Lookup(c); ok {
+		if size := v.bodySize(c); loc.DeclaredSize != size {
+			v.report(chunkPath, SeverityError, "declared size %d differs from actual size %d", loc.DeclaredSize, size)
+		}
+		if chunkPath != "." && loc.DeclaredSize%2 != 0 && loc.PadBytes == 0 {
+			v.report(chunkPath, SeverityError, "odd size %d without pad byte", loc.DeclaredSize)
+		}
+	}
+
+	gc, ok := c.(groupedChunk)
+	if !ok {
+		if sc, ok := c.(SubChunk); ok && sc.Incomplete() {
+			v.report(chunkPath, SeverityWarning, "size of incomplete sub-chunk is not determined")
+		}
+		return
+	}
+
+	v.checkFourCC(chunkPath, "type", gc.groupType())
+	if _, ok := c.(*RIFFChunk); ok && chunkPath != "." {
+		v.report(chunkPath, SeverityWarning, "RIFF chunk is nested")
+	}
+
+	payload, err := groupedPayload(c)
+	if err != nil {
+		v.report(chunkPath, SeverityError, "cannot load payload: %v", err)
+		return
+	}
+
+	names := chunkNamer{}
+	children := make([]namedChunk, len(payload))
+	for i, child := range payload {
+		var typ []byte
+		if cc, ok := child.(groupedChunk); ok {
+			typ = cc.groupType()
+		}
+		name := names.next(child.ChunkID(), typ)
+		children[i] = namedChunk{base: chunkBaseName(child.ChunkID(), typ), path: path.Join(chunkPath, name), chunk: child}
+	}
+
+	if v.profile != nil {
+		for _, rule := range v.profile.Rules {
+			if rule.Parent == basePath {
+				v.checkRule(chunkPath, &rule, children)
+			}
+		}
+	}
+
+	for _, child := range children {
+		v.checkChunk(child.chunk, child.path, path.Join(basePath, child.base))
+	}
+}
+
+// bodySize returns the body size of c including the pad bytes of the chunks in c which were read from the source.
+// The chunks without the locations are counted without the pad bytes, as same as BodySize.
+func (v *validator) bodySize(c Chunk) uint32 {
+	gc, ok := c.(groupedChunk)
+	if !ok {
+		return c.BodySize()
+	}
+	if _, ok := unloadedPayload(c); ok {
+		return c.BodySize()
+	}
+
+	size := uint32(typeBytes)
+	for _, p := range gc.payload() {
+		size += HeaderBytes + v.bodySize(p)
+		if loc, ok := v.locations.Lookup(p); ok {
+			size += uint32(loc.PadBytes)
+		}
+	}
+	return size
+}
+
+func (v *validator) checkFourCC(chunkPath, what string, b []byte) {
+	s := string(b)
+	for _, c := range b {
+		if c < 0x20 || c > 0x7e {
+			v.report(chunkPath, SeverityError, "%s %q contains non-printable characters", what, s)
+			return
+		}
+	}
+
+	trimmed := strings.TrimRight(s, " ")
+	if trimmed == "" || strings.IndexFunc(trimmed, func(r rune) bool {
+		return !('0' <= r && r <= '9' || 'A' <= r && r <= 'Z' || 'a' <= r && r <= 'z')
+	}) >= 0 {
+		v.report(chunkPath, SeverityWarning, "%s %q should be alphanumeric characters padded with trailing spaces", what, s)
+	}
+}
+
+func (v *validator) checkRule(parentPath string, rule *ChunkRule, children []namedChunk) {
+	var matched []int
+	for i, child := range children {
+		if child.base == rule.Name {
+			matched = append(matched, i)
+		}
+	}
+
+	if rule.Required && len(matched) == 0 {
+		v.report(parentPath, SeverityError, "required chunk %q is missing", rule.Name)
+	}
+	if rule.Unique && len(matched) > 1 {
+		for _, i := range matched[1:] {
+			v.report(children[i].path, SeverityError, "chunk %q is duplicated", rule.Name)
+		}
+	}
+	if rule.First && len(matched) > 0 && matched[0] != 0 {
+		v.report(children[matched[0]].path, SeverityError, "chunk %q must be the first chunk", rule.Name)
+	}
+
+	for _, i := range matched {
+		child := children[i]
+		if child.chunk.BodySize() < rule.MinSize {
+			v.report(child.path, SeverityError, "size %d is smaller than %d", child.chunk.BodySize(), rule.MinSize)
+		}
+
+		for _, name := range rule.Before {
+			for _, other := range children[:i] {
+				if other.base == name {
+					v.report(child.path, SeverityError, "chunk %q must precede %q", rule.Name, name)
+					break
+				}
+			}
+		}
+	}
+}
+
+// readPayloadHead reads the head of the payload of the sub-chunk up to n bytes.
+func readPayloadHead(c Chunk, n int64) []byte {
+	sc, ok := c.(SubChunk)
+	if !ok {
+		return nil
+	}
+	r, err := openPayload(sc)
+	if err != nil {
+		return nil
+	}
+
+	b, err := io.ReadAll(io.LimitReader(r, n))
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+// ProfileWAVE is a profile for WAVE format.
+var ProfileWAVE = &Profile{
+	Name:     "WAVE",
+	FormType: [typeBytes]byte{'W', 'A', 'V', 'E'},
+	Rules: []ChunkRule{
+		{Parent: ".", Name: "fmt ", Required: true, Unique: true, Before: []string{"data"}, MinSize: 16},
+		{Parent: ".", Name: "fact", Unique: true, Before: []string{"data"}, MinSize: 4},
+		{Parent: ".", Name: "bext", Unique: true, Before: []string{"data"}, MinSize: 602},
+		{Parent: ".", Name: "data", Required: true, Unique: true},
+	},
+	Check: func(c *RIFFChunk) []Finding {
+		// check the first ones, the duplicated ones are reported by Rules
+		var format, data Chunk
+		for _, chunk := range c.Payload {
+			switch string(chunk.ChunkID()) {
+			case "fmt ":
+				if format == nil {
+					format = chunk
+				}
+			case "data":
+				if data == nil {
+					data = chunk
+				}
+			}
+		}
+		if format == nil || data == nil {
+			return nil
+		}
+
+		head := readPayloadHead(format, 16)
+		if len(head) < 16 {
+			return nil
+		}
+		blockAlign := binary.LittleEndian.Uint16(head[12:14])
+		if blockAlign == 0 {
+			return []Finding{{Path: "fmt ", Severity: SeverityError, Message: "block align is zero"}}
+		}
+		if data.BodySize()%uint32(blockAlign) != 0 {
+			return []Finding{{Path: "data", Severity: SeverityWarning, Message: fmt.Sprintf("size %d is not a multiple of block align %d", data.BodySize(), blockAlign)}}
+		}
+		return nil
+	},
+}
+
+// ProfileAVI is a profile for AVI format.
+var ProfileAVI = &Profile{
+	Name:     "AVI",
+	FormType: [typeBytes]byte{'A', 'V', 'I', ' '},
+	Rules: []ChunkRule{
+		{Parent: ".", Name: "LIST-hdrl", Required: true, Unique: true, First: true},
+		{Parent: ".", Name: "LIST-movi", Required: true, Unique: true, Before: []string{"idx1"}},
+		{Parent: ".", Name: "idx1", Unique: true},
+		{Parent: "LIST-hdrl", Name: "avih", Required: true, Unique: true, First: true, MinSize: 56},
+		{Parent: "LIST-hdrl", Name: "LIST-strl", Required: true},
+		{Parent: "LIST-hdrl/LIST-strl", Name: "strh", Required: true, Unique: true, First: true, MinSize: 48},
+		{Parent: "LIST-hdrl/LIST-strl", Name: "strf", Required: true, Unique: true},
+	},
+}
+
+// ProfileWebP is a profile for WebP format.
+var ProfileWebP = &Profile{
+	Name:     "WebP",
+	FormType: [typeBytes]byte{'W', 'E', 'B', 'P'},
+	Rules: []ChunkRule{
+		{Parent: ".", Name: "VP8X", Unique: true, First: true, MinSize: 10},
+		{Parent: ".", Name: "ICCP", Unique: true, Before: []string{"ANIM", "ALPH", "VP8 ", "VP8L"}},
+		{Parent: ".", Name: "ANIM", Unique: true, MinSize: 6},
+		{Parent: ".", Name: "ALPH", Unique: true, Before: []string{"VP8 "}},
+		{Parent: ".", Name: "VP8 ", Unique: true, Before: []string{"EXIF", "XMP "}},
+		{Parent: ".", Name: "VP8L", Unique: true, Before: []string{"EXIF", "XMP "}},
+		{Parent: ".", Name: "EXIF", Unique: true, Before: []string{"XMP "}},
+		{Parent: ".", Name: "XMP ", Unique: true},
+	},
+	Check: func(c *RIFFChunk) []Finding {
+		if len(c.Payload) == 0 {
+			return []Finding{{Path: ".", Severity: SeverityError, Message: "no image data"}}
+		}
+
+		switch first := string(c.Payload[0].ChunkID()); first {
+		case "VP8X":
+			for _, chunk := range c.Payload {
+				switch string(chunk.ChunkID()) {
+				case "VP8 ", "VP8L", "ANMF":
+					return nil
+				}
+			}
+			return []Finding{{Path: ".", Severity: SeverityError, Message: "no image data"}}
+		case "VP8 ", "VP8L":
+			if len(c.Payload) > 1 {
+				return []Finding{{Path: ".", Severity: SeverityError, Message: "extended format requires VP8X chunk"}}
+			}
+			return nil
+		default:
+			return []Finding{{Path: ".", Severity: SeverityError, Message: fmt.Sprintf("first chunk %q is not VP8 , VP8L or VP8X", first)}}
+		}
+	},
+}
+
+// LookupProfile returns the profile for the form type, or nil if it is unknown.
+func LookupProfile(formType [typeBytes]byte) *Profile {
+	for _, p := range []*Profile{ProfileWAVE, ProfileAVI, ProfileWebP} {
+		if p.FormType == formType {
+			return p
+		}
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package riffbin_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/karupanerura/riffbin"
+)
+
+func newWaveChunks() []riffbin.Chunk {
+	return []riffbin.Chunk{
+		&riffbin.OnMemorySubChunk{
+			ID: [4]byte{'f', 'm', 't', ' '},
+			Payload: []byte{
+				0x01, 0x00, // Compression Code (Linear PCM)
+				0x01, 0x00, // Number of channels (Monoral)
+				0x44, 0xAC, 0x00, 0x00, // Sample rate (44.1Hz)
+				0x88, 0x58, 0x01, 0x00, // Average bytes per second (44.1Hz/Monoral)
+				0x02, 0x00, // Block align (16bit/Monoral)
+				0x10, 0x00, // Significant bits per sample (16bit)
+			},
+		},
+		&riffbin.OnMemorySubChunk{ID: [4]byte{'d', 'a', 't', 'a'}, Payload: []byte{0x00, 0x00, 0x01, 0x00}},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	wave := func(payload ...riffbin.Chunk) *riffbin.RIFFChunk {
+		return &riffbin.RIFFChunk{FormType: [4]byte{'W', 'A', 'V', 'E'}, Payload: payload}
+	}
+	webp := func(payload ...riffbin.Chunk) *riffbin.RIFFChunk {
+		return &riffbin.RIFFChunk{FormType: [4]byte{'W', 'E', 'B', 'P'}, Payload: payload}
+	}
+	sub := func(id string, size int) riffbin.Chunk {
+		c := &riffbin.OnMemorySubChunk{Payload: make([]byte, size)}
+		copy(c.ID[:], id)
+		return c
+	}
+	list := func(typ string, payload ...riffbin.Chunk) riffbin.Chunk {
+		c := &riffbin.ListChunk{Payload: payload}
+		copy(c.ListType[:], typ)
+		return c
+	}
+	waveChunks := newWaveChunks()
+	fmtChunk, dataChunk := waveChunks[0], waveChunks[1]
+
+	for _, tc := range []struct {
+		name     string
+		chunk    *riffbin.RIFFChunk
+		profile  *riffbin.Profile
+		expected []riffbin.Finding
+	}{
+		{
+			name:    "ValidWAVE",
+			chunk:   wave(fmtChunk, list("INFO", sub("INAM", 4)), dataChunk),
+			profile: riffbin.ProfileWAVE,
+		},
+		{
+			name:    "InvalidWAVE",
+			chunk:   wave(sub("data", 3), fmtChunk, dataChunk, sub("fa%t", 2)),
+			profile: riffbin.ProfileWAVE,
+			expected: []riffbin.Finding{
+				{Path: "fmt ", Severity: riffbin.SeverityError, Message: `chunk "fmt " must precede "data"`},
+				{Path: "data.1", Severity: riffbin.SeverityError, Message: `chunk "data" is duplicated`},
+				{Path: "fa%25t", Severity: riffbin.SeverityWarning, Message: `ID "fa%t" should be alphanumeric characters padded with trailing spaces`},
+				{Path: "data", Severity: riffbin.SeverityWarning, Message: `size 3 is not a multiple of block align 2`},
+			},
+		},
+		{
+			name:    "MissingWAVE",
+			chunk:   wave(sub("fmt ", 14)),
+			profile: riffbin.ProfileWAVE,
+			expected: []riffbin.Finding{
+				{Path: "fmt ", Severity: riffbin.SeverityError, Message: `size 14 is smaller than 16`},
+				{Path: ".", Severity: riffbin.SeverityError, Message: `required chunk "data" is missing`},
+			},
+		},
+		{
+			name:    "FormTypeMismatch",
+			chunk:   webp(sub("VP8L", 2)),
+			profile: riffbin.ProfileWAVE,
+			expected: []riffbin.Finding{
+				{Path: ".", Severity: riffbin.SeverityError, Message: `required chunk "fmt " is missing`},
+				{Path: ".", Severity: riffbin.SeverityError, Message: `required chunk "data" is missing`},
+				{Path: ".", Severity: riffbin.SeverityError, Message: `form type "WEBP" is not "WAVE" of WAVE`},
+			},
+		},
+		{
+			name:  "Structural",
+			chunk: wave(sub("A\x00BC", 2), sub(" abc", 2), list("IN\xffO", sub("ISFT", 1)), &riffbin.RIFFChunk{FormType: [4]byte{'A', 'V', 'I', 'X'}, Payload: []riffbin.Chunk{}}),
+			expected: []riffbin.Finding{
+				{Path: "A%00BC", Severity: riffbin.SeverityError, Message: `ID "A\x00BC" contains non-printable characters`},
+				{Path: " abc", Severity: riffbin.SeverityWarning, Message: `ID " abc" should be alphanumeric characters padded with trailing spaces`},
+				{Path: "LIST-IN%FFO", Severity: riffbin.SeverityError, Message: `type "IN\xffO" contains non-printable characters`},
+				{Path: "RIFF-AVIX", Severity: riffbin.SeverityWarning, Message: `RIFF chunk is nested`},
+			},
+		},
+		{
+			name: "ValidAVI",
+			chunk: &riffbin.RIFFChunk{
+				FormType: [4]byte{'A', 'V', 'I', ' '},
+				Payload: []riffbin.Chunk{
+					list("hdrl", sub("avih", 56), list("strl", sub("strh", 56), sub("strf", 40)), list("strl", sub("strh", 56), sub("strf", 40))),
+					list("movi", sub("00dc", 2)),
+					sub("idx1", 16),
+				},
+			},
+			profile: riffbin.ProfileAVI,
+		},
+		{
+			name: "InvalidAVI",
+			chunk: &riffbin.RIFFChunk{
+				FormType: [4]byte{'A', 'V', 'I', ' '},
+				Payload: []riffbin.Chunk{
+					sub("JUNK", 2),
+					list("hdrl", sub("avih", 56), list("strl", sub("strh", 56)), list("strl", sub("strf", 40), sub("strh", 56))),
+					sub("idx1", 16),
+					list("movi"),
+				},
+			},
+			profile: riffbin.ProfileAVI,
+			expected: []riffbin.Finding{
+				{Path: "LIST-hdrl", Severity: riffbin.SeverityError, Message: `chunk "LIST-hdrl" must be the first chunk`},
+				{Path: "LIST-movi", Severity: riffbin.SeverityError, Message: `chunk "LIST-movi" must precede "idx1"`},
+				{Path: "LIST-hdrl/LIST-strl", Severity: riffbin.SeverityError, Message: `required chunk "strf" is missing`},
+				{Path: "LIST-hdrl/LIST-strl.1/strh", Severity: riffbin.SeverityError, Message: `chunk "strh" must be the first chunk`},
+			},
+		},
+		{
+			name:    "ValidSimpleWebP",
+			chunk:   webp(sub("VP8L", 2)),
+			profile: riffbin.ProfileWebP,
+		},
+		{
+			name:    "ValidExtendedWebP",
+			chunk:   webp(sub("VP8X", 10), sub("ICCP", 2), sub("VP8 ", 2), sub("EXIF", 2), sub("XMP ", 2)),
+			profile: riffbin.ProfileWebP,
+		},
+		{
+			name:    "InvalidWebP",
+			chunk:   webp(sub("VP8 ", 2), sub("XMP ", 2), sub("EXIF", 2), sub("VP8X", 10)),
+			profile: riffbin.ProfileWebP,
+			expected: []riffbin.Finding{
+				{Path: "VP8X", Severity: riffbin.SeverityError, Message: `chunk "VP8X" must be the first chunk`},
+				{Path: "EXIF", Severity: riffbin.SeverityError, Message: `chunk "EXIF" must precede "XMP "`},
+				{Path: ".", Severity: riffbin.SeverityError, Message: `extended format requires VP8X chunk`},
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if df := cmp.Diff(riffbin.Validate(tc.chunk, tc.profile), tc.expected); df != "" {
+				t.Errorf("diff = %s", df)
+			}
+		})
+	}
+
+	t.Run("DeclaredSize", func(t *testing.T) {
+		t.Parallel()
+
+		var src bytes.Buffer
+		if _, err := riffbin.NewCompletedChunkWriter(&src).Write(wave(fmtChunk, list("INFO", sub("INAM", 4), sub("ISFT", 2)), dataChunk)); err != nil {
+			t.Fatal(err)
+		}
+
+		locs := &riffbin.ChunkLocations{}
+		riffChunk, err := riffbin.ReadSections(bytes.NewReader(src.Bytes()), riffbin.WithChunkLocations(locs))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if findings := riffbin.ValidateWithLocations(riffChunk, riffbin.LookupProfile(riffChunk.FormType), locs); len(findings) != 0 {
+			t.Errorf("unexpected findings: %v", findings)
+		}
+
+		if err := riffbin.DeleteChunk(riffChunk, "LIST-INFO/ISFT"); err != nil {
+			t.Fatal(err)
+		}
+		expected := []riffbin.Finding{
+			{Path: ".", Severity: riffbin.SeverityError, Message: "declared size 74 differs from actual size 64"},
+			{Path: "LIST-INFO", Severity: riffbin.SeverityError, Message: "declared size 26 differs from actual size 16"},
+		}
+		if df := cmp.Diff(riffbin.ValidateWithLocations(riffChunk, nil, locs), expected); df != "" {
+			t.Errorf("diff = %s", df)
+		}
+		if findings := riffbin.Validate(riffChunk, nil); len(findings) != 0 {
+			t.Errorf("unexpected findings without locations: %v", findings)
+		}
+	})
+
+	t.Run("PadBytes", func(t *testing.T) {
+		t.Parallel()
+
+		newWebP := func() *riffbin.RIFFChunk {
+			return webp(sub("VP8X", 10), sub("VP8 ", 3), list("INFO", sub("INAM", 5)), sub("EXIF", 1))
+		}
+		for _, tc := range []struct {
+			name     string
+			opts     []riffbin.WriterOption
+			expected []riffbin.Finding
+		}{
+			{
+				name: "Padded",
+				opts: []riffbin.WriterOption{riffbin.WithWritePadding()},
+			},
+			{
+				name: "Unpadded",
+				expected: []riffbin.Finding{
+					{Path: "VP8 ", Severity: riffbin.SeverityError, Message: "odd size 3 without pad byte"},
+					{Path: "LIST-INFO", Severity: riffbin.SeverityError, Message: "odd size 17 without pad byte"},
+					{Path: "LIST-INFO/INAM", Severity: riffbin.SeverityError, Message: "odd size 5 without pad byte"},
+					{Path: "EXIF", Severity: riffbin.SeverityError, Message: "odd size 1 without pad byte"},
+				},
+			},
+		} {
+			var src bytes.Buffer
+			if _, err := riffbin.NewCompletedChunkWriter(&src, tc.opts...).Write(newWebP()); err != nil {
+				t.Fatal(err)
+			}
+
+			// the pad bytes are read only if the file has them
+			locs := &riffbin.ChunkLocations{}
+			riffChunk, err := riffbin.ReadSections(bytes.NewReader(src.Bytes()), riffbin.WithReadPadding(), riffbin.WithChunkLocations(locs))
+			if err != nil {
+				locs = &riffbin.ChunkLocations{}
+				riffChunk, err = riffbin.ReadSections(bytes.NewReader(src.Bytes()), riffbin.WithChunkLocations(locs))
+			}
+			if err != nil {
+				t.Fatalf("%s: %v", tc.name, err)
+			}
+			if df := cmp.Diff(riffbin.ValidateWithLocations(riffChunk, riffbin.ProfileWebP, locs), tc.expected); df != "" {
+				t.Errorf("%s: diff = %s", tc.name, df)
+			}
+		}
+	})
+}
@@ -0,0 +1,240 @@
+package riffbin
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	guidBytes = 16
+
+	// W64HeaderBytes is the size of the chunk header of Wave64. The size field includes the header itself.
+	W64HeaderBytes = guidBytes + 8
+
+	// w64Alignment is the alignment of the chunks of Wave64.
+	w64Alignment = 8
+)
+
+// GUID is a 16 bytes chunk ID of Sony Wave64 in the byte order of the binary.
+type GUID [guidBytes]byte
+
+// fourCCGUIDSuffix is the suffix of the GUIDs derived from FourCCs. ("xxxxxxxx-ACF3-11D3-8CD1-00C04F8EDB8A")
+var fourCCGUIDSuffix = [guidBytes - idBytes]byte{0xF3, 0xAC, 0xD3, 0x11, 0x8C, 0xD1, 0x00, 0xC0, 0x4F, 0x8E, 0xDB, 0x8A}
+
+var (
+	// W64RIFF is the ID of the root chunk of Wave64. ("66666972-912E-11CF-A5D6-28DB04C10000")
+	W64RIFF = GUID{'r', 'i', 'f', 'f', 0x2E, 0x91, 0xCF, 0x11, 0xA5, 0xD6, 0x28, 0xDB, 0x04, 0xC1, 0x00, 0x00}
+
+	// W64LIST is the ID of the list chunk of Wave64. ("7473696C-912F-11CF-A5D6-28DB04C10000")
+	W64LIST = GUID{'l', 'i', 's', 't', 0x2F, 0x91, 0xCF, 0x11, 0xA5, 0xD6, 0x28, 0xDB, 0x04, 0xC1, 0x00, 0x00}
+
+	// W64WAVE is the form type of Wave64.
+	W64WAVE = FourCCGUID([idBytes]byte{'w', 'a', 'v', 'e'})
+)
+
+// FourCCGUID returns the Wave64 GUID derived from the FourCC. (e.g. "fmt ", "data")
+func FourCCGUID(fourCC [idBytes]byte) GUID {
+	var g GUID
+	copy(g[:], fourCC[:])
+	copy(g[idBytes:], fourCCGUIDSuffix[:])
+	return g
+}
+
+// FourCC returns the FourCC if g is derived from it.
+func (g GUID) FourCC() ([idBytes]byte, bool) {
+	var fourCC [idBytes]byte
+	copy(fourCC[:], g[:])
+	return fourCC, bytes.Equal(g[idBytes:], fourCCGUIDSuffix[:])
+}
+
+// String returns the canonical form of the GUID. (e.g. "66666972-912E-11CF-A5D6-28DB04C10000")
+func (g GUID) String() string {
+	return fmt.Sprintf("%08X-%04X-%04X-%X-%X",
+		binary.LittleEndian.Uint32(g[0:]), binary.LittleEndian.Uint16(g[4:]), binary.LittleEndian.Uint16(g[6:]), g[8:10], g[10:])
+}
+
+// name returns the name of the GUID in the chunk paths. The GUIDs derived from FourCCs and the well-known GUIDs are named by the FourCCs.
+func (g GUID) name() string {
+	if _, ok := g.FourCC(); ok || g == W64RIFF || g == W64LIST {
+		return escapeFourCC(g[:idBytes])
+	}
+	return g.String()
+}
+
+// W64Chunk is a chunk of Sony Wave64.
+type W64Chunk interface {
+	// ChunkID is the chunk ID.
+	ChunkID() GUID
+
+	// BodySize is byte length of the chunk body. It does not include the header and the padding.
+	BodySize() uint64
+}
+
+type w64GroupedChunk interface {
+	W64Chunk
+
+	groupType() GUID
+	payload() []W64Chunk
+}
+
+// W64RIFFChunk is a riff chunk of Wave64. This is must be the root chunk.
+type W64RIFFChunk struct {
+	FormType GUID
+	Payload  []W64Chunk
+}
+
+var _ w64GroupedChunk = (*W64RIFFChunk)(nil)
+
+func (c *W64RIFFChunk) ChunkID() GUID {
+	return W64RIFF
+}
+
+func (c *W64RIFFChunk) BodySize() uint64 {
+	return w64ChunkFormat.bodySize(c)
+}
+
+func (c *W64RIFFChunk) groupType() GUID {
+	return c.FormType
+}
+
+func (c *W64RIFFChunk) payload() []W64Chunk {
+	return c.Payload
+}
+
+// W64ListChunk is a list chunk of Wave64.
+type W64ListChunk struct {
+	ListType GUID
+	Payload  []W64Chunk
+}
+
+var _ w64GroupedChunk = (*W64ListChunk)(nil)
+
+func (c *W64ListChunk) ChunkID() GUID {
+	return W64LIST
+}
+
+func (c *W64ListChunk) BodySize() uint64 {
+	return w64ChunkFormat.bodySize(c)
+}
+
+func (c *W64ListChunk) groupType() GUID {
+	return c.ListType
+}
+
+func (c *W64ListChunk) payload() []W64Chunk {
+	return c.Payload
+}
+
+// W64SubChunk is a sub-chunk of Wave64. The payload is provided by SubChunk. (e.g. *OnMemorySubChunk, *InStreamSubChunk, *IncompleteSubChunk)
+// The ID of SubChunk is ignored, and the body size is not limited to 32 bits.
+type W64SubChunk struct {
+	ID GUID
+	SubChunk
+}
+
+var _ W64Chunk = (*W64SubChunk)(nil)
+
+// NewW64IncompleteSubChunk creates a new W64SubChunk with the incomplete payload provided from io.Reader.
+func NewW64IncompleteSubChunk(id GUID, r io.Reader) *W64SubChunk {
+	var fourCC [idBytes]byte
+	copy(fourCC[:], id[:])
+	return &W64SubChunk{ID: id, SubChunk: NewIncompleteSubChunk(fourCC, r)}
+}
+
+func (c *W64SubChunk) ChunkID() GUID {
+	return c.ID
+}
+
+func (c *W64SubChunk) BodySize() uint64 {
+	return subChunkSize(c.SubChunk)
+}
+
+// w64Tree is the chunk tree of Wave64.
+type w64Tree struct{}
+
+var _ chunkTree = w64Tree{}
+
+func (w64Tree) isRoot(id []byte) bool {
+	return bytes.Equal(W64RIFF[:], id)
+}
+
+func (w64Tree) isGroup(id []byte) bool {
+	return bytes.Equal(W64LIST[:], id) || bytes.Equal(W64RIFF[:], id)
+}
+
+func (w64Tree) validChild(parent, id []byte, siblings []interface{}) bool {
+	return true
+}
+
+func (w64Tree) newGroup(id, typ []byte, payload []interface{}) interface{} {
+	var t GUID
+	copy(t[:], typ)
+
+	chunks := make([]W64Chunk, len(payload))
+	for i, p := range payload {
+		chunks[i] = p.(W64Chunk)
+	}
+	if bytes.Equal(W64LIST[:], id) {
+		return &W64ListChunk{ListType: t, Payload: chunks}
+	}
+	return &W64RIFFChunk{FormType: t, Payload: chunks}
+}
+
+func (w64Tree) newSubChunk(id []byte, c SubChunk) interface{} {
+	chunk := &W64SubChunk{SubChunk: c}
+	copy(chunk.ID[:], id)
+	return chunk
+}
+
+func (w64Tree) name(id, typ []byte) string {
+	var g GUID
+	copy(g[:], id)
+	name := g.name()
+	if typ != nil {
+		var t GUID
+		copy(t[:], typ)
+		name += "-" + t.name()
+	}
+	return name
+}
+
+func (w64Tree) label(id []byte) string {
+	var g GUID
+	copy(g[:], id)
+	return g.String()
+}
+
+func (w64Tree) chunkID(c interface{}) []byte {
+	id := c.(W64Chunk).ChunkID()
+	return id[:]
+}
+
+func (w64Tree) groupType(c interface{}) ([]byte, bool) {
+	if cc, ok := c.(w64GroupedChunk); ok {
+		typ := cc.groupType()
+		return typ[:], true
+	}
+	return nil, false
+}
+
+func (w64Tree) payload(c interface{}) []interface{} {
+	payload := c.(w64GroupedChunk).payload()
+	chunks := make([]interface{}, len(payload))
+	for i, p := range payload {
+		chunks[i] = p
+	}
+	return chunks
+}
+
+func (w64Tree) subChunk(c interface{}) (SubChunk, bool) {
+	if cc, ok := c.(*W64SubChunk); ok {
+		return cc.SubChunk, true
+	}
+	return nil, false
+}
+
+func (w64Tree) leafSize(c interface{}) uint64 {
+	return c.(W64Chunk).BodySize()
+}
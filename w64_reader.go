@@ -0,0 +1,44 @@
+package riffbin
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// ReadW64Full reads Sony Wave64 binary from io.Reader.
+// It creates *W64RIFFChunk with *W64SubChunk of *OnMemorySubChunk for sub-chunks.
+func ReadW64Full(r io.Reader) (*W64RIFFChunk, error) {
+	return ReadW64FullContext(context.Background(), r, nil)
+}
+
+// ReadW64FullContext is same as ReadW64Full, but it stops reading when ctx is done.
+// progress is called with the path of the chunk in reading and the total bytes read if it is not nil.
+func ReadW64FullContext(ctx context.Context, r io.Reader, progress ProgressFunc) (*W64RIFFChunk, error) {
+	return readW64(&readState{ctx: ctx, src: r, progress: progress, format: w64ChunkFormat}, createOnMemorySubChunk)
+}
+
+// ReadW64Sections reads Sony Wave64 binary from io.ReadSeeker to use less memory than ReadW64Full.
+// It creates *W64RIFFChunk with *W64SubChunk of *InStreamSubChunk for sub-chunks.
+func ReadW64Sections(r PartialReader) (*W64RIFFChunk, error) {
+	return ReadW64SectionsContext(context.Background(), r, nil)
+}
+
+// ReadW64SectionsContext is same as ReadW64Sections, but it stops reading when ctx is done.
+// progress is called with the path of the chunk in reading and the total bytes read or skipped if it is not nil.
+func ReadW64SectionsContext(ctx context.Context, r PartialReader, progress ProgressFunc) (*W64RIFFChunk, error) {
+	pos, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("get seek position: %w", err)
+	}
+
+	return readW64(&readState{ctx: ctx, src: r, progress: progress, base: pos, format: w64ChunkFormat}, createInStreamSubChunk)
+}
+
+func readW64(s *readState, f subChunkConstructorFn) (*W64RIFFChunk, error) {
+	chunk, err := readRoot(s, f)
+	if err != nil {
+		return nil, err
+	}
+	return chunk.(*W64RIFFChunk), nil
+}
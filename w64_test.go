@@ -0,0 +1,202 @@
+package riffbin_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/karupanerura/riffbin"
+)
+
+func TestGUID(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		guid     riffbin.GUID
+		expected string
+	}{
+		{riffbin.W64RIFF, "66666972-912E-11CF-A5D6-28DB04C10000"},
+		{riffbin.W64LIST, "7473696C-912F-11CF-A5D6-28DB04C10000"},
+		{riffbin.W64WAVE, "65766177-ACF3-11D3-8CD1-00C04F8EDB8A"},
+		{riffbin.FourCCGUID([4]byte{'d', 'a', 't', 'a'}), "61746164-ACF3-11D3-8CD1-00C04F8EDB8A"},
+	} {
+		if got := tc.guid.String(); got != tc.expected {
+			t.Errorf("unexpected GUID: %s (expected: %s)", got, tc.expected)
+		}
+	}
+
+	if fourCC, ok := riffbin.W64WAVE.FourCC(); !ok || fourCC != [4]byte{'w', 'a', 'v', 'e'} {
+		t.Errorf("unexpected FourCC: %q %v", fourCC, ok)
+	}
+	if _, ok := riffbin.W64RIFF.FourCC(); ok {
+		t.Error("riff GUID is not derived from FourCC")
+	}
+}
+
+func newW64Chunk() *riffbin.W64RIFFChunk {
+	return &riffbin.W64RIFFChunk{
+		FormType: riffbin.W64WAVE,
+		Payload: []riffbin.W64Chunk{
+			&riffbin.W64SubChunk{ID: riffbin.FourCCGUID([4]byte{'f', 'm', 't', ' '}), SubChunk: &riffbin.OnMemorySubChunk{Payload: bytes.Repeat([]byte{0x01}, 16)}},
+			&riffbin.W64ListChunk{
+				ListType: riffbin.FourCCGUID([4]byte{'I', 'N', 'F', 'O'}),
+				Payload: []riffbin.W64Chunk{
+					// odd size to be padded
+					&riffbin.W64SubChunk{ID: riffbin.FourCCGUID([4]byte{'I', 'N', 'A', 'M'}), SubChunk: &riffbin.OnMemorySubChunk{Payload: []byte("name")}},
+				},
+			},
+			&riffbin.W64SubChunk{ID: riffbin.GUID{0x01, 0x02, 0x03}, SubChunk: &riffbin.OnMemorySubChunk{Payload: []byte{0x01, 0x02, 0x03}}},
+			&riffbin.W64SubChunk{ID: riffbin.FourCCGUID([4]byte{'d', 'a', 't', 'a'}), SubChunk: &riffbin.OnMemorySubChunk{Payload: []byte{0x00, 0x00, 0x01, 0x00, 0x02, 0x00}}},
+		},
+	}
+}
+
+func TestW64Writer(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	n, err := riffbin.NewW64CompletedChunkWriter(&buf).Write(newW64Chunk())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// riff(24+16) + fmt(24+16) + list(24+16 + INAM(24+4+4)) + custom(24+3+5) + data(24+6+2)
+	const expectedSize = 40 + 40 + 72 + 32 + 32
+	if n != expectedSize || buf.Len() != expectedSize {
+		t.Fatalf("unexpected size: %d", n)
+	}
+	b := buf.Bytes()
+	if !bytes.Equal(b[:16], riffbin.W64RIFF[:]) || binary.LittleEndian.Uint64(b[16:]) != expectedSize {
+		t.Errorf("unexpected riff header: %s", hex.Dump(b[:24]))
+	}
+	// the size of list includes the padding of INAM
+	if got := binary.LittleEndian.Uint64(b[80+16:]); got != 72 {
+		t.Errorf("unexpected list size: %d", got)
+	}
+	// the size of the sub-chunk does not include the padding
+	if got := binary.LittleEndian.Uint64(b[152+16:]); got != 24+3 {
+		t.Errorf("unexpected sub-chunk size: %d", got)
+	}
+
+	t.Run("ReadFull", func(t *testing.T) {
+		t.Parallel()
+
+		c, err := riffbin.ReadW64Full(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got bytes.Buffer
+		if _, err := riffbin.NewW64CompletedChunkWriter(&got).Write(c); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got.Bytes(), b) {
+			t.Errorf("unexpected round trip: %s", hex.Dump(got.Bytes()))
+		}
+	})
+
+	t.Run("ReadSections", func(t *testing.T) {
+		t.Parallel()
+
+		var paths []string
+		c, err := riffbin.ReadW64SectionsContext(context.Background(), bytes.NewReader(b), func(path string, n int64) {
+			if len(paths) == 0 || paths[len(paths)-1] != path {
+				paths = append(paths, path)
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(c.Payload) != 4 {
+			t.Fatalf("unexpected payload: %d", len(c.Payload))
+		}
+		if custom := c.Payload[2].(*riffbin.W64SubChunk); custom.ID != (riffbin.GUID{0x01, 0x02, 0x03}) || custom.BodySize() != 3 {
+			t.Errorf("unexpected sub-chunk: %s[%d]", custom.ID, custom.BodySize())
+		}
+		if _, ok := c.Payload[3].(*riffbin.W64SubChunk).SubChunk.(*riffbin.InStreamSubChunk); !ok {
+			t.Errorf("unexpected sub-chunk: %T", c.Payload[3].(*riffbin.W64SubChunk).SubChunk)
+		}
+		if data, _ := io.ReadAll(c.Payload[3].(*riffbin.W64SubChunk)); !bytes.Equal(data, []byte{0x00, 0x00, 0x01, 0x00, 0x02, 0x00}) {
+			t.Errorf("unexpected data: %v", data)
+		}
+
+		expectedPaths := []string{".", "fmt ", "list-INFO", "list-INFO/INAM", "list-INFO", "00030201-0000-0000-0000-000000000000", ".", "data", "."}
+		if len(paths) != len(expectedPaths) {
+			t.Fatalf("unexpected paths: %q", paths)
+		}
+		for i := range paths {
+			if paths[i] != expectedPaths[i] {
+				t.Errorf("unexpected paths: %q", paths)
+				break
+			}
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+
+		for name, input := range map[string][]byte{
+			"Empty":     {},
+			"Truncated": b[:len(b)-8],
+			"TooLong":   append(append([]byte{}, b...), make([]byte, 8)...),
+			"NotRIFF":   append(append([]byte{}, riffbin.W64LIST[:]...), b[16:]...),
+		} {
+			if _, err := riffbin.ReadW64Full(bytes.NewReader(input)); err != riffbin.ErrInvalidFormat {
+				t.Errorf("%s: unexpected error on ReadW64Full: %v", name, err)
+			}
+			if _, err := riffbin.ReadW64Sections(bytes.NewReader(input)); err != riffbin.ErrInvalidFormat {
+				t.Errorf("%s: unexpected error on ReadW64Sections: %v", name, err)
+			}
+		}
+	})
+}
+
+func TestW64IncompleteChunkWriter(t *testing.T) {
+	t.Parallel()
+
+	for name, wrap := range map[string]func(f *os.File) io.WriteSeeker{
+		"WriterAt":    func(f *os.File) io.WriteSeeker { return f },
+		"WriteSeeker": func(f *os.File) io.WriteSeeker { return &pureWriteSeeker{W: f} },
+	} {
+		wrap := wrap
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := newW64Chunk()
+			c.Payload[3] = riffbin.NewW64IncompleteSubChunk(riffbin.FourCCGUID([4]byte{'d', 'a', 't', 'a'}), bytes.NewReader([]byte{0x00, 0x00, 0x01, 0x00, 0x02, 0x00}))
+			c.Payload = append(c.Payload, &riffbin.W64SubChunk{ID: riffbin.FourCCGUID([4]byte{'t', 'a', 'i', 'l'}), SubChunk: &riffbin.OnMemorySubChunk{Payload: []byte{0x01}}})
+
+			f := writeTempFile(t, nil)
+			w, err := riffbin.NewW64IncompleteChunkWriter(wrap(f))
+			if err != nil {
+				t.Fatal(err)
+			}
+			n, err := w.Write(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pos, _ := f.Seek(0, io.SeekCurrent); pos != n {
+				t.Errorf("unexpected seek position: %d (written: %d)", pos, n)
+			}
+
+			b, err := os.ReadFile(f.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := riffbin.ReadW64Full(bytes.NewReader(b))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if data := got.Payload[3].(*riffbin.W64SubChunk).SubChunk.(*riffbin.OnMemorySubChunk); !bytes.Equal(data.Payload, []byte{0x00, 0x00, 0x01, 0x00, 0x02, 0x00}) {
+				t.Errorf("unexpected data: %v", data.Payload)
+			}
+			if tail := got.Payload[4].(*riffbin.W64SubChunk).SubChunk.(*riffbin.OnMemorySubChunk); !bytes.Equal(tail.Payload, []byte{0x01}) {
+				t.Errorf("unexpected tail: %v", tail.Payload)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package riffbin
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// W64CompletedChunkWriter is a Sony Wave64 chunk writer for the completed chunk.
+type W64CompletedChunkWriter struct {
+	w io.Writer
+}
+
+// NewW64CompletedChunkWriter creates a new W64CompletedChunkWriter.
+func NewW64CompletedChunkWriter(w io.Writer) *W64CompletedChunkWriter {
+	return &W64CompletedChunkWriter{w: w}
+}
+
+// Write writes the Wave64 message to the underlying data stream.
+// It returns the number of bytes written and any error encountered that caused the write to stop early. (same as Write of io.Writer)
+func (w *W64CompletedChunkWriter) Write(c *W64RIFFChunk) (int64, error) {
+	return w.WriteContext(context.Background(), c, nil)
+}
+
+// WriteContext is same as Write, but it stops writing when ctx is done.
+// progress is called with the path of the chunk in writing and the total bytes written if it is not nil.
+func (w *W64CompletedChunkWriter) WriteContext(ctx context.Context, c *W64RIFFChunk, progress ProgressFunc) (int64, error) {
+	return writeChunk(&writeState{ctx: ctx, w: w.w, progress: progress, format: w64ChunkFormat}, c)
+}
+
+// W64IncompleteChunkWriter is a Sony Wave64 chunk writer for the incomplete chunk.
+type W64IncompleteChunkWriter struct {
+	w    io.WriteSeeker
+	head int64
+}
+
+// NewW64IncompleteChunkWriter creates a new W64IncompleteChunkWriter.
+func NewW64IncompleteChunkWriter(w io.WriteSeeker) (*W64IncompleteChunkWriter, error) {
+	pos, err := w.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("seek: %w", err)
+	}
+
+	return &W64IncompleteChunkWriter{w: w, head: pos}, nil
+}
+
+// Write writes the Wave64 message to the underlying data stream, and re-write the bytes of the all chunk headers size to fix incomplete body bytes by random write.
+// It returns the number of bytes written and any error encountered that caused the write to stop early. (same as Write of io.Writer)
+func (w *W64IncompleteChunkWriter) Write(c *W64RIFFChunk) (int64, error) {
+	return w.WriteContext(context.Background(), c, nil)
+}
+
+// WriteContext is same as Write, but it stops writing when ctx is done.
+// progress is called with the path of the chunk in writing and the total bytes written if it is not nil.
+// The chunk headers are not re-written if it is stopped by ctx.
+func (w *W64IncompleteChunkWriter) WriteContext(ctx context.Context, c *W64RIFFChunk, progress ProgressFunc) (n int64, err error) {
+	n, err = writeChunk(&writeState{ctx: ctx, w: w.w, progress: progress, format: w64ChunkFormat, allowIncomplete: true}, c)
+	if err != nil {
+		err = fmt.Errorf("writeChunk at first: %w", err)
+		return
+	}
+
+	err = patchBodySizes(w.w, w64ChunkFormat, c, w.head, n)
+	return
+}
@@ -0,0 +1,248 @@
+package riffbin
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+var ErrFormatMismatch = errors.New("format mismatch")
+
+var (
+	waveType = [typeBytes]byte{'W', 'A', 'V', 'E'}
+	infoType = [typeBytes]byte{'I', 'N', 'F', 'O'}
+	fmtID    = [idBytes]byte{'f', 'm', 't', ' '}
+	dataID   = [idBytes]byte{'d', 'a', 't', 'a'}
+	cueID    = [idBytes]byte{'c', 'u', 'e', ' '}
+)
+
+// waveChunks is the chunks of WAVE to concatenate or split.
+type waveChunks struct {
+	format SubChunk
+	data   SubChunk
+	info   *ListChunk
+}
+
+func lookupWAVEChunks(c *RIFFChunk) (*waveChunks, error) {
+	if c.FormType != waveType {
+		return nil, fmt.Errorf("form type %q is not WAVE: %w", string(c.FormType[:]), ErrInvalidFormat)
+	}
+
+	wc := &waveChunks{}
+	for _, chunk := range c.Payload {
+		switch cc := chunk.(type) {
+		case *ListChunk:
+			if wc.info == nil && cc.ListType == infoType {
+				wc.info = cc
+			}
+		case SubChunk:
+			var id [idBytes]byte
+			copy(id[:], cc.ChunkID())
+			switch {
+			case id == fmtID && wc.format == nil:
+				wc.format = cc
+			case id == dataID && wc.data == nil:
+				wc.data = cc
+			}
+		}
+	}
+	if wc.format == nil {
+		return nil, fmt.Errorf("fmt chunk is not found: %w", ErrInvalidFormat)
+	}
+	if wc.data == nil {
+		return nil, fmt.Errorf("data chunk is not found: %w", ErrInvalidFormat)
+	}
+	return wc, nil
+}
+
+func (wc *waveChunks) blockAlign() (int64, error) {
+	head := readPayloadHead(wc.format, 16)
+	if len(head) < 16 {
+		return 0, fmt.Errorf("fmt chunk is too short: %w", ErrInvalidFormat)
+	}
+	blockAlign := binary.LittleEndian.Uint16(head[12:14])
+	if blockAlign == 0 {
+		return 0, fmt.Errorf("block align is zero: %w", ErrInvalidFormat)
+	}
+	return int64(blockAlign), nil
+}
+
+// WAVEBlockAlign returns the bytes of a sample frame of the WAVE. It is useful to convert the frame offsets to the byte offsets for SplitWAVE.
+func WAVEBlockAlign(c *RIFFChunk) (int64, error) {
+	wc, err := lookupWAVEChunks(c)
+	if err != nil {
+		return 0, err
+	}
+	return wc.blockAlign()
+}
+
+// WAVECueOffsets returns the byte offsets in the data chunk of the cue points in the WAVE, in ascending order without duplicates.
+// It returns empty if the WAVE has no cue chunk.
+func WAVECueOffsets(c *RIFFChunk) ([]int64, error) {
+	wc, err := lookupWAVEChunks(c)
+	if err != nil {
+		return nil, err
+	}
+	blockAlign, err := wc.blockAlign()
+	if err != nil {
+		return nil, err
+	}
+	l, err := ReadCueList(c)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[int64]bool{}
+	offsets := make([]int64, 0, len(l))
+	for _, p := range l {
+		off := int64(p.Position) * blockAlign
+		if !seen[off] {
+			seen[off] = true
+			offsets = append(offsets, off)
+		}
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+	return offsets, nil
+}
+
+// ConcatWAVE concatenates the WAVEs which have the identical fmt chunk into a WAVE, and writes it to w by IncompleteChunkWriter.
+// The data payloads are streamed as they are, and the LIST-INFO chunk of the first WAVE is carried over.
+// It returns ErrFormatMismatch if the fmt chunks are not identical.
+func ConcatWAVE(w io.WriteSeeker, srcs []*RIFFChunk, opts ...WriterOption) (int64, error) {
+	if len(srcs) == 0 {
+		return 0, errors.New("no WAVE to concatenate")
+	}
+
+	var format []byte
+	var info *ListChunk
+	var size int64
+	readers := make([]io.Reader, len(srcs))
+	for i, src := range srcs {
+		wc, err := lookupWAVEChunks(src)
+		if err != nil {
+			return 0, fmt.Errorf("srcs[%d]: %w", i, err)
+		}
+
+		b, err := ReadPayload(wc.format)
+		if err != nil {
+			return 0, fmt.Errorf("srcs[%d]: fmt: %w", i, err)
+		}
+		if i == 0 {
+			format, info = b, wc.info
+		} else if string(b) != string(format) {
+			return 0, fmt.Errorf("srcs[%d]: %w", i, ErrFormatMismatch)
+		}
+
+		r, err := openPayload(wc.data)
+		if err != nil {
+			return 0, fmt.Errorf("srcs[%d]: data: %w", i, err)
+		}
+		readers[i] = r
+		size += r.Size()
+	}
+
+	c := &RIFFChunk{
+		FormType: waveType,
+		Payload:  []Chunk{&OnMemorySubChunk{ID: fmtID, Payload: format}},
+	}
+	if info != nil {
+		cc, err := cloneChunk(info)
+		if err != nil {
+			return 0, fmt.Errorf("LIST-INFO: %w", err)
+		}
+		c.Payload = append(c.Payload, cc)
+	}
+	if int64(c.BodySize())+HeaderBytes+size > math.MaxUint32 {
+		return 0, fmt.Errorf("concatenated data size %d exceeds the limit", size)
+	}
+	c.Payload = append(c.Payload, NewIncompleteSubChunk(dataID, io.MultiReader(readers...)))
+
+	cw, err := NewIncompleteChunkWriter(w, opts...)
+	if err != nil {
+		return 0, err
+	}
+	return cw.Write(c)
+}
+
+// SplitWAVE splits the WAVE at the byte offsets in the data chunk into len(offsets)+1 WAVEs.
+// The offsets must be in ascending order, in the data chunk, and multiples of the block align. (see WAVEBlockAlign)
+// Each WAVE has the fmt chunk, the data chunk streamed from c and the LIST-INFO chunk of c if it exists.
+func SplitWAVE(c *RIFFChunk, offsets []int64) ([]*RIFFChunk, error) {
+	wc, err := lookupWAVEChunks(c)
+	if err != nil {
+		return nil, err
+	}
+	blockAlign, err := wc.blockAlign()
+	if err != nil {
+		return nil, err
+	}
+	data, err := openPayload(wc.data)
+	if err != nil {
+		return nil, fmt.Errorf("data: %w", err)
+	}
+
+	bounds := make([]int64, 0, len(offsets)+2)
+	bounds = append(bounds, 0)
+	for _, off := range offsets {
+		if off <= bounds[len(bounds)-1] || off >= data.Size() {
+			return nil, fmt.Errorf("offset %d is out of order or range", off)
+		}
+		if off%blockAlign != 0 {
+			return nil, fmt.Errorf("offset %d is not a multiple of block align %d", off, blockAlign)
+		}
+		bounds = append(bounds, off)
+	}
+	bounds = append(bounds, data.Size())
+
+	parts := make([]*RIFFChunk, len(bounds)-1)
+	for i := range parts {
+		format, err := cloneChunk(wc.format)
+		if err != nil {
+			return nil, fmt.Errorf("fmt: %w", err)
+		}
+		part := &RIFFChunk{FormType: waveType, Payload: []Chunk{format}}
+		if wc.info != nil {
+			info, err := cloneChunk(wc.info)
+			if err != nil {
+				return nil, fmt.Errorf("LIST-INFO: %w", err)
+			}
+			part.Payload = append(part.Payload, info)
+		}
+		part.Payload = append(part.Payload, &InStreamSubChunk{
+			ID:            dataID,
+			SectionReader: io.NewSectionReader(data, bounds[i], bounds[i+1]-bounds[i]),
+		})
+		parts[i] = part
+	}
+	return parts, nil
+}
+
+// cloneChunk clones c with the own cursors of the sub-chunk payloads, so the clone can be written independently of c.
+func cloneChunk(c Chunk) (Chunk, error) {
+	switch cc := c.(type) {
+	case *ListChunk:
+		payload, err := cc.Chunks()
+		if err != nil {
+			return nil, err
+		}
+		clone := &ListChunk{ListType: cc.ListType, Payload: make([]Chunk, len(payload))}
+		for i, p := range payload {
+			if clone.Payload[i], err = cloneChunk(p); err != nil {
+				return nil, err
+			}
+		}
+		return clone, nil
+	case SubChunk:
+		r, err := openPayload(cc)
+		if err != nil {
+			return nil, err
+		}
+		var id [idBytes]byte
+		copy(id[:], cc.ChunkID())
+		return &InStreamSubChunk{ID: id, SectionReader: r}, nil
+	}
+	return nil, fmt.Errorf("chunk %q cannot be cloned", string(c.ChunkID()))
+}
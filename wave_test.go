@@ -0,0 +1,167 @@
+package riffbin_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/karupanerura/riffbin"
+)
+
+func readWAVE(t *testing.T, b []byte) *riffbin.RIFFChunk {
+	t.Helper()
+
+	var buf bytes.Buffer
+	waveChunks := newWaveChunks()
+	_, err := riffbin.NewCompletedChunkWriter(&buf).Write(&riffbin.RIFFChunk{
+		FormType: [4]byte{'W', 'A', 'V', 'E'},
+		Payload: []riffbin.Chunk{
+			waveChunks[0],
+			&riffbin.ListChunk{
+				ListType: [4]byte{'I', 'N', 'F', 'O'},
+				Payload: []riffbin.Chunk{
+					&riffbin.OnMemorySubChunk{ID: [4]byte{'I', 'N', 'A', 'M'}, Payload: []byte("name")},
+				},
+			},
+			&riffbin.OnMemorySubChunk{ID: [4]byte{'d', 'a', 't', 'a'}, Payload: b},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	riffChunk, err := riffbin.ReadSections(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return riffChunk
+}
+
+func readChunkPayload(t *testing.T, c *riffbin.RIFFChunk, name string) []byte {
+	t.Helper()
+
+	chunk, err := riffbin.LookupChunk(c, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(chunk.(riffbin.SubChunk)); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestConcatWAVE(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Concat", func(t *testing.T) {
+		t.Parallel()
+
+		f := writeTempFile(t, nil)
+		_, err := riffbin.ConcatWAVE(f, []*riffbin.RIFFChunk{
+			readWAVE(t, []byte{0x01, 0x00, 0x02, 0x00}),
+			readWAVE(t, []byte{0x03, 0x00}),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := os.ReadFile(f.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		riffChunk, err := riffbin.ReadSections(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := readChunkPayload(t, riffChunk, "data"); !bytes.Equal(got, []byte{0x01, 0x00, 0x02, 0x00, 0x03, 0x00}) {
+			t.Errorf("unexpected data: %v", got)
+		}
+		if got := readChunkPayload(t, riffChunk, "LIST-INFO/INAM"); string(got) != "name" {
+			t.Errorf("unexpected INAM: %q", got)
+		}
+	})
+
+	t.Run("FormatMismatch", func(t *testing.T) {
+		t.Parallel()
+
+		other := readWAVE(t, []byte{0x03, 0x00})
+		other.Payload[0] = &riffbin.OnMemorySubChunk{ID: [4]byte{'f', 'm', 't', ' '}, Payload: make([]byte, 16)}
+
+		f := writeTempFile(t, nil)
+		_, err := riffbin.ConcatWAVE(f, []*riffbin.RIFFChunk{readWAVE(t, []byte{0x01, 0x00}), other})
+		if !errors.Is(err, riffbin.ErrFormatMismatch) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestSplitWAVE(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Split", func(t *testing.T) {
+		t.Parallel()
+
+		parts, err := riffbin.SplitWAVE(readWAVE(t, []byte{0x01, 0x00, 0x02, 0x00, 0x03, 0x00}), []int64{2, 4})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(parts) != 3 {
+			t.Fatalf("unexpected number of parts: %d", len(parts))
+		}
+
+		for i, part := range parts {
+			var buf bytes.Buffer
+			if _, err := riffbin.NewCompletedChunkWriter(&buf).Write(part); err != nil {
+				t.Fatal(err)
+			}
+			riffChunk, err := riffbin.ReadSections(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := readChunkPayload(t, riffChunk, "data"); !bytes.Equal(got, []byte{byte(i + 1), 0x00}) {
+				t.Errorf("parts[%d]: unexpected data: %v", i, got)
+			}
+			if got := readChunkPayload(t, riffChunk, "LIST-INFO/INAM"); string(got) != "name" {
+				t.Errorf("parts[%d]: unexpected INAM: %q", i, got)
+			}
+		}
+	})
+
+	t.Run("InvalidOffsets", func(t *testing.T) {
+		t.Parallel()
+
+		wave := readWAVE(t, []byte{0x01, 0x00, 0x02, 0x00, 0x03, 0x00})
+		for _, offsets := range [][]int64{{0}, {6}, {4, 2}, {3}} {
+			if _, err := riffbin.SplitWAVE(wave, offsets); err == nil {
+				t.Errorf("%v: error is expected", offsets)
+			}
+		}
+	})
+
+	t.Run("CueOffsets", func(t *testing.T) {
+		t.Parallel()
+
+		cue := make([]byte, 4+24*3)
+		binary.LittleEndian.PutUint32(cue, 3)
+		for i, sampleOffset := range []uint32{2, 1, 2} {
+			binary.LittleEndian.PutUint32(cue[4+24*i:], uint32(i))
+			copy(cue[4+24*i+8:], "data")
+			binary.LittleEndian.PutUint32(cue[4+24*i+20:], sampleOffset)
+		}
+
+		wave := readWAVE(t, []byte{0x01, 0x00, 0x02, 0x00, 0x03, 0x00})
+		wave.Payload = append(wave.Payload, &riffbin.OnMemorySubChunk{ID: [4]byte{'c', 'u', 'e', ' '}, Payload: cue})
+		offsets, err := riffbin.WAVECueOffsets(wave)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if df := cmp.Diff(offsets, []int64{2, 4}); df != "" {
+			t.Errorf("diff = %s", df)
+		}
+	})
+}